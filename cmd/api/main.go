@@ -1,14 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
+	_ "time/tzdata" // time zones of user profiles work without the system database
 
 	"github.com/henok-tesfu/expense-manager/internal/database"
 	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/loginguard"
+	"github.com/henok-tesfu/expense-manager/internal/mailer"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/password"
 	"github.com/henok-tesfu/expense-manager/internal/routes"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/storage"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+	"github.com/henok-tesfu/expense-manager/internal/workers"
 	"github.com/joho/godotenv"
 )
 
@@ -16,23 +31,110 @@ func main() {
 	// Load environment variables
 	loadEnvOrPanic()
 
+	// Register custom validation rules
+	utils.RegisterCustomValidations()
+
 	// Connect to the database and defer closing the connection
 	database.ConnectDatabase()
 	defer closeDatabase()
 
-	// Load environment variables or set default configurations
+	// Load the signing keys or fall back to the single secrets
+	accessKeys, err := newKeyring("ACCESS_KEYRING", "ACCESS_SECRET")
+	if err != nil {
+		log.Fatalf("Error loading access token keys: %v", err)
+	}
+	refreshKeys, err := newKeyring("REFRESH_KEYRING", "REFRESH_SECRET")
+	if err != nil {
+		log.Fatalf("Error loading refresh token keys: %v", err)
+	}
 	jwtConfig := jwt.Config{
-		AccessSecret:       []byte(os.Getenv("ACCESS_SECRET")),
-		RefreshSecret:      []byte(os.Getenv("REFRESH_SECRET")),
+		AccessKeys:         accessKeys,
+		RefreshKeys:        refreshKeys,
 		AccessTokenExpiry:  15 * time.Minute,
 		RefreshTokenExpiry: 7 * 24 * time.Hour,
 	}
 
-	// Initialize the TokenService
-	tokenService := jwt.NewTokenService(jwtConfig)
+	// Initialize the TokenService, which checks sessions against the SessionService
+	sessionService := services.NewSessionService()
+	tokenService := jwt.NewTokenService(jwtConfig, sessionService)
+
+	// Initialize blob storage for receipts
+	store, err := newStorage()
+	if err != nil {
+		log.Fatalf("Error initializing storage: %v", err)
+	}
+
+	// Initialize the mailer for password reset and verification emails
+	mail, err := newMailer()
+	if err != nil {
+		log.Fatalf("Error initializing mailer: %v", err)
+	}
+
+	// Hash passwords with argon2id, tuned by PASSWORD_ARGON2_* variables
+	hasher, err := newPasswordHasher()
+	if err != nil {
+		log.Fatalf("Error initializing password hashing: %v", err)
+	}
+
+	// Decide which new passwords are accepted, tuned by PASSWORD_* variables
+	policy, err := newPasswordPolicy()
+	if err != nil {
+		log.Fatalf("Error initializing password policy: %v", err)
+	}
+
+	// Count failed logins where every instance sees them
+	guard, err := newLoginGuard()
+	if err != nil {
+		log.Fatalf("Error initializing login protection: %v", err)
+	}
+
+	// Sign data export download links with EXPORT_LINK_SECRET
+	exportLinkSecret, err := newExportLinkSecret()
+	if err != nil {
+		log.Fatalf("Error initializing data export links: %v", err)
+	}
+
+	// By default users who have not verified their email can read but not change data
+	routesConfig := routes.Config{
+		Storage:              store,
+		Mailer:               mail,
+		PasswordHasher:       hasher,
+		PasswordPolicy:       policy,
+		LoginGuard:           guard,
+		PasswordResetURL:     envOr("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		EmailVerificationURL: envOr("EMAIL_VERIFICATION_URL", "http://localhost:8000/api/verify-email"),
+		ExportLinkSecret:     exportLinkSecret,
+		TOTPIssuer:           envOr("TOTP_ISSUER", "Expense Manager"),
+		UnverifiedAccess:     envOr("UNVERIFIED_USER_ACCESS", middleware.UnverifiedAccessReadOnly),
+	}
+	switch routesConfig.UnverifiedAccess {
+	case middleware.UnverifiedAccessFull, middleware.UnverifiedAccessReadOnly, middleware.UnverifiedAccessNone:
+	default:
+		log.Fatalf("Unknown UNVERIFIED_USER_ACCESS %q", routesConfig.UnverifiedAccess)
+	}
+
+	// Stop background workers on shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Build the services once for the routes and the background workers
+	svc := routes.NewServices(tokenService, sessionService, routesConfig)
+
+	// Materialize recurring expenses in the background
+	go workers.NewRecurringMaterializer(svc.Recurring, time.Hour).Run(ctx)
+
+	// Purge deleted accounts once ACCOUNT_DELETION_GRACE_PERIOD (30 days by default) has passed
+	gracePeriod, err := time.ParseDuration(envOr("ACCOUNT_DELETION_GRACE_PERIOD", "720h"))
+	if err != nil || gracePeriod < 0 {
+		log.Fatalf("Invalid ACCOUNT_DELETION_GRACE_PERIOD %q", os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"))
+	}
+	go workers.NewAccountPurger(svc.User, gracePeriod, time.Hour).Run(ctx)
+
+	// Build requested data exports and remove expired ones
+	go workers.NewDataExporter(svc.DataExport, 10*time.Second).Run(ctx)
 
 	// Initialize routes
-	router := routes.InitRoutes(tokenService)
+	router := routes.InitRoutes(tokenService, svc, routesConfig)
 
 	// Set the server port
 	port := os.Getenv("PORT")
@@ -41,8 +143,190 @@ func main() {
 	}
 
 	// Start the server
-	log.Println("Server starting on port", port)
-	log.Fatal(http.ListenAndServe(":"+port, router))
+	server := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		log.Println("Server starting on port", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Wait for a shutdown signal, then let in-flight requests finish
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server shutdown error:", err)
+	}
+}
+
+// newStorage configures receipt storage from STORAGE_DRIVER: "local" (the
+// default) keeps files below STORAGE_PATH, "s3" uses an S3-compatible bucket
+func newStorage() (storage.Storage, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_PATH")
+		if root == "" {
+			root = "uploads"
+		}
+		return storage.NewLocal(root)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
+
+// newMailer configures email from MAIL_DRIVER: "outbox" (the default) writes
+// messages below MAIL_OUTBOX_PATH, or only logs them when it is empty, and
+// "smtp" sends them through SMTP_HOST
+func newMailer() (mailer.Mailer, error) {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "", "outbox":
+		return mailer.NewOutbox(os.Getenv("MAIL_OUTBOX_PATH"))
+	case "smtp":
+		port := 0
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			var err error
+			if port, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", value)
+			}
+		}
+		return mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+}
+
+// newPasswordHasher configures argon2id from PASSWORD_ARGON2_MEMORY (in KiB),
+// PASSWORD_ARGON2_ITERATIONS and PASSWORD_ARGON2_PARALLELISM, defaulting to
+// password.DefaultParams. Changing them rehashes passwords as users log in.
+func newPasswordHasher() (*password.Argon2id, error) {
+	params := password.DefaultParams
+	for _, setting := range []struct {
+		name  string
+		value *uint32
+	}{
+		{"PASSWORD_ARGON2_MEMORY", &params.Memory},
+		{"PASSWORD_ARGON2_ITERATIONS", &params.Iterations},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			parsed, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", setting.name, value)
+			}
+			*setting.value = uint32(parsed)
+		}
+	}
+	if value := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_ARGON2_PARALLELISM %q", value)
+		}
+		params.Parallelism = uint8(parsed)
+	}
+	return password.NewArgon2id(params)
+}
+
+// newPasswordPolicy configures the password policy from PASSWORD_MIN_LENGTH,
+// PASSWORD_MAX_LENGTH, PASSWORD_MIN_CHARACTER_CLASSES, PASSWORD_MIN_STRENGTH
+// and PASSWORD_ALLOW_PERSONAL_INFO, defaulting to password.DefaultPolicy.
+// BREACHED_PASSWORDS_DIR names a local copy of the breached password ranges.
+func newPasswordPolicy() (*password.Policy, error) {
+	policy := password.DefaultPolicy
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"PASSWORD_MIN_LENGTH", &policy.MinLength},
+		{"PASSWORD_MAX_LENGTH", &policy.MaxLength},
+		{"PASSWORD_MIN_CHARACTER_CLASSES", &policy.MinCharacterClasses},
+		{"PASSWORD_MIN_STRENGTH", &policy.MinStrength},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return nil, fmt.Errorf("invalid %s %q", setting.name, value)
+			}
+			*setting.value = parsed
+		}
+	}
+	if os.Getenv("PASSWORD_ALLOW_PERSONAL_INFO") == "true" {
+		policy.DisallowPersonalInfo = false
+	}
+	if dir := os.Getenv("BREACHED_PASSWORDS_DIR"); dir != "" {
+		breached, err := password.NewRangeDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+	return &policy, nil
+}
+
+// newLoginGuard configures where failed logins are counted from
+// LOGIN_GUARD_STORE: "mysql" (the default) shares the counters between
+// instances, "memory" keeps them in the process
+func newLoginGuard() (*loginguard.Guard, error) {
+	var store loginguard.Store
+	switch driver := os.Getenv("LOGIN_GUARD_STORE"); driver {
+	case "", "mysql":
+		store = loginguard.NewMySQLStore(database.DB)
+	case "memory":
+		store = loginguard.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_GUARD_STORE %q", driver)
+	}
+	return loginguard.New(store, loginguard.DefaultAccountPolicy, loginguard.DefaultIPPolicy), nil
+}
+
+// newKeyring loads the keyring file named by the fileVar environment variable,
+// or makes a single HS256 key from the secretVar one when no file is set
+func newKeyring(fileVar, secretVar string) (*jwt.Keyring, error) {
+	if path := os.Getenv(fileVar); path != "" {
+		return jwt.LoadKeyring(path)
+	}
+	secret := os.Getenv(secretVar)
+	if secret == "" {
+		return nil, fmt.Errorf("%s or %s is required", fileVar, secretVar)
+	}
+	return jwt.NewSecretKeyring([]byte(secret)), nil
+}
+
+// newExportLinkSecret reads EXPORT_LINK_SECRET, or makes a random secret when
+// it is not set, which invalidates links on restart and across instances
+func newExportLinkSecret() ([]byte, error) {
+	if secret := os.Getenv("EXPORT_LINK_SECRET"); secret != "" {
+		return []byte(secret), nil
+	}
+	log.Println("EXPORT_LINK_SECRET is not set; data export links only work on this instance until it restarts")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// envOr returns the environment variable name, or fallback when it is not set
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
 }
 
 func closeDatabase() {
@@ -60,7 +344,7 @@ func loadEnvOrPanic() {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	requiredVars := []string{"DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT", "DB_NAME", "ACCESS_SECRET", "REFRESH_SECRET"}
+	requiredVars := []string{"DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT", "DB_NAME"}
 	for _, v := range requiredVars {
 		if os.Getenv(v) == "" {
 			log.Fatalf("Environment variable %s is required but not set", v)
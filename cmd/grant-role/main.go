@@ -0,0 +1,77 @@
+// Command grant-role gives a user a staff role, or takes it away with
+// -revoke, for setting up the first admin who can then manage roles through
+// the API. The change is recorded in the admin audit log without an actor.
+//
+//	go run ./cmd/grant-role -email admin@example.com -role admin
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/rbac"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user")
+	role := flag.String("role", "", "role to grant: "+strings.Join(rbac.Roles(), " or "))
+	revoke := flag.Bool("revoke", false, "take the role away instead of granting it")
+	flag.Parse()
+
+	if *email == "" || *role == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !rbac.ValidRole(*role) {
+		log.Fatalf("Unknown role %q; roles are %s", *role, strings.Join(rbac.Roles(), ", "))
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	// Connect to the database and defer closing the connection
+	database.ConnectDatabase()
+	defer database.DB.Close()
+
+	user, err := models.GetUserByEmail(*email)
+	if err != nil {
+		log.Fatalf("Error looking up user: %v", err)
+	}
+	if user == nil {
+		log.Fatalf("No user with email %s", *email)
+	}
+
+	action, changed := models.AdminActionGrantRole, false
+	if *revoke {
+		action = models.AdminActionRevokeRole
+		if changed, err = models.RevokeRole(user.ID, *role); err == nil && changed {
+			// Access tokens still carry the role until their sessions end
+			_, err = models.RevokeAllSessions(user.ID)
+		}
+	} else {
+		changed, err = models.GrantRole(user.ID, *role, nil)
+	}
+	if err != nil {
+		log.Fatalf("Error changing role: %v", err)
+	}
+	if !changed {
+		log.Printf("Nothing to do: %s is already as asked for user %d", *role, user.ID)
+		return
+	}
+
+	if err := models.RecordAdminAction(&models.AdminAuditEntry{
+		Action:       action,
+		TargetUserID: &user.ID,
+		Detail:       *role + " (command line)",
+	}); err != nil {
+		log.Fatalf("Error recording the change in the audit log: %v", err)
+	}
+	log.Printf("Done: %s %s for user %d", action, *role, user.ID)
+}
@@ -0,0 +1,62 @@
+// Command import-rates loads an ECB-style CSV or XML exchange-rate file into
+// the exchange_rates table, for deployments without network access.
+//
+//	go run ./cmd/import-rates -file eurofxref-hist.csv
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+	"github.com/henok-tesfu/expense-manager/internal/ecb"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "rate file to import, or - for standard input")
+	format := flag.String("format", "", "file format (csv or xml), detected from the file name when omitted")
+	base := flag.String("base", ecb.Base, "currency the file's rates are quoted against")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Error opening rate file: %v", err)
+		}
+		defer f.Close()
+		input = f
+	}
+	if *format == "" {
+		*format = ecb.FormatFromName(*file)
+	}
+
+	// Connect to the database and defer closing the connection
+	database.ConnectDatabase()
+	defer database.DB.Close()
+
+	result, err := services.NewExchangeRateService().Import(input, strings.ToLower(*format), strings.ToUpper(*base))
+	if err != nil {
+		log.Fatalf("Error importing exchange rates: %v", err)
+	}
+
+	log.Printf("Imported %d exchange rates", result.Imported)
+	if len(result.Skipped) > 0 {
+		log.Printf("Skipped unknown currencies: %s", strings.Join(result.Skipped, ", "))
+	}
+}
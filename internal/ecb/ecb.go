@@ -0,0 +1,172 @@
+// Package ecb parses exchange-rate files in the formats published by the
+// European Central Bank, so rates can be loaded without network access:
+//
+//   - CSV, either the daily file ("Date, USD, JPY, ...", dates such as
+//     "17 October 2024") or the historical one (ISO dates, "N/A" for gaps)
+//   - XML, the gesmes envelope with nested Cube elements
+//
+// Every rate is quoted against a single base currency, EUR for ECB files.
+package ecb
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Formats
+const (
+	FormatCSV = "csv"
+	FormatXML = "xml"
+)
+
+// Base is the currency ECB rates are quoted against
+const Base = "EUR"
+
+var (
+	ErrUnknownFormat = errors.New("unknown rate file format")
+	ErrInvalidFile   = errors.New("invalid rate file")
+)
+
+// Rate is one quote read from a file: one unit of the base currency was worth
+// Rate units of Currency on Date
+type Rate struct {
+	Date     time.Time
+	Currency string
+	Rate     string
+}
+
+var dateLayouts = []string{"2006-01-02", "2 January 2006", "02 January 2006"}
+
+// Parse reads a rate file in the given format
+func Parse(r io.Reader, format string) ([]Rate, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatXML:
+		return ParseXML(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ParseCSV reads a file with a "Date" column followed by one column per currency
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "Date") {
+		return nil, fmt.Errorf("%w: line 1: the first column must be Date", ErrInvalidFile)
+	}
+
+	rates := []Rate{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		date, err := parseDate(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			currency := strings.ToUpper(strings.TrimSpace(header[i]))
+			value := strings.TrimSpace(record[i])
+			if currency == "" || value == "" || value == "N/A" {
+				continue
+			}
+			if err := checkRate(value); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %s: %v", ErrInvalidFile, line, currency, err)
+			}
+			rates = append(rates, Rate{Date: date, Currency: currency, Rate: value})
+		}
+	}
+	return rates, nil
+}
+
+type envelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// ParseXML reads a gesmes envelope as published by the ECB
+func ParseXML(r io.Reader) ([]Rate, error) {
+	var doc envelope
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	rates := []Rate{}
+	for _, day := range doc.Cube.Days {
+		date, err := parseDate(day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%w: Cube time=%q: %v", ErrInvalidFile, day.Time, err)
+		}
+		for _, rate := range day.Rates {
+			currency := strings.ToUpper(strings.TrimSpace(rate.Currency))
+			value := strings.TrimSpace(rate.Rate)
+			if err := checkRate(value); err != nil {
+				return nil, fmt.Errorf("%w: %s %s: %v", ErrInvalidFile, day.Time, currency, err)
+			}
+			rates = append(rates, Rate{Date: date, Currency: currency, Rate: value})
+		}
+	}
+	return rates, nil
+}
+
+// FormatFromName guesses the format of a file from its extension or media type
+func FormatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".csv"), strings.Contains(name, "text/csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".xml"), strings.Contains(name, "/xml"):
+		return FormatXML
+	}
+	return ""
+}
+
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+func checkRate(s string) error {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 || strings.ContainsAny(s, "eE/") {
+		return fmt.Errorf("invalid rate %q", s)
+	}
+	return nil
+}
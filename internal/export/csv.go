@@ -0,0 +1,45 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+type csvWriter struct {
+	w     *csv.Writer
+	names Names
+}
+
+func newCSVWriter(w io.Writer, names Names) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w), names: names}
+	if err := writer.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (c *csvWriter) Write(e *models.Expense) error {
+	fields := record(e, c.names)
+	// The free text columns from Category on are escaped
+	for i := 4; i < len(fields); i++ {
+		fields[i] = EscapeFormula(fields[i])
+	}
+	return c.w.Write(fields)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// EscapeFormula prefixes text that spreadsheets would run as a formula, as
+// they do for text starting with =, +, -, @, a tab or a carriage return
+func EscapeFormula(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
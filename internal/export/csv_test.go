@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@cmd", "'@cmd"},
+		{"\tTab", "'\tTab"},
+		{"\rReturn", "'\rReturn"},
+		{"", ""},
+		{"Groceries", "Groceries"},
+		{"a=b", "a=b"},
+		{" =1", " =1"},
+	}
+	for _, tt := range tests {
+		if got := EscapeFormula(tt.field); got != tt.want {
+			t.Errorf("EscapeFormula(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV, testExpenses(t)))).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"ID", "Date", "Amount", "Currency", "Category", "Account", "Payee", "Description"},
+		{"1", "2024-01-05", "12.50", "USD", "Food / Groceries", "Checking", `'=HYPERLINK("http://x")`, "Weekly shop"},
+		{"2", "2024-01-06", "3.20", "USD", "Food / Groceries", "", "Kiosk", "'-coffee"},
+		{"3", "2024-01-07", "40.00", "EUR", "", "", "Café & Bar <Berlin>", "Dinner"},
+		{"4", "2024-01-08", "7.30", "USD", "", "Checking", "Bakery", "'@lunch"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Package export writes expenses to files for use outside the application,
+// one expense at a time so that large exports never sit in memory.
+package export
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// Formats
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatXLSX  = "xlsx"
+)
+
+// ErrUnknownFormat is returned for export formats without a writer
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// contentTypes holds the media type of every format
+var contentTypes = map[string]string{
+	FormatCSV:   "text/csv; charset=utf-8",
+	FormatJSONL: "application/x-ndjson",
+	FormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// columns are the fields of every exported expense, in order
+var columns = []string{"ID", "Date", "Amount", "Currency", "Category", "Account", "Payee", "Description"}
+
+// Names resolves the category and account IDs of expenses to display names
+type Names struct {
+	Categories map[int]string
+	Accounts   map[int]string
+}
+
+func (n Names) category(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return n.Categories[*id]
+}
+
+func (n Names) account(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return n.Accounts[*id]
+}
+
+// Writer writes expenses in one format. Close must be called to complete the file.
+type Writer interface {
+	Write(e *models.Expense) error
+	Close() error
+}
+
+// NewWriter returns a writer for the format
+func NewWriter(format string, w io.Writer, names Names) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, names)
+	case FormatJSONL:
+		return newJSONLWriter(w, names), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, names)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ContentType returns the media type of the format, or false for unknown formats
+func ContentType(format string) (string, bool) {
+	contentType, ok := contentTypes[format]
+	return contentType, ok
+}
+
+// CategoryPaths names every category after its ancestors, e.g. "Transport / Fuel"
+func CategoryPaths(categories []*models.Category) map[int]string {
+	byID := make(map[int]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	paths := make(map[int]string, len(categories))
+	for _, c := range categories {
+		names := []string{c.Name}
+		seen := map[int]bool{c.ID: true}
+		for parent := c.ParentID; parent != nil && !seen[*parent]; {
+			p, ok := byID[*parent]
+			if !ok {
+				break
+			}
+			seen[p.ID] = true
+			names = append([]string{p.Name}, names...)
+			parent = p.ParentID
+		}
+		paths[c.ID] = strings.Join(names, " / ")
+	}
+	return paths
+}
+
+// record returns the column values of an expense as text
+func record(e *models.Expense, names Names) []string {
+	return []string{
+		strconv.Itoa(e.ID),
+		e.Date.Format("2006-01-02"),
+		e.Amount.Decimal(),
+		e.Amount.Currency(),
+		names.category(e.CategoryID),
+		names.account(e.AccountID),
+		e.Payee,
+		e.Description,
+	}
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// testNames resolve the categories and accounts of testExpenses
+var testNames = Names{
+	Categories: map[int]string{1: "Food / Groceries"},
+	Accounts:   map[int]string{7: "Checking"},
+}
+
+// testExpenses returns expenses with text a spreadsheet would run as a
+// formula, text that has to be escaped in XML, and several currencies
+func testExpenses(t *testing.T) []*models.Expense {
+	t.Helper()
+	category, account := 1, 7
+	expense := func(id int, day, amount string, categoryID, accountID *int, payee, description string) *models.Expense {
+		parsed, err := money.Parse(amount)
+		if err != nil {
+			t.Fatalf("money.Parse(%q): %v", amount, err)
+		}
+		date, _ := time.Parse("2006-01-02", day)
+		return &models.Expense{ID: id, Amount: parsed, Date: date, CategoryID: categoryID, AccountID: accountID,
+			Payee: payee, Description: description}
+	}
+	return []*models.Expense{
+		expense(1, "2024-01-05", "12.50 USD", &category, &account, `=HYPERLINK("http://x")`, "Weekly shop"),
+		expense(2, "2024-01-06", "3.20 USD", &category, nil, "Kiosk", "-coffee"),
+		expense(3, "2024-01-07", "40.00 EUR", nil, nil, "Café & Bar <Berlin>", "Dinner"),
+		expense(4, "2024-01-08", "7.30 USD", nil, &account, "Bakery", "@lunch"),
+	}
+}
+
+// writeAll writes expenses in the format and returns the file
+func writeAll(t *testing.T, format string, expenses []*models.Expense) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf, testNames)
+	if err != nil {
+		t.Fatalf("NewWriter(%s): %v", format, err)
+	}
+	for _, e := range expenses {
+		if err := writer.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("pdf", &bytes.Buffer{}, testNames); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("NewWriter(pdf): err = %v, want ErrUnknownFormat", err)
+	}
+	if _, ok := ContentType("pdf"); ok {
+		t.Error("ContentType(pdf) is known")
+	}
+}
+
+func TestCategoryPaths(t *testing.T) {
+	transport, fuel, loop := 1, 2, 4
+	paths := CategoryPaths([]*models.Category{
+		{ID: transport, Name: "Transport"},
+		{ID: fuel, ParentID: &transport, Name: "Fuel"},
+		{ID: 3, ParentID: &fuel, Name: "Diesel"},
+		// A category whose parent is itself must not loop forever
+		{ID: loop, ParentID: &loop, Name: "Loop"},
+	})
+	want := map[int]string{1: "Transport", 2: "Transport / Fuel", 3: "Transport / Fuel / Diesel", 4: "Loop"}
+	for id, path := range want {
+		if paths[id] != path {
+			t.Errorf("path of %d = %q, want %q", id, paths[id], path)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// jsonlExpense is one line of a JSON Lines export. Amounts are decimal
+// strings so that no precision is lost.
+type jsonlExpense struct {
+	ID          int    `json:"id"`
+	Date        string `json:"date"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	CategoryID  *int   `json:"category_id"`
+	Category    string `json:"category"`
+	AccountID   *int   `json:"account_id"`
+	Account     string `json:"account"`
+	Payee       string `json:"payee"`
+	Description string `json:"description"`
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+	names   Names
+}
+
+func newJSONLWriter(w io.Writer, names Names) *jsonlWriter {
+	return &jsonlWriter{encoder: json.NewEncoder(w), names: names}
+}
+
+// Write encodes the expense as one JSON object followed by a newline
+func (j *jsonlWriter) Write(e *models.Expense) error {
+	return j.encoder.Encode(jsonlExpense{
+		ID:          e.ID,
+		Date:        e.Date.Format("2006-01-02"),
+		Amount:      e.Amount.Decimal(),
+		Currency:    e.Amount.Currency(),
+		CategoryID:  e.CategoryID,
+		Category:    j.names.category(e.CategoryID),
+		AccountID:   e.AccountID,
+		Account:     j.names.account(e.AccountID),
+		Payee:       e.Payee,
+		Description: e.Description,
+	})
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	decoder := json.NewDecoder(bytes.NewReader(writeAll(t, FormatJSONL, testExpenses(t))))
+	var lines []jsonlExpense
+	for {
+		var line jsonlExpense
+		err := decoder.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decoding line %d: %v", len(lines)+1, err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(lines))
+	}
+
+	first := lines[0]
+	if first.ID != 1 || first.Date != "2024-01-05" || first.Amount != "12.50" || first.Currency != "USD" ||
+		first.CategoryID == nil || *first.CategoryID != 1 || first.Category != "Food / Groceries" ||
+		first.AccountID == nil || *first.AccountID != 7 || first.Account != "Checking" {
+		t.Errorf("line 1 = %+v", first)
+	}
+	// JSON is not opened by spreadsheets, so text is kept as it is
+	if first.Payee != `=HYPERLINK("http://x")` || lines[1].Description != "-coffee" {
+		t.Errorf("text was changed: %q, %q", first.Payee, lines[1].Description)
+	}
+	third := lines[2]
+	if third.CategoryID != nil || third.Category != "" || third.AccountID != nil || third.Payee != "Café & Bar <Berlin>" ||
+		third.Amount != "40.00" || third.Currency != "EUR" {
+		t.Errorf("line 3 = %+v", third)
+	}
+}
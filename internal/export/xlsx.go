@@ -0,0 +1,252 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// The fixed parts of a workbook with an "Expenses" and a "Summary" sheet.
+// Cell style 1 formats dates and style 2 is bold, for header rows.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Expenses" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// Cell styles defined in xlsxStyles
+const (
+	styleDate = 1
+	styleBold = 2
+)
+
+// excelEpoch is day zero of spreadsheet date serial numbers
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxCell is a cell value; numbers and dates are written as numbers, everything else as text
+type xlsxCell struct {
+	value  string
+	number bool
+	style  int
+}
+
+// categoryTotal sums the expenses of one category in one currency for the summary sheet
+type categoryTotal struct {
+	category string
+	count    int
+	total    money.Amount
+}
+
+// xlsxWriter streams expenses into the first sheet of a workbook. Only the
+// per category totals of the summary sheet are kept in memory.
+type xlsxWriter struct {
+	zip    *zip.Writer
+	sheet  io.Writer
+	names  Names
+	rows   int
+	totals map[string]*categoryTotal
+}
+
+func newXLSXWriter(w io.Writer, names Names) (*xlsxWriter, error) {
+	writer := &xlsxWriter{zip: zip.NewWriter(w), names: names, totals: map[string]*categoryTotal{}}
+
+	var err error
+	if writer.sheet, err = writer.zip.Create("xl/worksheets/sheet1.xml"); err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(writer.sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	if err := writeXLSXHeader(writer.sheet, columns); err != nil {
+		return nil, err
+	}
+	writer.rows = 1
+	return writer, nil
+}
+
+func (x *xlsxWriter) Write(e *models.Expense) error {
+	fields := record(e, x.names)
+	cells := make([]xlsxCell, len(fields))
+	for i, field := range fields {
+		cells[i] = xlsxCell{value: field}
+	}
+	cells[0].number = true
+	cells[1] = xlsxCell{value: strconv.Itoa(int(e.Date.Sub(excelEpoch) / (24 * time.Hour))), number: true, style: styleDate}
+	cells[2].number = true
+
+	x.rows++
+	if err := writeXLSXRow(x.sheet, x.rows, cells); err != nil {
+		return err
+	}
+
+	category := fields[4]
+	if category == "" {
+		category = "Uncategorized"
+	}
+	key := category + "\x00" + e.Amount.Currency()
+	total, ok := x.totals[key]
+	if !ok {
+		total = &categoryTotal{category: category, total: e.Amount}
+		x.totals[key] = total
+	} else {
+		sum, err := total.total.Add(e.Amount)
+		if err != nil {
+			return err
+		}
+		total.total = sum
+	}
+	total.count++
+	return nil
+}
+
+// Close finishes the expense sheet and writes the summary sheet and the rest of the workbook
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := x.summary(); err != nil {
+		return err
+	}
+
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+	return x.zip.Close()
+}
+
+// summary writes one row per category and currency with the number of expenses and their total
+func (x *xlsxWriter) summary() error {
+	totals := make([]*categoryTotal, 0, len(x.totals))
+	for _, total := range x.totals {
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].category != totals[j].category {
+			return totals[i].category < totals[j].category
+		}
+		return totals[i].total.Currency() < totals[j].total.Currency()
+	})
+
+	sheet, err := x.zip.Create("xl/worksheets/sheet2.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return err
+	}
+	if err := writeXLSXHeader(sheet, []string{"Category", "Currency", "Expenses", "Total"}); err != nil {
+		return err
+	}
+	for i, total := range totals {
+		err := writeXLSXRow(sheet, i+2, []xlsxCell{
+			{value: total.category},
+			{value: total.total.Currency()},
+			{value: strconv.Itoa(total.count), number: true},
+			{value: total.total.Decimal(), number: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(sheet, xlsxSheetEnd)
+	return err
+}
+
+// writeXLSXHeader writes a bold first row
+func writeXLSXHeader(w io.Writer, names []string) error {
+	cells := make([]xlsxCell, len(names))
+	for i, name := range names {
+		cells[i] = xlsxCell{value: name, style: styleBold}
+	}
+	return writeXLSXRow(w, 1, cells)
+}
+
+func writeXLSXRow(w io.Writer, row int, cells []xlsxCell) error {
+	var b strings.Builder
+	ref := strconv.Itoa(row)
+	b.WriteString(`<row r="` + ref + `">`)
+	for i, cell := range cells {
+		b.WriteString(`<c r="` + columnName(i) + ref + `"`)
+		if cell.style != 0 {
+			b.WriteString(` s="` + strconv.Itoa(cell.style) + `"`)
+		}
+		if cell.number {
+			b.WriteString(`><v>` + cell.value + `</v></c>`)
+			continue
+		}
+		b.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(&b, []byte(cell.value))
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// columnName returns the spreadsheet name of a zero-based column index: A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// xlsxSheet is the part of a worksheet the tests read
+type xlsxSheet struct {
+	Rows []struct {
+		R     string `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readSheet parses a worksheet of the workbook into rows of cell values
+func readSheet(t *testing.T, workbook *zip.Reader, name string) [][]string {
+	t.Helper()
+	file, err := workbook.Open(name)
+	if err != nil {
+		t.Fatalf("opening %s: %v", name, err)
+	}
+	defer file.Close()
+
+	var sheet xlsxSheet
+	if err := xml.NewDecoder(file).Decode(&sheet); err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+
+	rows := [][]string{}
+	for i, row := range sheet.Rows {
+		values := []string{}
+		for j, cell := range row.Cells {
+			if want := columnName(j) + row.R; cell.R != want {
+				t.Errorf("%s row %d: cell %q, want %q", name, i+1, cell.R, want)
+			}
+			if i == 0 && cell.Style != styleBold {
+				t.Errorf("%s: header cell %s is not bold", name, cell.R)
+			}
+			if cell.Type == "inlineStr" {
+				values = append(values, cell.Inline)
+			} else {
+				values = append(values, cell.Value)
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeAll(t, FormatXLSX, testExpenses(t))
+	workbook, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("the workbook is not a ZIP file: %v", err)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		file, err := workbook.Open(name)
+		if err != nil {
+			t.Errorf("the workbook has no %s: %v", name, err)
+			continue
+		}
+		decoder := xml.NewDecoder(file)
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s is not valid XML: %v", name, err)
+				}
+				break
+			}
+		}
+		file.Close()
+	}
+
+	// Dates are serial numbers: 45296 is 2024-01-05. Inline strings are not
+	// run as formulas, so text is kept as it is.
+	expenses := readSheet(t, workbook, "xl/worksheets/sheet1.xml")
+	want := [][]string{
+		{"ID", "Date", "Amount", "Currency", "Category", "Account", "Payee", "Description"},
+		{"1", "45296", "12.50", "USD", "Food / Groceries", "Checking", `=HYPERLINK("http://x")`, "Weekly shop"},
+		{"2", "45297", "3.20", "USD", "Food / Groceries", "", "Kiosk", "-coffee"},
+		{"3", "45298", "40.00", "EUR", "", "", "Café & Bar <Berlin>", "Dinner"},
+		{"4", "45299", "7.30", "USD", "", "Checking", "Bakery", "@lunch"},
+	}
+	if !reflect.DeepEqual(expenses, want) {
+		t.Errorf("expense sheet = %q, want %q", expenses, want)
+	}
+
+	// One row per category and currency, sorted
+	summary := readSheet(t, workbook, "xl/worksheets/sheet2.xml")
+	wantSummary := [][]string{
+		{"Category", "Currency", "Expenses", "Total"},
+		{"Food / Groceries", "USD", "2", "15.70"},
+		{"Uncategorized", "EUR", "1", "40.00"},
+		{"Uncategorized", "USD", "1", "7.30"},
+	}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("summary sheet = %q, want %q", summary, wantSummary)
+	}
+}
+
+func TestXLSXWriterEmpty(t *testing.T) {
+	data := writeAll(t, FormatXLSX, nil)
+	workbook, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("the workbook is not a ZIP file: %v", err)
+	}
+	if rows := readSheet(t, workbook, "xl/worksheets/sheet2.xml"); len(rows) != 1 {
+		t.Errorf("summary of no expenses has %d rows, want only the header", len(rows))
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 7: "H", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
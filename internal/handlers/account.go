@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// AccountHandler contains dependencies for account-related operations
+type AccountHandler struct {
+	AccountService *services.AccountService
+}
+
+// AccountInput represents the input structure for creating or updating an account.
+// Identifier is the account number as it appears in imported statements.
+type AccountInput struct {
+	Name       string `json:"name" validate:"required,max=100" example:"Checking"`
+	Identifier string `json:"identifier" validate:"max=100" example:"123456789:0001234567"`
+	Currency   string `json:"currency" validate:"omitempty,currency" example:"USD"`
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		AccountService: accountService,
+	}
+}
+
+// Create handles account creation
+// @Summary Create an account
+// @Description Create a bank account or card; statement imports assign expenses to the account whose identifier matches the statement
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param AccountInput body AccountInput true "Account Input"
+// @Success 201 {object} SuccessResponse "Account created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/accounts [post]
+func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
+	account, ok := decodeAccountInput(w, r)
+	if !ok {
+		return
+	}
+	account.UserID = middleware.UserIDFromContext(r.Context())
+
+	newAccount, err := h.AccountService.CreateAccount(account)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create account")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Account created successfully", newAccount)
+}
+
+// List handles listing the user's accounts
+// @Summary List accounts
+// @Description List the authenticated user's accounts, including those created by statement imports
+// @Tags Account
+// @Produce json
+// @Success 200 {object} SuccessResponse "Accounts retrieved successfully"
+// @Router /api/accounts [get]
+func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.AccountService.ListAccounts(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list accounts", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Accounts retrieved successfully", accounts)
+}
+
+// Get handles fetching a single account
+// @Summary Get an account
+// @Description Get one of the authenticated user's accounts
+// @Tags Account
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} SuccessResponse "Account retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Account not found"
+// @Router /api/accounts/{id} [get]
+func (h *AccountHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.AccountService.GetAccount(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get account")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Account retrieved successfully", account)
+}
+
+// Update handles updating an account
+// @Summary Update an account
+// @Description Rename an account or change its statement identifier or currency
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param AccountInput body AccountInput true "Account Input"
+// @Success 200 {object} SuccessResponse "Account updated successfully"
+// @Failure 404 {object} ErrorResponse "Account not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/accounts/{id} [put]
+func (h *AccountHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	account, ok := decodeAccountInput(w, r)
+	if !ok {
+		return
+	}
+	account.ID = id
+	account.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.AccountService.UpdateAccount(account)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update account")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Account updated successfully", updated)
+}
+
+// Delete handles deleting an account
+// @Summary Delete an account
+// @Description Delete an account; its expenses are kept without an account
+// @Tags Account
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} SuccessResponse "Account deleted successfully"
+// @Failure 404 {object} ErrorResponse "Account not found"
+// @Router /api/accounts/{id} [delete]
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.AccountService.DeleteAccount(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete account")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Account deleted successfully", nil)
+}
+
+// decodeAccountInput decodes and validates the request body into an account
+func decodeAccountInput(w http.ResponseWriter, r *http.Request) (*models.Account, bool) {
+	var input AccountInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	account := &models.Account{Name: input.Name}
+	if identifier := strings.TrimSpace(input.Identifier); identifier != "" {
+		account.Identifier = &identifier
+	}
+	if input.Currency != "" {
+		account.Currency = &input.Currency
+	}
+	return account, true
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// defaultAdminPageSize is the number of users or audit log entries listed when no limit is given
+const defaultAdminPageSize = 50
+
+// AdminHandler contains dependencies for the staff operations on users
+type AdminHandler struct {
+	AdminService *services.AdminService
+}
+
+// DisableUserInput represents the optional body of disabling an account
+type DisableUserInput struct {
+	// Reason is kept in the audit log
+	Reason string `json:"reason" validate:"max=255" example:"Chargeback fraud"`
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		AdminService: adminService,
+	}
+}
+
+// ListUsers handles listing and searching users
+// @Summary List users
+// @Description List users, newest first, optionally searching their username and email. Needs the users:read permission.
+// @Tags Admin
+// @Produce json
+// @Param q query string false "Part of the username or email"
+// @Param role query string false "Role the users hold: admin or support"
+// @Param disabled query bool false "Only disabled (true) or enabled (false) users"
+// @Param limit query int false "Maximum number of users, 50 by default"
+// @Param offset query int false "Number of users to skip"
+// @Success 200 {object} SuccessResponse "Users retrieved successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 422 {object} ErrorResponse "Invalid filters"
+// @Router /api/admin/users [get]
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.UserFilter{Query: query.Get("q"), Role: query.Get("role")}
+	filterErrors := make(map[string]string)
+	if value := query.Get("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			filterErrors["disabled"] = "disabled must be true or false"
+		} else {
+			filter.Disabled = &disabled
+		}
+	}
+	filter.Limit, filter.Offset = parsePage(query, filterErrors)
+	if len(filterErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid filters", filterErrors)
+		return
+	}
+
+	users, err := h.AdminService.ListUsers(filter)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to list users")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Users retrieved successfully", users)
+}
+
+// GetUser handles viewing a user
+// @Summary Get a user
+// @Description Get a user with their roles. Needs the users:read permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse "User retrieved successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /api/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.AdminService.GetUser(id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "User retrieved successfully", user)
+}
+
+// DisableUser handles disabling an account
+// @Summary Disable an account
+// @Description Stop a user from logging in. Every session ends at once and personal access tokens stop working until the account is enabled again. Needs the users:disable permission; disabling users with roles needs roles:manage as well.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param DisableUserInput body DisableUserInput false "Reason for the audit log"
+// @Success 200 {object} SuccessResponse "User disabled successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 422 {object} ErrorResponse "Validation errors or own account"
+// @Router /api/admin/users/{id}/disable [post]
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var input DisableUserInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	user, err := h.AdminService.DisableUser(actorFrom(r), id, input.Reason)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to disable user")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "User disabled successfully", user)
+}
+
+// EnableUser handles enabling a disabled account
+// @Summary Enable an account
+// @Description Let a disabled user log in again. Needs the users:disable permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse "User enabled successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Router /api/admin/users/{id}/enable [post]
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.AdminService.EnableUser(actorFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to enable user")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "User enabled successfully", user)
+}
+
+// ResetTwoFactor handles turning off the two-factor authentication of a user
+// @Summary Reset two-factor authentication
+// @Description Turn off two-factor authentication for a user who lost their authenticator and recovery codes; they log in with the password alone until they enroll again. Needs the users:reset_2fa permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse "Two-factor authentication reset successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 422 {object} ErrorResponse "Two-factor authentication is not enabled"
+// @Router /api/admin/users/{id}/2fa [delete]
+func (h *AdminHandler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.AdminService.ResetTwoFactor(actorFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to reset two-factor authentication")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Two-factor authentication reset successfully", user)
+}
+
+// Impersonate handles acting as a user
+// @Summary Impersonate a user
+// @Description Get an access token to use the API as a user, to see what they see. The session is read-only, cannot be refreshed, ends with the access token, and is recorded in the audit log and the user's session list. Users with roles cannot be impersonated. Needs the users:impersonate permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse "Impersonation started successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 422 {object} ErrorResponse "Own account, user with roles or disabled user"
+// @Router /api/admin/users/{id}/impersonate [post]
+func (h *AdminHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	impersonation, err := h.AdminService.Impersonate(actorFrom(r), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to impersonate user")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Impersonation started successfully", impersonation)
+}
+
+// GrantRole handles giving a user a role
+// @Summary Grant a role
+// @Description Give a user a role; their access tokens carry it from their next login or token refresh. Needs the roles:manage permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role: admin or support"
+// @Success 200 {object} SuccessResponse "Role granted successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 422 {object} ErrorResponse "Unknown role or own account"
+// @Router /api/admin/users/{id}/roles/{role} [put]
+func (h *AdminHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.AdminService.GrantRole(actorFrom(r), id, mux.Vars(r)["role"])
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to grant role")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Role granted successfully", user)
+}
+
+// RevokeRole handles taking a role from a user
+// @Summary Revoke a role
+// @Description Take a role from a user. Their sessions end at once, so that no access token with the role remains. Needs the roles:manage permission.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role: admin or support"
+// @Success 200 {object} SuccessResponse "Role revoked successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 422 {object} ErrorResponse "Unknown role or own account"
+// @Router /api/admin/users/{id}/roles/{role} [delete]
+func (h *AdminHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.AdminService.RevokeRole(actorFrom(r), id, mux.Vars(r)["role"])
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to revoke role")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Role revoked successfully", user)
+}
+
+// AuditLog handles reading the audit log of staff actions
+// @Summary List the admin audit log
+// @Description List the actions staff took on users, newest first: disabling and enabling accounts, resetting two-factor authentication, impersonating and changing roles. Needs the audit_log:read permission.
+// @Tags Admin
+// @Produce json
+// @Param actor_id query int false "Staff user who took the actions"
+// @Param target_user_id query int false "User the actions were taken on"
+// @Param action query string false "Action, e.g. user.impersonate"
+// @Param limit query int false "Maximum number of entries, 50 by default"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} SuccessResponse "Audit log retrieved successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 422 {object} ErrorResponse "Invalid filters"
+// @Router /api/admin/audit-log [get]
+func (h *AdminHandler) AuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AdminAuditFilter{Action: query.Get("action")}
+	filterErrors := make(map[string]string)
+	for name, target := range map[string]*int{"actor_id": &filter.ActorID, "target_user_id": &filter.TargetUserID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				filterErrors[name] = name + " must be an integer"
+				continue
+			}
+			*target = id
+		}
+	}
+	filter.Limit, filter.Offset = parsePage(query, filterErrors)
+	if len(filterErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid filters", filterErrors)
+		return
+	}
+
+	entries, err := h.AdminService.ListAuditLog(filter)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to list audit log")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Audit log retrieved successfully", entries)
+}
+
+// parsePage reads the limit and offset query parameters of admin lists,
+// adding invalid ones to errors
+func parsePage(query url.Values, errors map[string]string) (limit, offset int) {
+	for name, target := range map[string]*int{"limit": &limit, "offset": &offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			errors[name] = name + " must be a non-negative integer"
+			continue
+		}
+		*target = n
+	}
+	if limit == 0 {
+		limit = defaultAdminPageSize
+	}
+	return limit, offset
+}
+
+// actorFrom describes the staff user making a request for the audit log
+func actorFrom(r *http.Request) services.Actor {
+	return services.Actor{
+		UserID:      middleware.UserIDFromContext(r.Context()),
+		Permissions: middleware.PermissionsFromContext(r.Context()),
+		Device:      clientDevice(r),
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// maxMultipartOverhead leaves room for multipart headers and boundaries around the file
+const maxMultipartOverhead = 1 << 20
+
+// AttachmentHandler contains dependencies for receipt attachment operations
+type AttachmentHandler struct {
+	AttachmentService *services.AttachmentService
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		AttachmentService: attachmentService,
+	}
+}
+
+// Upload handles attaching a receipt to an expense
+// @Summary Upload a receipt
+// @Description Attach a receipt image (JPEG, PNG, GIF, WebP) or PDF of up to 10 MB to an expense, sent as the "file" field of a multipart form. Thumbnails are generated for JPEG, PNG and GIF images.
+// @Tags Attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Param file formData file true "Receipt file"
+// @Success 201 {object} SuccessResponse "Attachment uploaded successfully"
+// @Failure 400 {object} ErrorResponse "Missing file"
+// @Failure 404 {object} ErrorResponse "Expense not found"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 415 {object} ErrorResponse "Unsupported file type"
+// @Router /api/expenses/{id}/attachments [post]
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	expenseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAttachmentSize+maxMultipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Expected a multipart/form-data request", nil)
+		return
+	}
+
+	// Stream the first "file" part instead of buffering the whole form
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondWithServiceError(w, services.ErrAttachmentTooLarge, "")
+				return
+			}
+			respondWithError(w, http.StatusBadRequest, "Missing file", map[string]string{
+				"file": "file is required",
+			})
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.AttachmentService.Upload(r.Context(), middleware.UserIDFromContext(r.Context()),
+			expenseID, part.FileName(), part)
+		part.Close()
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = services.ErrAttachmentTooLarge
+		}
+		if err != nil {
+			respondWithServiceError(w, err, "Failed to upload attachment")
+			return
+		}
+
+		respondWithSuccess(w, http.StatusCreated, "Attachment uploaded successfully", attachment)
+		return
+	}
+}
+
+// List handles listing the attachments of an expense
+// @Summary List receipts
+// @Description List the receipt attachments of an expense
+// @Tags Attachment
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Success 200 {object} SuccessResponse "Attachments retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Expense not found"
+// @Router /api/expenses/{id}/attachments [get]
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	expenseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListAttachments(middleware.UserIDFromContext(r.Context()), expenseID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to list attachments")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+// Download handles downloading a receipt
+// @Summary Download a receipt
+// @Description Download the original file of a receipt attachment
+// @Tags Attachment
+// @Produce octet-stream
+// @Param id path int true "Expense ID"
+// @Param attachmentID path int true "Attachment ID"
+// @Success 200 {file} file "Receipt file"
+// @Failure 404 {object} ErrorResponse "Attachment not found"
+// @Router /api/expenses/{id}/attachments/{attachmentID} [get]
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, false)
+}
+
+// Thumbnail handles downloading the thumbnail of an image receipt
+// @Summary Download a receipt thumbnail
+// @Description Download a JPEG thumbnail of an image receipt, at most 256 pixels wide and high
+// @Tags Attachment
+// @Produce jpeg
+// @Param id path int true "Expense ID"
+// @Param attachmentID path int true "Attachment ID"
+// @Success 200 {file} file "Thumbnail"
+// @Failure 404 {object} ErrorResponse "Attachment or thumbnail not found"
+// @Router /api/expenses/{id}/attachments/{attachmentID}/thumbnail [get]
+func (h *AttachmentHandler) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, true)
+}
+
+// Delete handles deleting a receipt
+// @Summary Delete a receipt
+// @Description Delete a receipt attachment and its stored files
+// @Tags Attachment
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Param attachmentID path int true "Attachment ID"
+// @Success 200 {object} SuccessResponse "Attachment deleted successfully"
+// @Failure 404 {object} ErrorResponse "Attachment not found"
+// @Router /api/expenses/{id}/attachments/{attachmentID} [delete]
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	expenseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathInt(w, r, "attachmentID")
+	if !ok {
+		return
+	}
+
+	if err := h.AttachmentService.DeleteAttachment(r.Context(), middleware.UserIDFromContext(r.Context()), expenseID, id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete attachment")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Attachment deleted successfully", nil)
+}
+
+// serve streams an attachment file or its thumbnail to its owner
+func (h *AttachmentHandler) serve(w http.ResponseWriter, r *http.Request, thumb bool) {
+	expenseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathInt(w, r, "attachmentID")
+	if !ok {
+		return
+	}
+
+	attachment, file, err := h.AttachmentService.Open(r.Context(), middleware.UserIDFromContext(r.Context()), expenseID, id, thumb)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to download attachment")
+		return
+	}
+	defer file.Close()
+
+	filename := attachment.Filename
+	if thumb {
+		w.Header().Set("Content-Type", "image/jpeg")
+		filename = "thumbnail-" + filename + ".jpg"
+	} else {
+		w.Header().Set("Content-Type", attachment.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send attachment %d: %v", attachment.ID, err)
+	}
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// BudgetHandler contains dependencies for budget-related operations
+type BudgetHandler struct {
+	BudgetService *services.BudgetService
+}
+
+// BudgetInput represents the input structure for creating or updating a budget
+type BudgetInput struct {
+	Name       string `json:"name" validate:"required,max=100"`
+	CategoryID *int   `json:"category_id"`
+	Amount     string `json:"amount" validate:"required,money_positive" example:"500.00 EUR"`
+	Period     string `json:"period" validate:"required,oneof=weekly monthly yearly custom"`
+	StartDate  string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	// EndDate is only used, and required, for custom budgets
+	EndDate  string `json:"end_date" validate:"required_if=Period custom,omitempty,datetime=2006-01-02"`
+	Rollover bool   `json:"rollover"`
+}
+
+// NewBudgetHandler creates a new BudgetHandler
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		BudgetService: budgetService,
+	}
+}
+
+// Create handles budget creation
+// @Summary Create a budget
+// @Description Create a weekly, monthly, yearly or custom budget for a category or for all spending
+// @Tags Budget
+// @Accept json
+// @Produce json
+// @Param BudgetInput body BudgetInput true "Budget Input"
+// @Success 201 {object} SuccessResponse "Budget created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/budgets [post]
+func (h *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	budget, ok := decodeBudgetInput(w, r)
+	if !ok {
+		return
+	}
+	budget.UserID = middleware.UserIDFromContext(r.Context())
+
+	newBudget, err := h.BudgetService.CreateBudget(budget)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create budget")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Budget created successfully", newBudget)
+}
+
+// List handles listing the user's budgets
+// @Summary List budgets
+// @Description List the authenticated user's budgets
+// @Tags Budget
+// @Produce json
+// @Success 200 {object} SuccessResponse "Budgets retrieved successfully"
+// @Router /api/budgets [get]
+func (h *BudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	budgets, err := h.BudgetService.ListBudgets(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list budgets", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budgets retrieved successfully", budgets)
+}
+
+// Get handles fetching a single budget
+// @Summary Get a budget
+// @Description Get one of the authenticated user's budgets
+// @Tags Budget
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} SuccessResponse "Budget retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Budget not found"
+// @Router /api/budgets/{id} [get]
+func (h *BudgetHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	budget, err := h.BudgetService.GetBudget(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get budget")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budget retrieved successfully", budget)
+}
+
+// Update handles updating a budget
+// @Summary Update a budget
+// @Description Replace the fields of one of the authenticated user's budgets
+// @Tags Budget
+// @Accept json
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param BudgetInput body BudgetInput true "Budget Input"
+// @Success 200 {object} SuccessResponse "Budget updated successfully"
+// @Failure 404 {object} ErrorResponse "Budget not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/budgets/{id} [put]
+func (h *BudgetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	budget, ok := decodeBudgetInput(w, r)
+	if !ok {
+		return
+	}
+	budget.ID = id
+	budget.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.BudgetService.UpdateBudget(budget)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update budget")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budget updated successfully", updated)
+}
+
+// Delete handles deleting a budget
+// @Summary Delete a budget
+// @Description Delete one of the authenticated user's budgets
+// @Tags Budget
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Success 200 {object} SuccessResponse "Budget deleted successfully"
+// @Failure 404 {object} ErrorResponse "Budget not found"
+// @Router /api/budgets/{id} [delete]
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.BudgetService.DeleteBudget(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete budget")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budget deleted successfully", nil)
+}
+
+// Statuses handles budget vs. actual for all budgets
+// @Summary Budget status overview
+// @Description Budget vs. actual, remaining and percent used for the current period of every budget
+// @Tags Budget
+// @Produce json
+// @Param date query string false "Report as of this date (YYYY-MM-DD), defaults to today"
+// @Param currency query string false "Convert amounts to this currency (ISO 4217), defaults to the budget currency"
+// @Success 200 {object} SuccessResponse "Budget statuses retrieved successfully"
+// @Failure 422 {object} ErrorResponse "Invalid date or currency, or no exchange rate"
+// @Router /api/budgets/status [get]
+func (h *BudgetHandler) Statuses(w http.ResponseWriter, r *http.Request) {
+	asOf, ok := reportDate(w, r)
+	if !ok {
+		return
+	}
+
+	currency, ok := reportCurrency(w, r)
+	if !ok {
+		return
+	}
+
+	statuses, err := h.BudgetService.Statuses(middleware.UserIDFromContext(r.Context()), asOf, currency)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to compute budget statuses")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budget statuses retrieved successfully", statuses)
+}
+
+// Status handles budget vs. actual for one budget
+// @Summary Budget status
+// @Description Budget vs. actual, remaining and percent used for the current period of a budget
+// @Tags Budget
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param date query string false "Report as of this date (YYYY-MM-DD), defaults to today"
+// @Param currency query string false "Convert amounts to this currency (ISO 4217), defaults to the budget currency"
+// @Success 200 {object} SuccessResponse "Budget status retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Budget not found"
+// @Failure 422 {object} ErrorResponse "Invalid date or currency, no exchange rate, or budget not started"
+// @Router /api/budgets/{id}/status [get]
+func (h *BudgetHandler) Status(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	asOf, ok := reportDate(w, r)
+	if !ok {
+		return
+	}
+
+	currency, ok := reportCurrency(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.BudgetService.Status(middleware.UserIDFromContext(r.Context()), id, asOf, currency)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to compute budget status")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Budget status retrieved successfully", status)
+}
+
+// decodeBudgetInput decodes and validates the request body into a budget
+func decodeBudgetInput(w http.ResponseWriter, r *http.Request) (*models.Budget, bool) {
+	var input BudgetInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	// The validator already checked the amount and the date layouts
+	amount, _ := money.Parse(input.Amount)
+	startDate, _ := time.Parse(dateLayout, input.StartDate)
+
+	var endDate *time.Time
+	if input.Period == models.PeriodCustom {
+		date, _ := time.Parse(dateLayout, input.EndDate)
+		if date.Before(startDate) {
+			respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+				"EndDate": "EndDate must not be before StartDate",
+			})
+			return nil, false
+		}
+		endDate = &date
+	}
+
+	return &models.Budget{
+		Name:       input.Name,
+		CategoryID: input.CategoryID,
+		Amount:     amount,
+		Period:     input.Period,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Rollover:   input.Rollover,
+	}, true
+}
+
+// reportDate parses the optional "date" query parameter, defaulting to today
+func reportDate(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	value := r.URL.Query().Get("date")
+	if value == "" {
+		return time.Now().UTC(), true
+	}
+
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+			"date": "date must be a date in YYYY-MM-DD format",
+		})
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// CategoryHandler contains dependencies for category-related operations
+type CategoryHandler struct {
+	CategoryService *services.CategoryService
+}
+
+// CategoryInput represents the input structure for creating or updating a category
+type CategoryInput struct {
+	ParentID *int   `json:"parent_id"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Colour   string `json:"colour" validate:"omitempty,hexcolor,max=7"`
+	Icon     string `json:"icon" validate:"max=50"`
+}
+
+// NewCategoryHandler creates a new CategoryHandler
+func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		CategoryService: categoryService,
+	}
+}
+
+// List handles listing the user's categories as a tree
+// @Summary List categories
+// @Description List the authenticated user's categories, with subcategories nested under "children"
+// @Tags Category
+// @Produce json
+// @Success 200 {object} SuccessResponse "Categories retrieved successfully"
+// @Router /api/categories [get]
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.CategoryService.Tree(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list categories", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Categories retrieved successfully", tree)
+}
+
+// Create handles category creation
+// @Summary Create a category
+// @Description Create a category, optionally nested under a parent category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param CategoryInput body CategoryInput true "Category Input"
+// @Success 201 {object} SuccessResponse "Category created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/categories [post]
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	category, ok := decodeCategoryInput(w, r)
+	if !ok {
+		return
+	}
+	category.UserID = middleware.UserIDFromContext(r.Context())
+
+	newCategory, err := h.CategoryService.CreateCategory(category)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create category")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Category created successfully", newCategory)
+}
+
+// Update handles updating a category
+// @Summary Update a category
+// @Description Rename, recolour or move one of the authenticated user's categories
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param CategoryInput body CategoryInput true "Category Input"
+// @Success 200 {object} SuccessResponse "Category updated successfully"
+// @Failure 404 {object} ErrorResponse "Category not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/categories/{id} [put]
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	category, ok := decodeCategoryInput(w, r)
+	if !ok {
+		return
+	}
+	category.ID = id
+	category.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.CategoryService.UpdateCategory(category)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update category")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Category updated successfully", updated)
+}
+
+// Delete handles deleting a category
+// @Summary Delete a category
+// @Description Delete a category. Its expenses and budgets move to its parent (reassign_to=parent, the default) or to another category (reassign_to=<id>); its subcategories move to its parent. A top-level category with budgets needs a reassign_to category.
+// @Tags Category
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param reassign_to query string false "\"parent\" or the ID of the category that receives the expenses and budgets"
+// @Success 200 {object} SuccessResponse "Category deleted successfully"
+// @Failure 404 {object} ErrorResponse "Category not found"
+// @Failure 409 {object} ErrorResponse "The category has budgets and no reassignment target"
+// @Failure 422 {object} ErrorResponse "Invalid reassignment target"
+// @Router /api/categories/{id} [delete]
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var reassignTo *int
+	if value := r.URL.Query().Get("reassign_to"); value != "" && value != "parent" {
+		targetID, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+				"reassign_to": "reassign_to must be \"parent\" or a category ID",
+			})
+			return
+		}
+		reassignTo = &targetID
+	}
+
+	if err := h.CategoryService.DeleteCategory(middleware.UserIDFromContext(r.Context()), id, reassignTo); err != nil {
+		respondWithServiceError(w, err, "Failed to delete category")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Category deleted successfully", nil)
+}
+
+// decodeCategoryInput decodes and validates the request body into a category
+func decodeCategoryInput(w http.ResponseWriter, r *http.Request) (*models.Category, bool) {
+	var input CategoryInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	colour := input.Colour
+	if colour == "" {
+		colour = "#9E9E9E"
+	}
+
+	return &models.Category{
+		ParentID: input.ParentID,
+		Name:     input.Name,
+		Colour:   colour,
+		Icon:     input.Icon,
+	}, true
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// DataExportHandler contains dependencies for personal data export operations
+type DataExportHandler struct {
+	DataExportService *services.DataExportService
+}
+
+// NewDataExportHandler creates a new DataExportHandler
+func NewDataExportHandler(dataExportService *services.DataExportService) *DataExportHandler {
+	return &DataExportHandler{
+		DataExportService: dataExportService,
+	}
+}
+
+// Request handles requesting an export of all the user's data
+// @Summary Request a data export
+// @Description Queue an export of everything stored for the authenticated user: profile, expenses, categories, accounts, budgets, recurring expenses, attachments with their original files and the audit log of sign-ins and failed logins. The export is built in the background as a ZIP archive of JSON and CSV files; poll it until its status is "ready" to get a download link. Only one export can be pending or running at a time.
+// @Tags Data Export
+// @Produce json
+// @Success 202 {object} SuccessResponse "Data export requested successfully"
+// @Failure 409 {object} ErrorResponse "A data export is already in progress"
+// @Router /api/exports [post]
+func (h *DataExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	export, err := h.DataExportService.RequestExport(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to request data export")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusAccepted, "Data export requested successfully", export)
+}
+
+// List handles listing the user's data exports
+// @Summary List data exports
+// @Description List the data exports of the authenticated user, newest first. Ready exports include a download link valid for an hour; archives are kept for 7 days.
+// @Tags Data Export
+// @Produce json
+// @Success 200 {object} SuccessResponse "Data exports retrieved successfully"
+// @Router /api/exports [get]
+func (h *DataExportHandler) List(w http.ResponseWriter, r *http.Request) {
+	exports, err := h.DataExportService.ListExports(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list data exports", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Data exports retrieved successfully", exports)
+}
+
+// Get handles polling the status of a data export
+// @Summary Get a data export
+// @Description Get the status of a data export: pending, running, ready or failed. Once ready it includes a signed download link valid for an hour.
+// @Tags Data Export
+// @Produce json
+// @Param id path int true "Data export ID"
+// @Success 200 {object} SuccessResponse "Data export retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Data export not found"
+// @Router /api/exports/{id} [get]
+func (h *DataExportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	export, err := h.DataExportService.GetExport(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to retrieve data export")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Data export retrieved successfully", export)
+}
+
+// Download handles downloading the archive of a data export through a signed link
+// @Summary Download a data export
+// @Description Download the ZIP archive of a ready data export. The link from the export's download_url is signed and expires, so it works without an access token, e.g. in a browser.
+// @Tags Data Export
+// @Produce application/zip
+// @Param id path int true "Data export ID"
+// @Param expires query int true "Expiry of the link, in Unix seconds"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 403 {object} ErrorResponse "Download link is invalid or has expired"
+// @Router /api/exports/{id}/download [get]
+func (h *DataExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	export, file, err := h.DataExportService.Open(r.Context(), id, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to download data export")
+		return
+	}
+	defer file.Close()
+
+	filename := fmt.Sprintf("expense-manager-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	if export.Size != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*export.Size, 10))
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send data export %d: %v", export.ID, err)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// EmailVerificationHandler contains dependencies for email verification
+type EmailVerificationHandler struct {
+	EmailVerificationService *services.EmailVerificationService
+}
+
+// NewEmailVerificationHandler creates a new EmailVerificationHandler
+func NewEmailVerificationHandler(emailVerificationService *services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		EmailVerificationService: emailVerificationService,
+	}
+}
+
+// Verify handles following the link of a verification email
+// @Summary Verify an email
+// @Description Mark the user's email as verified with the token of the verification link. Access tokens issued before pick the change up on the next refresh.
+// @Tags User
+// @Produce json
+// @Param token query string true "Token of the verification link"
+// @Success 200 {object} SuccessResponse "Email verified successfully"
+// @Failure 422 {object} ErrorResponse "Invalid or expired token"
+// @Router /api/verify-email [get]
+func (h *EmailVerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+			"token": "token is required",
+		})
+		return
+	}
+
+	if err := h.EmailVerificationService.VerifyEmail(token); err != nil {
+		respondWithServiceError(w, err, "Failed to verify email")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Email verified successfully", nil)
+}
+
+// Resend handles sending the verification email again
+// @Summary Resend the verification email
+// @Description Send the authenticated user a new verification link, at most once a minute
+// @Tags User
+// @Produce json
+// @Success 200 {object} SuccessResponse "Verification email sent"
+// @Failure 422 {object} ErrorResponse "Email already verified"
+// @Failure 429 {object} ErrorResponse "Verification email sent too recently"
+// @Router /api/verify-email/resend [post]
+func (h *EmailVerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	err := h.EmailVerificationService.ResendVerification(r.Context(), middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to send verification email")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Verification email sent", nil)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/ecb"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// maxRateFileSize comfortably fits the full ECB history in XML
+const maxRateFileSize = 32 << 20
+
+// ExchangeRateHandler contains dependencies for exchange-rate operations
+type ExchangeRateHandler struct {
+	ExchangeRateService *services.ExchangeRateService
+}
+
+// NewExchangeRateHandler creates a new ExchangeRateHandler
+func NewExchangeRateHandler(exchangeRateService *services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		ExchangeRateService: exchangeRateService,
+	}
+}
+
+// Import handles loading an exchange-rate file
+// @Summary Import exchange rates
+// @Description Load an ECB-style CSV or XML rate file, sent as the raw request body or as the "file" field of a multipart form. Rates already stored for the same pair and date are replaced. The rates are shared by every user, so this needs the rates:manage permission.
+// @Tags Exchange Rate
+// @Accept text/csv,application/xml,multipart/form-data
+// @Produce json
+// @Param format query string false "File format (csv or xml), detected from the content type or file name when omitted"
+// @Param base query string false "Currency the file's rates are quoted against, defaults to EUR"
+// @Success 200 {object} SuccessResponse "Exchange rates imported successfully"
+// @Failure 403 {object} ErrorResponse "Missing permission"
+// @Failure 422 {object} ErrorResponse "Invalid or unreadable file"
+// @Router /api/admin/exchange-rates/import [post]
+func (h *ExchangeRateHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRateFileSize)
+
+	var (
+		body io.Reader = r.Body
+		name           = r.Header.Get("Content-Type")
+	)
+	if strings.HasPrefix(name, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+			return
+		}
+		defer file.Close()
+		body, name = file, header.Filename
+	}
+
+	query := r.URL.Query()
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = ecb.FormatFromName(name)
+	}
+	base := strings.ToUpper(query.Get("base"))
+	if base == "" {
+		base = ecb.Base
+	}
+
+	result, err := h.ExchangeRateService.Import(body, format, base)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to import exchange rates")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Exchange rates imported successfully", result)
+}
+
+// Get handles looking up a stored exchange rate
+// @Summary Get an exchange rate
+// @Description Get the stored rate of a currency pair on a date, or on the nearest earlier date with a rate
+// @Tags Exchange Rate
+// @Produce json
+// @Param base query string true "Base currency (ISO 4217)"
+// @Param quote query string true "Quote currency (ISO 4217)"
+// @Param date query string false "Date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} SuccessResponse "Exchange rate retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Exchange rate not found"
+// @Failure 422 {object} ErrorResponse "Invalid parameters"
+// @Router /api/exchange-rates [get]
+func (h *ExchangeRateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	base := strings.ToUpper(query.Get("base"))
+	quote := strings.ToUpper(query.Get("quote"))
+
+	validationErrors := map[string]string{}
+	if !money.IsValidCurrency(base) {
+		validationErrors["base"] = "base must be an ISO 4217 currency code"
+	}
+	if !money.IsValidCurrency(quote) {
+		validationErrors["quote"] = "quote must be an ISO 4217 currency code"
+	}
+	if len(validationErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	on, ok := reportDate(w, r)
+	if !ok {
+		return
+	}
+
+	rate, err := h.ExchangeRateService.GetRate(base, quote, on)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get exchange rate")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Exchange rate retrieved successfully", rate)
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/henok-tesfu/expense-manager/internal/export"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// dateLayout is the layout used for dates in requests and query parameters
+const dateLayout = "2006-01-02"
+
+// ExpenseHandler contains dependencies for expense-related operations
+type ExpenseHandler struct {
+	ExpenseService *services.ExpenseService
+}
+
+// ExpenseInput represents the input structure for creating or updating an expense
+type ExpenseInput struct {
+	Amount      string `json:"amount" validate:"required,money_positive" example:"12.34 USD"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	Description string `json:"description" validate:"max=255"`
+	CategoryID  *int   `json:"category_id"`
+	AccountID   *int   `json:"account_id"`
+	Payee       string `json:"payee" validate:"max=255"`
+}
+
+// NewExpenseHandler creates a new ExpenseHandler
+func NewExpenseHandler(expenseService *services.ExpenseService) *ExpenseHandler {
+	return &ExpenseHandler{
+		ExpenseService: expenseService,
+	}
+}
+
+// Create handles expense creation
+// @Summary Create an expense
+// @Description Record a new expense for the authenticated user
+// @Tags Expense
+// @Accept json
+// @Produce json
+// @Param ExpenseInput body ExpenseInput true "Expense Input"
+// @Success 201 {object} SuccessResponse "Expense created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid payload"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/expenses [post]
+func (h *ExpenseHandler) Create(w http.ResponseWriter, r *http.Request) {
+	expense, ok := decodeExpenseInput(w, r)
+	if !ok {
+		return
+	}
+	expense.UserID = middleware.UserIDFromContext(r.Context())
+
+	newExpense, err := h.ExpenseService.CreateExpense(expense)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Expense created successfully", newExpense)
+}
+
+// List handles listing the user's expenses
+// @Summary List expenses
+// @Description List the authenticated user's expenses, newest first
+// @Tags Expense
+// @Produce json
+// @Param from query string false "Earliest date (YYYY-MM-DD)"
+// @Param to query string false "Latest date (YYYY-MM-DD)"
+// @Param category_id query int false "Category ID, including its subcategories"
+// @Param payee query string false "Payee"
+// @Param limit query int false "Maximum number of expenses"
+// @Param offset query int false "Number of expenses to skip"
+// @Success 200 {object} SuccessResponse "Expenses retrieved successfully"
+// @Failure 422 {object} ErrorResponse "Invalid filters"
+// @Router /api/expenses [get]
+func (h *ExpenseHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, filterErrors := parseExpenseFilter(r.URL.Query())
+	if len(filterErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid filters", filterErrors)
+		return
+	}
+
+	expenses, err := h.ExpenseService.ListExpenses(middleware.UserIDFromContext(r.Context()), filter)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to list expenses")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Expenses retrieved successfully", expenses)
+}
+
+// Export handles exporting the user's expenses to a file
+// @Summary Export expenses
+// @Description Download the authenticated user's expenses, newest first, as CSV, JSON Lines or XLSX. The filters are the same as for listing expenses. The file is streamed, so exports of any size are supported. XLSX workbooks have an "Expenses" sheet and a "Summary" sheet with the number and total of expenses per category and currency.
+// @Tags Expense
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format: csv (default), jsonl or xlsx"
+// @Param from query string false "Earliest date (YYYY-MM-DD)"
+// @Param to query string false "Latest date (YYYY-MM-DD)"
+// @Param category_id query int false "Category ID, including its subcategories"
+// @Param payee query string false "Payee"
+// @Param limit query int false "Maximum number of expenses"
+// @Param offset query int false "Number of expenses to skip"
+// @Success 200 {file} file "Expense export"
+// @Failure 404 {object} ErrorResponse "Category not found"
+// @Failure 422 {object} ErrorResponse "Invalid filters or format"
+// @Router /api/expenses/export [get]
+func (h *ExpenseHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, filterErrors := parseExpenseFilter(r.URL.Query())
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = export.FormatCSV
+	}
+	contentType, ok := export.ContentType(format)
+	if !ok {
+		filterErrors["format"] = "format must be one of csv, jsonl or xlsx"
+	}
+	if len(filterErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid filters", filterErrors)
+		return
+	}
+
+	filename := "expenses-" + time.Now().Format("20060102") + "." + format
+	out := &exportResponse{w: w, header: func() {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "private, no-store")
+	}}
+
+	userID := middleware.UserIDFromContext(r.Context())
+	if err := h.ExpenseService.Export(userID, filter, format, out); err != nil {
+		if !out.started {
+			respondWithServiceError(w, err, "Failed to export expenses")
+			return
+		}
+		// The status was already sent; the client sees a truncated file
+		log.Printf("Failed to export expenses of user %d: %v", userID, err)
+	}
+}
+
+// Get handles fetching a single expense
+// @Summary Get an expense
+// @Description Get one of the authenticated user's expenses
+// @Tags Expense
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Success 200 {object} SuccessResponse "Expense retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Expense not found"
+// @Router /api/expenses/{id} [get]
+func (h *ExpenseHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	expense, err := h.ExpenseService.GetExpense(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Expense retrieved successfully", expense)
+}
+
+// Update handles updating an expense
+// @Summary Update an expense
+// @Description Replace the fields of one of the authenticated user's expenses
+// @Tags Expense
+// @Accept json
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Param ExpenseInput body ExpenseInput true "Expense Input"
+// @Success 200 {object} SuccessResponse "Expense updated successfully"
+// @Failure 404 {object} ErrorResponse "Expense not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/expenses/{id} [put]
+func (h *ExpenseHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	expense, ok := decodeExpenseInput(w, r)
+	if !ok {
+		return
+	}
+	expense.ID = id
+	expense.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.ExpenseService.UpdateExpense(expense)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Expense updated successfully", updated)
+}
+
+// Delete handles deleting an expense
+// @Summary Delete an expense
+// @Description Delete one of the authenticated user's expenses
+// @Tags Expense
+// @Produce json
+// @Param id path int true "Expense ID"
+// @Success 200 {object} SuccessResponse "Expense deleted successfully"
+// @Failure 404 {object} ErrorResponse "Expense not found"
+// @Router /api/expenses/{id} [delete]
+func (h *ExpenseHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ExpenseService.DeleteExpense(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Expense deleted successfully", nil)
+}
+
+// decodeExpenseInput decodes and validates the request body into an expense
+func decodeExpenseInput(w http.ResponseWriter, r *http.Request) (*models.Expense, bool) {
+	var input ExpenseInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	// The validator already checked the amount and the date layout
+	amount, _ := money.Parse(input.Amount)
+	date, _ := time.Parse(dateLayout, input.Date)
+
+	return &models.Expense{
+		Amount:      amount,
+		Date:        date,
+		Description: input.Description,
+		CategoryID:  input.CategoryID,
+		AccountID:   input.AccountID,
+		Payee:       input.Payee,
+	}, true
+}
+
+// parseExpenseFilter builds an expense filter from query parameters
+func parseExpenseFilter(query url.Values) (models.ExpenseFilter, map[string]string) {
+	filter := models.ExpenseFilter{
+		Payee: query.Get("payee"),
+	}
+	errors := make(map[string]string)
+
+	if value := query.Get("category_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			errors["category_id"] = "category_id must be an integer"
+		} else {
+			filter.CategoryIDs = []int{id}
+		}
+	}
+
+	for _, name := range []string{"from", "to"} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			errors[name] = name + " must be a date in YYYY-MM-DD format"
+			continue
+		}
+		if name == "from" {
+			filter.From = &date
+		} else {
+			filter.To = &date
+		}
+	}
+
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			errors[name] = name + " must be a non-negative integer"
+			continue
+		}
+		*target = n
+	}
+
+	return filter, errors
+}
+
+// pathID parses the {id} route variable, responding with 404 when it is not a number
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	return pathInt(w, r, "id")
+}
+
+// pathInt parses a numeric route variable, responding with 404 when it is not a number
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Resource not found", nil)
+		return 0, false
+	}
+	return id, true
+}
+
+// exportResponse sends the export headers only once the first bytes are
+// written, so errors found before that can still get a JSON error response
+type exportResponse struct {
+	w       http.ResponseWriter
+	header  func()
+	started bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.header()
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(p)
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/importer"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// maxStatementSize bounds uploaded bank statements
+const maxStatementSize = 10 << 20
+
+// ImportHandler contains dependencies for bank statement imports
+type ImportHandler struct {
+	ImportService *services.ImportService
+}
+
+// ImportProfileInput represents the input structure for creating or updating a CSV mapping profile.
+// Columns are header names, or 1-based positions when HasHeader is false.
+type ImportProfileInput struct {
+	Name               string   `json:"name" validate:"required,max=100" example:"My Bank"`
+	Delimiter          string   `json:"delimiter" validate:"omitempty,len=1" example:";"`
+	HasHeader          *bool    `json:"has_header"`
+	SkipRows           int      `json:"skip_rows" validate:"gte=0,lte=100"`
+	DateColumn         string   `json:"date_column" validate:"required,max=100" example:"Booking date"`
+	DateFormat         string   `json:"date_format" validate:"required,max=50" example:"DD.MM.YYYY"`
+	AmountSign         string   `json:"amount_sign" validate:"required,oneof=negative_is_expense positive_is_expense debit_credit" example:"negative_is_expense"`
+	AmountColumn       string   `json:"amount_column" validate:"required_unless=AmountSign debit_credit,max=100" example:"Amount"`
+	DebitColumn        string   `json:"debit_column" validate:"required_if=AmountSign debit_credit,max=100"`
+	CreditColumn       string   `json:"credit_column" validate:"required_if=AmountSign debit_credit,max=100"`
+	DecimalSeparator   string   `json:"decimal_separator" validate:"omitempty,oneof=. ," example:","`
+	DescriptionColumns []string `json:"description_columns" validate:"dive,required,max=100"`
+	PayeeColumn        string   `json:"payee_column" validate:"max=100" example:"Counterparty"`
+	Currency           string   `json:"currency" validate:"required,currency" example:"EUR"`
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		ImportService: importService,
+	}
+}
+
+// Import handles importing a bank statement
+// @Summary Import a bank statement
+// @Description Read a bank statement (CSV, OFX/QFX or QIF) sent as the "file" field of a multipart form and record its outgoing transactions as expenses, all in one transaction. Lines imported before are reported as duplicates and never recorded twice; OFX lines are recognised by their FITID. Incoming money is skipped. Expenses are assigned to the account whose identifier matches the statement's account number, and an account is created for numbers seen for the first time. With dry_run=true nothing is recorded and the result is a preview. Lines that cannot be read are reported in errors, keyed "line N".
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Statement file"
+// @Param format query string false "Statement format (csv, ofx, qfx or qif), detected from the file name when omitted"
+// @Param profile_id query int false "Mapping profile, required for CSV"
+// @Param category_id query int false "Category for every imported expense"
+// @Param account_id query int false "Account for every imported expense, instead of matching account numbers"
+// @Param currency query string false "Currency of statements that do not state one (QIF); defaults to the account's currency, then the home currency"
+// @Param dry_run query bool false "Only preview the import"
+// @Success 200 {object} SuccessResponse "Statement imported successfully"
+// @Failure 400 {object} ErrorResponse "Missing file"
+// @Failure 404 {object} ErrorResponse "Import profile not found"
+// @Failure 413 {object} ErrorResponse "File too large"
+// @Failure 422 {object} ErrorResponse "Unreadable lines or invalid parameters"
+// @Router /api/imports [post]
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := services.ImportOptions{Format: strings.ToLower(query.Get("format"))}
+	validationErrors := map[string]string{}
+	for name, target := range map[string]**int{
+		"profile_id":  &options.ProfileID,
+		"category_id": &options.CategoryID,
+		"account_id":  &options.AccountID,
+	} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				validationErrors[name] = name + " must be an integer"
+				continue
+			}
+			*target = &id
+		}
+	}
+	if value := query.Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			validationErrors["dry_run"] = "dry_run must be true or false"
+		}
+		options.DryRun = dryRun
+	}
+	if options.Currency = strings.ToUpper(query.Get("currency")); options.Currency != "" &&
+		!money.IsValidCurrency(options.Currency) {
+		validationErrors["currency"] = "currency must be an ISO 4217 currency code"
+	}
+	if len(validationErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxStatementSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Statement exceeds the maximum size of 10 MB", nil)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Missing file", map[string]string{
+			"file": "file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if options.Format == "" {
+		options.Format = importer.FormatFromName(header.Filename)
+	}
+
+	result, err := h.ImportService.Import(middleware.UserIDFromContext(r.Context()), file, options)
+	var lineErrors importer.LineErrors
+	if errors.As(err, &lineErrors) {
+		respondWithError(w, http.StatusUnprocessableEntity, "The statement contains unreadable lines", lineErrors.Fields())
+		return
+	}
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to import statement")
+		return
+	}
+
+	message := "Statement imported successfully"
+	if options.DryRun {
+		message = "Statement preview generated successfully"
+	}
+	respondWithSuccess(w, http.StatusOK, message, result)
+}
+
+// CreateProfile handles import profile creation
+// @Summary Create an import profile
+// @Description Save how to read the CSV statements of a bank: columns, date format, amount sign convention and decimal separator
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Param ImportProfileInput body ImportProfileInput true "Import Profile Input"
+// @Success 201 {object} SuccessResponse "Import profile created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/import-profiles [post]
+func (h *ImportHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	profile, ok := decodeImportProfileInput(w, r)
+	if !ok {
+		return
+	}
+	profile.UserID = middleware.UserIDFromContext(r.Context())
+
+	newProfile, err := h.ImportService.CreateImportProfile(profile)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create import profile")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Import profile created successfully", newProfile)
+}
+
+// ListProfiles handles listing the user's import profiles
+// @Summary List import profiles
+// @Description List the authenticated user's CSV mapping profiles
+// @Tags Import
+// @Produce json
+// @Success 200 {object} SuccessResponse "Import profiles retrieved successfully"
+// @Router /api/import-profiles [get]
+func (h *ImportHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.ImportService.ListImportProfiles(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list import profiles", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Import profiles retrieved successfully", profiles)
+}
+
+// GetProfile handles fetching a single import profile
+// @Summary Get an import profile
+// @Description Get one of the authenticated user's CSV mapping profiles
+// @Tags Import
+// @Produce json
+// @Param id path int true "Import profile ID"
+// @Success 200 {object} SuccessResponse "Import profile retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Import profile not found"
+// @Router /api/import-profiles/{id} [get]
+func (h *ImportHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := h.ImportService.GetImportProfile(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get import profile")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Import profile retrieved successfully", profile)
+}
+
+// UpdateProfile handles updating an import profile
+// @Summary Update an import profile
+// @Description Replace the settings of a CSV mapping profile
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Param id path int true "Import profile ID"
+// @Param ImportProfileInput body ImportProfileInput true "Import Profile Input"
+// @Success 200 {object} SuccessResponse "Import profile updated successfully"
+// @Failure 404 {object} ErrorResponse "Import profile not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/import-profiles/{id} [put]
+func (h *ImportHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	profile, ok := decodeImportProfileInput(w, r)
+	if !ok {
+		return
+	}
+	profile.ID = id
+	profile.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.ImportService.UpdateImportProfile(profile)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update import profile")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Import profile updated successfully", updated)
+}
+
+// DeleteProfile handles deleting an import profile
+// @Summary Delete an import profile
+// @Description Delete a CSV mapping profile; expenses imported with it are kept
+// @Tags Import
+// @Produce json
+// @Param id path int true "Import profile ID"
+// @Success 200 {object} SuccessResponse "Import profile deleted successfully"
+// @Failure 404 {object} ErrorResponse "Import profile not found"
+// @Router /api/import-profiles/{id} [delete]
+func (h *ImportHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ImportService.DeleteImportProfile(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete import profile")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Import profile deleted successfully", nil)
+}
+
+// decodeImportProfileInput decodes and validates the request body into an import profile
+func decodeImportProfileInput(w http.ResponseWriter, r *http.Request) (*models.ImportProfile, bool) {
+	var input ImportProfileInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	profile := &models.ImportProfile{
+		Name:               input.Name,
+		Delimiter:          input.Delimiter,
+		HasHeader:          input.HasHeader == nil || *input.HasHeader,
+		SkipRows:           input.SkipRows,
+		DateColumn:         input.DateColumn,
+		DateFormat:         input.DateFormat,
+		AmountSign:         input.AmountSign,
+		AmountColumn:       input.AmountColumn,
+		DebitColumn:        input.DebitColumn,
+		CreditColumn:       input.CreditColumn,
+		DecimalSeparator:   input.DecimalSeparator,
+		DescriptionColumns: input.DescriptionColumns,
+		PayeeColumn:        input.PayeeColumn,
+		Currency:           input.Currency,
+	}
+	if profile.Delimiter == "" {
+		profile.Delimiter = ","
+	}
+	if profile.DecimalSeparator == "" {
+		profile.DecimalSeparator = "."
+	}
+	if profile.DescriptionColumns == nil {
+		profile.DescriptionColumns = []string{}
+	}
+	return profile, true
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
+)
+
+// KeysHandler publishes the keys that verify access tokens
+type KeysHandler struct {
+	TokenService *jwt.TokenService
+}
+
+// NewKeysHandler creates a new KeysHandler
+func NewKeysHandler(tokenService *jwt.TokenService) *KeysHandler {
+	return &KeysHandler{
+		TokenService: tokenService,
+	}
+}
+
+// JWKS handles publishing the access token keys
+// @Summary Access token verification keys
+// @Description The public RS256 and EdDSA keys that currently verify access tokens, as a JSON Web Key Set, so that other services can verify them by their kid header. Keys being retired are listed until their grace period ends; HS256 keys are never published.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (h *KeysHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.TokenService.JWKS())
+}
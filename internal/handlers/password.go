@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// PasswordHandler contains dependencies for password recovery
+type PasswordHandler struct {
+	PasswordService *services.PasswordService
+}
+
+// ForgotPasswordInput represents the input structure for requesting a password reset
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordInput represents the input structure for setting a new password
+type ResetPasswordInput struct {
+	// Token is the token of the reset link
+	Token string `json:"token" validate:"required"`
+	// Password has to meet the password policy
+	Password string `json:"password" validate:"required"`
+}
+
+// NewPasswordHandler creates a new PasswordHandler
+func NewPasswordHandler(passwordService *services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		PasswordService: passwordService,
+	}
+}
+
+// Forgot handles requesting a password reset
+// @Summary Request a password reset
+// @Description Email a link to reset the password, valid for one hour and usable once. A link is sent at most once every five minutes per account. The email is sent in the background, so the response is the same, and as fast, whether or not the email is registered.
+// @Tags Password
+// @Accept json
+// @Produce json
+// @Param ForgotPasswordInput body ForgotPasswordInput true "Forgot Password Input"
+// @Success 200 {object} SuccessResponse "Reset link sent"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/password/forgot [post]
+func (h *PasswordHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var input ForgotPasswordInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	// The response never tells whether the email is registered
+	h.PasswordService.ForgotPassword(input.Email)
+
+	respondWithSuccess(w, http.StatusOK, "If the email is registered, a reset link has been sent to it", nil)
+}
+
+// Reset handles setting a new password
+// @Summary Reset the password
+// @Description Set a new password with the token of a reset link. Every session of the user is signed out.
+// @Tags Password
+// @Accept json
+// @Produce json
+// @Param ResetPasswordInput body ResetPasswordInput true "Reset Password Input"
+// @Success 200 {object} SuccessResponse "Password reset successfully"
+// @Failure 422 {object} ErrorResponse "Invalid or expired token, validation errors or password policy violations"
+// @Router /api/password/reset [post]
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var input ResetPasswordInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	if err := h.PasswordService.ResetPassword(input.Token, input.Password); err != nil {
+		respondWithServiceError(w, err, "Failed to reset password")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Password reset successfully", nil)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// PersonalAccessTokenHandler contains dependencies for personal access token operations
+type PersonalAccessTokenHandler struct {
+	PersonalAccessTokenService *services.PersonalAccessTokenService
+}
+
+// PersonalAccessTokenInput represents the input structure for creating a personal access token
+type PersonalAccessTokenInput struct {
+	Name string `json:"name" validate:"required,max=100" example:"Nightly expense sync"`
+	// Scopes are any of expenses:read, expenses:write, reports:read and budgets:write
+	Scopes []string `json:"scopes" validate:"required,min=1" example:"expenses:read,expenses:write"`
+	// ExpiresAt is optional; tokens without it stay valid until revoked
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-12-31T23:59:59Z"`
+}
+
+// CreatedPersonalAccessToken is a new personal access token together with the token itself
+type CreatedPersonalAccessToken struct {
+	*models.PersonalAccessToken
+	// Token is only shown once; send it as "Authorization: Bearer <token>"
+	Token string `json:"token" example:"emp_3q2-7Hh0V9tV0yYp0y2Jd1w8m3b2oXw3nTzP1yQyZpA"`
+}
+
+// NewPersonalAccessTokenHandler creates a new PersonalAccessTokenHandler
+func NewPersonalAccessTokenHandler(personalAccessTokenService *services.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		PersonalAccessTokenService: personalAccessTokenService,
+	}
+}
+
+// Create handles personal access token creation
+// @Summary Create a personal access token
+// @Description Create a long-lived token for scripts and integrations, limited to the given scopes. The token is returned only in this response; only its hash is stored.
+// @Tags Personal Access Token
+// @Accept json
+// @Produce json
+// @Param PersonalAccessTokenInput body PersonalAccessTokenInput true "Personal Access Token Input"
+// @Success 201 {object} SuccessResponse "Personal access token created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/personal-access-tokens [post]
+func (h *PersonalAccessTokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input PersonalAccessTokenInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	token, secret, err := h.PersonalAccessTokenService.CreateToken(&models.PersonalAccessToken{
+		UserID:    middleware.UserIDFromContext(r.Context()),
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	})
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create personal access token")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Personal access token created successfully",
+		CreatedPersonalAccessToken{PersonalAccessToken: token, Token: secret})
+}
+
+// List handles listing the user's personal access tokens
+// @Summary List personal access tokens
+// @Description List the authenticated user's personal access tokens with their scopes, expiry and last use; the tokens themselves cannot be shown again
+// @Tags Personal Access Token
+// @Produce json
+// @Success 200 {object} SuccessResponse "Personal access tokens retrieved successfully"
+// @Router /api/personal-access-tokens [get]
+func (h *PersonalAccessTokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.PersonalAccessTokenService.ListTokens(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list personal access tokens", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Personal access tokens retrieved successfully", tokens)
+}
+
+// Revoke handles revoking a personal access token
+// @Summary Revoke a personal access token
+// @Description Delete a personal access token; requests made with it fail from now on
+// @Tags Personal Access Token
+// @Produce json
+// @Param id path int true "Personal access token ID"
+// @Success 200 {object} SuccessResponse "Personal access token revoked successfully"
+// @Failure 404 {object} ErrorResponse "Personal access token not found"
+// @Router /api/personal-access-tokens/{id} [delete]
+func (h *PersonalAccessTokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.PersonalAccessTokenService.RevokeToken(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to revoke personal access token")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Personal access token revoked successfully", nil)
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// maxPreviewCount caps how many occurrences a preview may return
+const maxPreviewCount = 100
+
+// RecurringHandler contains dependencies for recurring-expense operations
+type RecurringHandler struct {
+	RecurringService *services.RecurringService
+}
+
+// RecurringExpenseInput represents the input structure for creating or updating a recurring expense
+type RecurringExpenseInput struct {
+	Amount      string `json:"amount" validate:"required,money_positive" example:"1200.00 EUR"`
+	CategoryID  *int   `json:"category_id"`
+	Description string `json:"description" validate:"max=255"`
+	Payee       string `json:"payee" validate:"max=255"`
+	RRule       string `json:"rrule" validate:"required,rrule,max=255" example:"FREQ=MONTHLY;BYMONTHDAY=1"`
+	StartDate   string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	Active      *bool  `json:"active"`
+}
+
+// OccurrenceInput represents the fields to override on a single occurrence; omitted fields are unchanged
+type OccurrenceInput struct {
+	Amount      string  `json:"amount" validate:"omitempty,money_positive" example:"1250.00 EUR"`
+	Date        string  `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	CategoryID  *int    `json:"category_id"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	Payee       *string `json:"payee" validate:"omitempty,max=255"`
+}
+
+// NewRecurringHandler creates a new RecurringHandler
+func NewRecurringHandler(recurringService *services.RecurringService) *RecurringHandler {
+	return &RecurringHandler{
+		RecurringService: recurringService,
+	}
+}
+
+// Create handles recurring expense creation
+// @Summary Create a recurring expense
+// @Description Create an expense that repeats according to an RFC 5545 recurrence rule (FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH)
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param RecurringExpenseInput body RecurringExpenseInput true "Recurring Expense Input"
+// @Success 201 {object} SuccessResponse "Recurring expense created successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/recurring-expenses [post]
+func (h *RecurringHandler) Create(w http.ResponseWriter, r *http.Request) {
+	recurring, ok := decodeRecurringExpenseInput(w, r)
+	if !ok {
+		return
+	}
+	recurring.UserID = middleware.UserIDFromContext(r.Context())
+
+	newRecurring, err := h.RecurringService.CreateRecurringExpense(recurring)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create recurring expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Recurring expense created successfully", newRecurring)
+}
+
+// List handles listing the user's recurring expenses
+// @Summary List recurring expenses
+// @Description List the authenticated user's recurring expenses
+// @Tags Recurring
+// @Produce json
+// @Success 200 {object} SuccessResponse "Recurring expenses retrieved successfully"
+// @Router /api/recurring-expenses [get]
+func (h *RecurringHandler) List(w http.ResponseWriter, r *http.Request) {
+	recurring, err := h.RecurringService.ListRecurringExpenses(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list recurring expenses", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Recurring expenses retrieved successfully", recurring)
+}
+
+// Get handles fetching a single recurring expense
+// @Summary Get a recurring expense
+// @Description Get one of the authenticated user's recurring expenses
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Success 200 {object} SuccessResponse "Recurring expense retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense not found"
+// @Router /api/recurring-expenses/{id} [get]
+func (h *RecurringHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	recurring, err := h.RecurringService.GetRecurringExpense(middleware.UserIDFromContext(r.Context()), id)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to get recurring expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Recurring expense retrieved successfully", recurring)
+}
+
+// Update handles updating a recurring expense
+// @Summary Update a recurring expense
+// @Description Replace the fields of a recurring expense; occurrences already recorded as expenses are not changed
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Param RecurringExpenseInput body RecurringExpenseInput true "Recurring Expense Input"
+// @Success 200 {object} SuccessResponse "Recurring expense updated successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/recurring-expenses/{id} [put]
+func (h *RecurringHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	recurring, ok := decodeRecurringExpenseInput(w, r)
+	if !ok {
+		return
+	}
+	recurring.ID = id
+	recurring.UserID = middleware.UserIDFromContext(r.Context())
+
+	updated, err := h.RecurringService.UpdateRecurringExpense(recurring)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update recurring expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Recurring expense updated successfully", updated)
+}
+
+// Delete handles deleting a recurring expense
+// @Summary Delete a recurring expense
+// @Description Stop and delete a recurring expense; expenses already recorded from it are kept
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Success 200 {object} SuccessResponse "Recurring expense deleted successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense not found"
+// @Router /api/recurring-expenses/{id} [delete]
+func (h *RecurringHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.RecurringService.DeleteRecurringExpense(middleware.UserIDFromContext(r.Context()), id); err != nil {
+		respondWithServiceError(w, err, "Failed to delete recurring expense")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Recurring expense deleted successfully", nil)
+}
+
+// Preview handles listing upcoming occurrences
+// @Summary Preview occurrences
+// @Description List the next occurrences of a recurring expense with skips and overrides applied
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Param count query int false "Number of occurrences (default 10, max 100)"
+// @Param from query string false "First date to consider (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} SuccessResponse "Occurrences retrieved successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense not found"
+// @Failure 422 {object} ErrorResponse "Invalid parameters"
+// @Router /api/recurring-expenses/{id}/preview [get]
+func (h *RecurringHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	count := 10
+	if value := query.Get("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPreviewCount {
+			respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+				"count": "count must be an integer between 1 and " + strconv.Itoa(maxPreviewCount),
+			})
+			return
+		}
+		count = n
+	}
+
+	from := time.Now().UTC()
+	if value := query.Get("from"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+				"from": "from must be a date in YYYY-MM-DD format",
+			})
+			return
+		}
+		from = date
+	}
+
+	occurrences, err := h.RecurringService.Preview(middleware.UserIDFromContext(r.Context()), id, from, count)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to preview occurrences")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Occurrences retrieved successfully", occurrences)
+}
+
+// SkipOccurrence handles skipping a single occurrence
+// @Summary Skip an occurrence
+// @Description Skip one occurrence of a recurring expense, deleting its expense if it was already recorded
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Param date path string true "Occurrence date (YYYY-MM-DD)"
+// @Success 200 {object} SuccessResponse "Occurrence skipped successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense or occurrence not found"
+// @Router /api/recurring-expenses/{id}/occurrences/{date}/skip [post]
+func (h *RecurringHandler) SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	id, date, ok := occurrencePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.RecurringService.SkipOccurrence(middleware.UserIDFromContext(r.Context()), id, date); err != nil {
+		respondWithServiceError(w, err, "Failed to skip occurrence")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Occurrence skipped successfully", nil)
+}
+
+// EditOccurrence handles overriding a single occurrence
+// @Summary Edit an occurrence
+// @Description Override the fields of one occurrence of a recurring expense, updating its expense if it was already recorded
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Param date path string true "Occurrence date (YYYY-MM-DD)"
+// @Param OccurrenceInput body OccurrenceInput true "Occurrence Input"
+// @Success 200 {object} SuccessResponse "Occurrence updated successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense or occurrence not found"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/recurring-expenses/{id}/occurrences/{date} [put]
+func (h *RecurringHandler) EditOccurrence(w http.ResponseWriter, r *http.Request) {
+	id, date, ok := occurrencePath(w, r)
+	if !ok {
+		return
+	}
+
+	var input OccurrenceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	override := &models.OccurrenceException{
+		OccurrenceDate: date,
+		CategoryID:     input.CategoryID,
+		Description:    input.Description,
+		Payee:          input.Payee,
+	}
+	if input.Amount != "" {
+		amount, _ := money.Parse(input.Amount)
+		override.Amount = &amount
+	}
+	if input.Date != "" {
+		moved, _ := time.Parse(dateLayout, input.Date)
+		override.Date = &moved
+	}
+
+	occurrence, err := h.RecurringService.EditOccurrence(middleware.UserIDFromContext(r.Context()), id, override)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update occurrence")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Occurrence updated successfully", occurrence)
+}
+
+// RestoreOccurrence handles removing the skip or override of a single occurrence
+// @Summary Restore an occurrence
+// @Description Undo a skip or override so the occurrence follows the recurring expense again
+// @Tags Recurring
+// @Produce json
+// @Param id path int true "Recurring expense ID"
+// @Param date path string true "Occurrence date (YYYY-MM-DD)"
+// @Success 200 {object} SuccessResponse "Occurrence restored successfully"
+// @Failure 404 {object} ErrorResponse "Recurring expense or occurrence not found"
+// @Router /api/recurring-expenses/{id}/occurrences/{date} [delete]
+func (h *RecurringHandler) RestoreOccurrence(w http.ResponseWriter, r *http.Request) {
+	id, date, ok := occurrencePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.RecurringService.RestoreOccurrence(middleware.UserIDFromContext(r.Context()), id, date); err != nil {
+		respondWithServiceError(w, err, "Failed to restore occurrence")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Occurrence restored successfully", nil)
+}
+
+// decodeRecurringExpenseInput decodes and validates the request body into a recurring expense
+func decodeRecurringExpenseInput(w http.ResponseWriter, r *http.Request) (*models.RecurringExpense, bool) {
+	var input RecurringExpenseInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+
+	// The validator already checked the amount and the date layout
+	amount, _ := money.Parse(input.Amount)
+	startDate, _ := time.Parse(dateLayout, input.StartDate)
+
+	active := true
+	if input.Active != nil {
+		active = *input.Active
+	}
+
+	return &models.RecurringExpense{
+		Amount:      amount,
+		CategoryID:  input.CategoryID,
+		Description: input.Description,
+		Payee:       input.Payee,
+		RRule:       input.RRule,
+		StartDate:   startDate,
+		Active:      active,
+	}, true
+}
+
+// occurrencePath parses the {id} and {date} route variables of occurrence routes
+func occurrencePath(w http.ResponseWriter, r *http.Request) (int, time.Time, bool) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+
+	date, err := time.Parse(dateLayout, mux.Vars(r)["date"])
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Resource not found", nil)
+		return 0, time.Time{}, false
+	}
+	return id, date, true
+}
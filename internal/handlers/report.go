@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// ReportHandler contains dependencies for reporting operations
+type ReportHandler struct {
+	ReportService *services.ReportService
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		ReportService: reportService,
+	}
+}
+
+// Spending handles the spending per category report
+// @Summary Spending report
+// @Description Total spending per category over a date range, converted at each day's exchange rate
+// @Tags Report
+// @Produce json
+// @Param from query string false "First day (YYYY-MM-DD), defaults to the first day of the current month"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param currency query string false "Report currency (ISO 4217), defaults to the home currency"
+// @Success 200 {object} SuccessResponse "Spending report retrieved successfully"
+// @Failure 422 {object} ErrorResponse "Invalid parameters"
+// @Router /api/reports/spending [get]
+func (h *ReportHandler) Spending(w http.ResponseWriter, r *http.Request) {
+	today := time.Now().UTC()
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := r.URL.Query()
+	validationErrors := map[string]string{}
+	for name, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			validationErrors[name] = name + " must be a date in YYYY-MM-DD format"
+			continue
+		}
+		*date = parsed
+	}
+	if len(validationErrors) == 0 && to.Before(from) {
+		validationErrors["to"] = "to must not be before from"
+	}
+	if len(validationErrors) > 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	currency, ok := reportCurrency(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.ReportService.Spending(middleware.UserIDFromContext(r.Context()), from, to, currency)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to compute spending report")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Spending report retrieved successfully", report)
+}
+
+// reportCurrency parses the optional "currency" query parameter shared by all report endpoints
+func reportCurrency(w http.ResponseWriter, r *http.Request) (string, bool) {
+	currency := strings.ToUpper(r.URL.Query().Get("currency"))
+	if currency != "" && !money.IsValidCurrency(currency) {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", map[string]string{
+			"currency": "currency must be an ISO 4217 currency code",
+		})
+		return "", false
+	}
+	return currency, true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// SessionHandler contains dependencies for session-related operations
+type SessionHandler struct {
+	SessionService *services.SessionService
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		SessionService: sessionService,
+	}
+}
+
+// List handles listing the user's sessions
+// @Summary List active sessions
+// @Description List the devices the authenticated user is logged in on, with the user agent and IP address they last refreshed from, most recently used first. The session of the request is marked as current.
+// @Tags Session
+// @Produce json
+// @Success 200 {object} SuccessResponse "Sessions retrieved successfully"
+// @Router /api/sessions [get]
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.SessionService.ListSessions(middleware.UserIDFromContext(r.Context()),
+		middleware.SessionIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list sessions", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+// Revoke handles signing out a session
+// @Summary Sign out a session
+// @Description Sign out one of the authenticated user's sessions; its refresh token stops working at once and its access tokens within a few seconds
+// @Tags Session
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} SuccessResponse "Session signed out successfully"
+// @Failure 404 {object} ErrorResponse "Session not found"
+// @Router /api/sessions/{id} [delete]
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	err := h.SessionService.RevokeSession(middleware.UserIDFromContext(r.Context()), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to sign out session")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Session signed out successfully", nil)
+}
+
+// RevokeOthers handles signing out every other session
+// @Summary Sign out everywhere else
+// @Description Sign out every session of the authenticated user except the one making the request
+// @Tags Session
+// @Produce json
+// @Success 200 {object} SuccessResponse "Other sessions signed out successfully"
+// @Router /api/sessions/others [delete]
+func (h *SessionHandler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	count, err := h.SessionService.RevokeOtherSessions(middleware.UserIDFromContext(r.Context()),
+		middleware.SessionIDFromContext(r.Context()))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to sign out other sessions", nil)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Other sessions signed out successfully", map[string]int{"revoked": count})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/utils"
+)
+
+// TwoFactorHandler contains dependencies for two-factor authentication operations
+type TwoFactorHandler struct {
+	TwoFactorService *services.TwoFactorService
+}
+
+// TwoFactorCodeInput represents a code from the authenticator app, or for
+// disabling and regenerating also a recovery code
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required,max=20" example:"123456"`
+}
+
+// RecoveryCodesResponse carries recovery codes, which are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7pm-x2qd,4hnc-wz8e"`
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		TwoFactorService: twoFactorService,
+	}
+}
+
+// Enroll handles starting two-factor authentication
+// @Summary Start two-factor authentication
+// @Description Generate a TOTP secret for the authenticated user and return it as an otpauth URI and a QR code PNG to scan with an authenticator app. It takes effect once confirmed with a code; enrolling again replaces an unconfirmed secret.
+// @Tags Two-Factor Authentication
+// @Produce json
+// @Success 200 {object} SuccessResponse "Two-factor authentication enrolment started"
+// @Failure 422 {object} ErrorResponse "Two-factor authentication is already enabled"
+// @Router /api/me/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := h.TwoFactorService.Enroll(middleware.UserIDFromContext(r.Context()))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to start two-factor authentication")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Two-factor authentication enrolment started", enrollment)
+}
+
+// Confirm handles enabling two-factor authentication
+// @Summary Confirm two-factor authentication
+// @Description Enable two-factor authentication with a first code from the authenticator app. The response holds one-time recovery codes for when the authenticator is lost; they are not shown again.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Param TwoFactorCodeInput body TwoFactorCodeInput true "Code from the authenticator app"
+// @Success 200 {object} SuccessResponse "Two-factor authentication enabled"
+// @Failure 422 {object} ErrorResponse "Invalid code or no enrolment started"
+// @Router /api/me/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.TwoFactorService.Confirm(middleware.UserIDFromContext(r.Context()), input.Code)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Two-factor authentication enabled", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes handles replacing the recovery codes
+// @Summary Regenerate recovery codes
+// @Description Replace the recovery codes of the authenticated user after checking a code from the authenticator app or a recovery code; the old recovery codes stop working
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Param TwoFactorCodeInput body TwoFactorCodeInput true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} SuccessResponse "Recovery codes regenerated"
+// @Failure 422 {object} ErrorResponse "Invalid code or two-factor authentication not enabled"
+// @Router /api/me/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.TwoFactorService.RegenerateRecoveryCodes(middleware.UserIDFromContext(r.Context()), input.Code)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Recovery codes regenerated", RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable handles turning two-factor authentication off
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off after checking a code from the authenticator app or a recovery code
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Param TwoFactorCodeInput body TwoFactorCodeInput true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} SuccessResponse "Two-factor authentication disabled"
+// @Failure 422 {object} ErrorResponse "Invalid code or two-factor authentication not enabled"
+// @Router /api/me/2fa [delete]
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	input, ok := decodeTwoFactorCode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.TwoFactorService.Disable(middleware.UserIDFromContext(r.Context()), input.Code); err != nil {
+		respondWithServiceError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// decodeTwoFactorCode decodes and validates a TwoFactorCodeInput, responding with the error if it is invalid
+func decodeTwoFactorCode(w http.ResponseWriter, r *http.Request) (*TwoFactorCodeInput, bool) {
+	var input TwoFactorCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return nil, false
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return nil, false
+	}
+	return &input, true
+}
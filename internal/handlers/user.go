@@ -2,14 +2,30 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/henok-tesfu/expense-manager/internal/ecb"
+	"github.com/henok-tesfu/expense-manager/internal/export"
+	"github.com/henok-tesfu/expense-manager/internal/importer"
 	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/password"
 	"github.com/henok-tesfu/expense-manager/internal/services"
 	"github.com/henok-tesfu/expense-manager/internal/utils"
 )
 
+// refreshTokenPath limits the refresh token cookie to the refresh and logout endpoints
+const refreshTokenPath = "/api/auth"
+
 // ErrorResponse represents the standard error response structure
 type ErrorResponse struct {
 	Message string            `json:"message"`
@@ -24,41 +40,120 @@ type SuccessResponse struct {
 
 // UserHandler contains dependencies for user-related operations
 type UserHandler struct {
-	UserService  *services.UserService
-	TokenService *jwt.TokenService
+	UserService              *services.UserService
+	TokenService             *jwt.TokenService
+	EmailVerificationService *services.EmailVerificationService
+	TwoFactorService         *services.TwoFactorService
+	LoginGuardService        *services.LoginGuardService
 }
 
 // RegisterInput represents the input structure for user registration
 type RegisterInput struct {
 	Username string `json:"username" validate:"required,min=3"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	// Password has to meet the password policy; violations are listed by rule in errors
+	Password string `json:"password" validate:"required"`
+	// HomeCurrency defaults to USD
+	HomeCurrency string `json:"home_currency" validate:"omitempty,currency" example:"EUR"`
+}
+
+// HomeCurrencyInput represents the input structure for changing the home currency
+type HomeCurrencyInput struct {
+	Currency string `json:"currency" validate:"required,currency" example:"EUR"`
+}
+
+// UpdateProfileInput represents the fields of a profile update; fields that are left out stay as they are
+type UpdateProfileInput struct {
+	Username *string `json:"username" validate:"omitempty,min=3,max=255" example:"jane"`
+	// Email has to be verified again after a change, which needs CurrentPassword
+	Email        *string `json:"email" validate:"omitempty,email,max=255" example:"jane@example.com"`
+	HomeCurrency *string `json:"home_currency" validate:"omitempty,currency" example:"EUR"`
+	// Locale is a BCP 47 language tag
+	Locale *string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+	// TimeZone is an IANA time zone name
+	TimeZone        *string `json:"time_zone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+// ChangePasswordInput represents the input structure for changing the password
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	// NewPassword has to meet the password policy
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
+// DeleteAccountInput confirms the deletion of the account
+type DeleteAccountInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	// Code from the authenticator app or a recovery code, needed with two-factor authentication
+	Code string `json:"code" validate:"max=20" example:"123456"`
+}
+
+// Token delivery modes of login
+const (
+	// TokenModeCookie sets the tokens as HTTP-only cookies, for browsers
+	TokenModeCookie = "cookie"
+	// TokenModeBody returns the tokens in the response body, for API clients
+	TokenModeBody = "body"
+)
+
 // LoginInput represents the input structure for user login
 // @Description Input payload for login
 type LoginInput struct {
-	Email    string `json:"email" validate:"required,email"` // User email
-	Password string `json:"password" validate:"required"`    // User password
+	Email    string `json:"email" validate:"required,email,max=255"` // User email
+	Password string `json:"password" validate:"required"`            // User password
+	// Mode is "cookie" (the default) or "body" to get the tokens in the response instead of cookies
+	Mode string `json:"mode" validate:"omitempty,oneof=cookie body" example:"body"`
+}
+
+// MFALoginInput represents the second step of a login of a user with two-factor authentication
+type MFALoginInput struct {
+	// MFAToken is the token returned by the first step of the login
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// Code is a code from the authenticator app or a recovery code
+	Code string `json:"code" validate:"required,max=20" example:"123456"`
+	// Mode is "cookie" (the default) or "body" to get the tokens in the response instead of cookies
+	Mode string `json:"mode" validate:"omitempty,oneof=cookie body" example:"body"`
+}
+
+// RefreshInput represents the optional body of refresh and logout requests
+// from clients that keep the refresh token themselves instead of in a cookie
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse carries the tokens of a login or refresh in the response
+// body. The access token is sent as "Authorization: Bearer <token>".
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	// ExpiresIn is the number of seconds the access token is valid for
+	ExpiresIn int `json:"expires_in" example:"900"`
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userService *services.UserService, tokenService *jwt.TokenService) *UserHandler {
+func NewUserHandler(userService *services.UserService, tokenService *jwt.TokenService,
+	emailVerificationService *services.EmailVerificationService, twoFactorService *services.TwoFactorService,
+	loginGuardService *services.LoginGuardService) *UserHandler {
 	return &UserHandler{
-		UserService:  userService,
-		TokenService: tokenService,
+		UserService:              userService,
+		TokenService:             tokenService,
+		EmailVerificationService: emailVerificationService,
+		TwoFactorService:         twoFactorService,
+		LoginGuardService:        loginGuardService,
 	}
 }
 
 // Register handles user registration
 // @Summary Register a new user
-// @Description Create a new user with a username, email, and password
+// @Description Create a new user with a username, email, and password. The user starts unverified and is sent an email with a verification link.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param RegisterInput body RegisterInput true "Register Input"
 // @Success 201 {object} SuccessResponse "User registered successfully"
-// @Failure 422 {object} ErrorResponse "Validation or payload errors"
+// @Failure 422 {object} ErrorResponse "Validation errors or password policy violations"
 // @Router /api/register [post]
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var registerInput RegisterInput
@@ -75,18 +170,30 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Register the user
-	newUser, err := h.UserService.RegisterUser(registerInput.Username, registerInput.Email, registerInput.Password)
+	newUser, err := h.UserService.RegisterUser(registerInput.Username, registerInput.Email, registerInput.Password,
+		registerInput.HomeCurrency)
+	if errors.Is(err, password.ErrWeakPassword) {
+		respondWithServiceError(w, err, "Failed to register user")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	respondWithSuccess(w, http.StatusCreated, "User registered successfully", newUser)
+	// The user can ask for the email again, so a failed send is not fatal
+	if err := h.EmailVerificationService.SendVerification(r.Context(), newUser); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", newUser.ID, err)
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "User registered successfully; check your email to verify it", newUser)
 }
 
 // Login handles user login
 // @Summary Login a user
-// @Description Authenticate a user with an email and password
+// @Description Authenticate a user with an email and password. The tokens are set as cookies, or with mode "body" returned in the response for clients that send them in the Authorization header.
+// @Description Repeated failures make the account and the client address wait exponentially longer between attempts, up to a temporary lockout.
+// @Description For users with two-factor authentication no tokens are issued; the response has "mfa_required" and a short-lived "mfa_token" to send with a code to /api/login/mfa.
 // @Tags User
 // @Accept json
 // @Produce json
@@ -95,6 +202,8 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} ErrorResponse "payload errors"
 // @Failure 422 {object} ErrorResponse "Validation errors"
 // @Failure 401 {object} ErrorResponse "Invalid email or password"
+// @Failure 403 {object} ErrorResponse "Account is disabled"
+// @Failure 429 {object} ErrorResponse "Too many failed logins; wait for the Retry-After header"
 // @Router /api/login [post]
 // Login handles user login
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -112,82 +221,472 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Accounts and addresses with recent failed logins have to wait
+	device := clientDevice(r)
+	retryAfter, err := h.LoginGuardService.Attempt(r.Context(), credentials.Email, device)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to check login attempts")
+		return
+	}
+
 	// Authenticate user credentials
 	user, err := h.UserService.AuthenticateUser(credentials.Email, credentials.Password)
+	if errors.Is(err, services.ErrAccountDisabled) {
+		respondWithError(w, http.StatusForbidden, "Account is disabled; contact support", nil)
+		return
+	}
+	if err != nil {
+		h.loginFailed(w, r, credentials.Email, models.FailedLoginInvalidCredentials, "Invalid email or password", retryAfter)
+		return
+	}
+
+	// The password alone is not enough with two-factor authentication
+	if user.TwoFactorEnabled {
+		if err := h.LoginGuardService.PasswordAccepted(r.Context(), device); err != nil {
+			log.Printf("Failed to release login attempt of user %d: %v", user.ID, err)
+		}
+		mfaToken, err := h.TokenService.GenerateMFAToken(user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to generate tokens", nil)
+			return
+		}
+		respondWithSuccess(w, http.StatusOK, "Two-factor authentication code required", map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	h.completeLogin(w, r, user, credentials.Mode)
+}
+
+// LoginMFA handles the second step of a login with two-factor authentication
+// @Summary Complete a login with two-factor authentication
+// @Description Exchange the mfa_token of a login and a code from the authenticator app, or a recovery code, for the tokens of the login. Each code and recovery code works only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param MFALoginInput body MFALoginInput true "MFA Login Input"
+// @Success 200 {object} SuccessResponse "Login successful"
+// @Failure 400 {object} ErrorResponse "payload errors"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Failure 401 {object} ErrorResponse "Invalid or expired MFA token or code"
+// @Failure 403 {object} ErrorResponse "Account is disabled"
+// @Failure 429 {object} ErrorResponse "Too many failed logins; wait for the Retry-After header"
+// @Router /api/login/mfa [post]
+func (h *UserHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var input MFALoginInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	claims, err := h.TokenService.ValidateMFAToken(input.MFAToken)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
+		return
+	}
+
+	user, err := h.UserService.GetUser(claims.UserId)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Invalid email or password", nil)
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired MFA token", nil)
 		return
 	}
+	if user.DisabledAt != nil {
+		respondWithError(w, http.StatusForbidden, "Account is disabled; contact support", nil)
+		return
+	}
+
+	// Codes are short, so guessing them is throttled like guessing passwords
+	retryAfter, err := h.LoginGuardService.Attempt(r.Context(), user.Email, clientDevice(r))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to check login attempts")
+		return
+	}
+
+	err = h.TwoFactorService.Verify(user.ID, input.Code)
+	if errors.Is(err, services.ErrInvalidTwoFactorCode) || errors.Is(err, services.ErrTwoFactorNotEnabled) {
+		h.loginFailed(w, r, user.Email, models.FailedLoginInvalidCode, "Invalid two-factor authentication code", retryAfter)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to verify two-factor authentication code", nil)
+		return
+	}
+
+	h.completeLogin(w, r, user, input.Mode)
+}
+
+// loginFailed records a failed login and responds with 401, telling the
+// client in Retry-After when it has to wait before trying again
+func (h *UserHandler) loginFailed(w http.ResponseWriter, r *http.Request, email, reason, message string, retryAfter time.Duration) {
+	h.LoginGuardService.Failed(email, clientDevice(r), reason)
+	if retryAfter > 0 {
+		throttled := &services.LoginThrottledError{RetryAfter: retryAfter}
+		w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+	}
+	respondWithError(w, http.StatusUnauthorized, message, nil)
+}
+
+// completeLogin issues the tokens of a login, as cookies or in the body depending on mode
+func (h *UserHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, mode string) {
+	if err := h.LoginGuardService.Succeeded(r.Context(), user.Email, clientDevice(r)); err != nil {
+		log.Printf("Failed to reset failed logins of user %d: %v", user.ID, err)
+	}
 
 	// Generate tokens
-	if err := h.generateAndSetTokens(w, user.ID); err != nil {
+	tokens, err := h.generateTokens(r, user.ID)
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to generate tokens", nil)
 		return
 	}
 
-	// Send a success response with user data
-	respondWithSuccess(w, http.StatusOK, "Login successful", map[string]interface{}{
+	data := map[string]interface{}{
 		"user": map[string]interface{}{
-			"id":    user.ID,
-			"email": user.Email,
+			"id":             user.ID,
+			"email":          user.Email,
+			"email_verified": user.EmailVerifiedAt != nil,
 		},
-	})
+	}
+	if mode == TokenModeBody {
+		data["tokens"] = tokens
+	} else {
+		h.setTokenCookies(w, tokens)
+	}
+
+	// Send a success response with user data
+	respondWithSuccess(w, http.StatusOK, "Login successful", data)
 }
 
 // Refresh handles token refreshing
-// @Summary Refresh the access token
-// @Description Generate a new access token using a valid refresh token
+// @Summary Refresh the tokens
+// @Description Exchange a valid refresh token for a new access token and a new refresh token; the old refresh token stops working. Presenting a refresh token that was already exchanged revokes every token of that login, which then has to log in again.
+// @Description The refresh token is read from the cookie, or from the body for clients that keep it themselves; those get the new tokens in the response instead of cookies.
 // @Tags User
 // @Accept json
 // @Produce json
-// @Success 200 {object} SuccessResponse "Access token refreshed"
+// @Param RefreshInput body RefreshInput false "Refresh token of non-browser clients"
+// @Success 200 {object} SuccessResponse "Tokens refreshed"
+// @Failure 400 {object} ErrorResponse "Invalid JSON payload"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Router /api/auth/refresh [post]
 func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
-	// Get the refresh token from cookies
-	refreshTokenCookie, err := r.Cookie("refresh_token")
+	// Get the refresh token from the body or the cookie
+	refreshToken, inBody, err := refreshTokenFrom(r)
 	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON payload", nil)
+		return
+	}
+	if refreshToken == "" {
 		respondWithError(w, http.StatusUnauthorized, "Missing refresh token", nil)
 		return
 	}
 
-	// Validate the refresh token
-	refreshClaims, err := h.TokenService.ValidateRefreshToken(refreshTokenCookie.Value)
+	// Exchange the refresh token for a new one
+	newRefreshToken, refreshClaims, err := h.TokenService.RotateRefreshToken(refreshToken, clientDevice(r))
+	if errors.Is(err, jwt.ErrTokenReused) {
+		h.clearCookie(w, "access_token", "/")
+		h.clearCookie(w, "refresh_token", refreshTokenPath)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used; please log in again", nil)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
 		return
 	}
 
-	// Generate a new access token
-	newAccessToken, err := h.TokenService.GenerateAccessToken(refreshClaims.UserId)
+	// Generate a new access token, picking up a verification or role change since the last one
+	grant, err := h.UserService.AccessGrant(refreshClaims.UserId)
+	if errors.Is(err, services.ErrAccountDisabled) || errors.Is(err, services.ErrUserNotFound) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate new access token", nil)
+		return
+	}
+	newAccessToken, err := h.TokenService.GenerateAccessToken(refreshClaims.UserId, refreshClaims.SessionID, grant)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to generate new access token", nil)
 		return
 	}
 
-	// Set the new access token in cookies
-	h.setCookie(w, "access_token", newAccessToken, h.TokenService.Config.AccessTokenExpiry, "/")
+	tokens := h.tokenResponse(newAccessToken, newRefreshToken)
+	if inBody {
+		respondWithSuccess(w, http.StatusOK, "Tokens refreshed", tokens)
+		return
+	}
 
-	respondWithSuccess(w, http.StatusOK, "Access token refreshed", nil)
+	// Set the new tokens in cookies
+	h.setTokenCookies(w, tokens)
+	respondWithSuccess(w, http.StatusOK, "Tokens refreshed", nil)
 }
 
-// generateAndSetTokens generates access and refresh tokens and sets them as cookies
-func (h *UserHandler) generateAndSetTokens(w http.ResponseWriter, userId int) error {
-	accessToken, err := h.TokenService.GenerateAccessToken(userId)
+// Logout handles logging out
+// @Summary Log out
+// @Description End the session of the refresh token, read from the cookie or the body, and clear both token cookies. Succeeds even when the refresh token is missing or already invalid.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param RefreshInput body RefreshInput false "Refresh token of non-browser clients"
+// @Success 200 {object} SuccessResponse "Logged out successfully"
+// @Router /api/auth/logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if refreshToken, _, _ := refreshTokenFrom(r); refreshToken != "" {
+		if err := h.TokenService.RevokeRefreshToken(refreshToken); err != nil {
+			log.Printf("Failed to revoke refresh token: %v", err)
+		}
+	}
+
+	h.clearCookie(w, "access_token", "/")
+	h.clearCookie(w, "refresh_token", refreshTokenPath)
+
+	respondWithSuccess(w, http.StatusOK, "Logged out successfully", nil)
+}
+
+// SetHomeCurrency handles changing the currency reports default to
+// @Summary Set the home currency
+// @Description Change the currency that reports are converted to when no currency parameter is given
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param HomeCurrencyInput body HomeCurrencyInput true "Home Currency Input"
+// @Success 200 {object} SuccessResponse "Home currency updated successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors"
+// @Router /api/me/home-currency [put]
+func (h *UserHandler) SetHomeCurrency(w http.ResponseWriter, r *http.Request) {
+	var input HomeCurrencyInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	user, err := h.UserService.SetHomeCurrency(middleware.UserIDFromContext(r.Context()), input.Currency)
 	if err != nil {
-		return err
+		respondWithServiceError(w, err, "Failed to update home currency")
+		return
 	}
 
-	refreshToken, err := h.TokenService.GenerateRefreshToken(userId)
+	respondWithSuccess(w, http.StatusOK, "Home currency updated successfully", user)
+}
+
+// Profile handles reading the user's profile
+// @Summary Get the profile
+// @Description Get the authenticated user's profile
+// @Tags User
+// @Produce json
+// @Success 200 {object} SuccessResponse "Profile retrieved successfully"
+// @Router /api/me [get]
+func (h *UserHandler) Profile(w http.ResponseWriter, r *http.Request) {
+	user, err := h.UserService.GetUser(middleware.UserIDFromContext(r.Context()))
 	if err != nil {
-		return err
+		respondWithServiceError(w, err, "Failed to retrieve profile")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Profile retrieved successfully", user)
+}
+
+// UpdateProfile handles changing the user's profile
+// @Summary Update the profile
+// @Description Change the username, email, home currency, locale or time zone of the authenticated user; fields that are left out stay as they are. A new email needs the current password, becomes unverified and is sent a verification link.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param UpdateProfileInput body UpdateProfileInput true "Update Profile Input"
+// @Success 200 {object} SuccessResponse "Profile updated successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors, email taken or incorrect current password"
+// @Failure 429 {object} ErrorResponse "Too many incorrect passwords; wait for the Retry-After header"
+// @Router /api/me [patch]
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var input UpdateProfileInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
 	}
 
-	// Set cookies
-	h.setCookie(w, "access_token", accessToken, h.TokenService.Config.AccessTokenExpiry, "/")
-	h.setCookie(w, "refresh_token", refreshToken, h.TokenService.Config.RefreshTokenExpiry, "/api/auth/refresh")
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
 
-	return nil
+	update := services.ProfileUpdate{
+		Username:        input.Username,
+		Email:           input.Email,
+		HomeCurrency:    input.HomeCurrency,
+		Locale:          input.Locale,
+		TimeZone:        input.TimeZone,
+		CurrentPassword: input.CurrentPassword,
+	}
+	user, emailChanged, err := h.UserService.UpdateProfile(r.Context(), middleware.UserIDFromContext(r.Context()),
+		clientDevice(r), update)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update profile")
+		return
+	}
+
+	message := "Profile updated successfully"
+	if emailChanged {
+		// The user can ask for the email again, so a failed send is not fatal
+		if err := h.EmailVerificationService.SendVerification(r.Context(), user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+		message = "Profile updated successfully; check your email to verify the new address"
+	}
+
+	respondWithSuccess(w, http.StatusOK, message, user)
+}
+
+// ChangePassword handles changing the user's password
+// @Summary Change the password
+// @Description Set a new password after checking the current one. Every other session of the user is signed out.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param ChangePasswordInput body ChangePasswordInput true "Change Password Input"
+// @Success 200 {object} SuccessResponse "Password changed successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors, incorrect current password or password policy violations"
+// @Failure 429 {object} ErrorResponse "Too many incorrect passwords; wait for the Retry-After header"
+// @Router /api/me/password [post]
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var input ChangePasswordInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	err := h.UserService.ChangePassword(r.Context(), middleware.UserIDFromContext(r.Context()), clientDevice(r),
+		middleware.SessionIDFromContext(r.Context()), input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to change password")
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Password changed successfully", nil)
+}
+
+// DeleteAccount handles deleting the user's account
+// @Summary Delete the account
+// @Description Delete the authenticated user's account after checking the current password and, with two-factor authentication, a code. Every session and token stops working at once; the expenses, attachments and all other data are permanently deleted after a grace period.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param DeleteAccountInput body DeleteAccountInput true "Delete Account Input"
+// @Success 200 {object} SuccessResponse "Account deleted successfully"
+// @Failure 422 {object} ErrorResponse "Validation errors, incorrect current password or two-factor code"
+// @Failure 429 {object} ErrorResponse "Too many incorrect passwords or codes; wait for the Retry-After header"
+// @Router /api/me [delete]
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	var input DeleteAccountInput
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", nil)
+		return
+	}
+
+	if valid, validationErrors := utils.ValidateStruct(&input); !valid {
+		respondWithError(w, http.StatusUnprocessableEntity, "Validation failed", validationErrors)
+		return
+	}
+
+	err := h.UserService.DeleteAccount(r.Context(), middleware.UserIDFromContext(r.Context()), clientDevice(r),
+		input.CurrentPassword, input.Code)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to delete account")
+		return
+	}
+
+	h.clearCookie(w, "access_token", "/")
+	h.clearCookie(w, "refresh_token", refreshTokenPath)
+
+	respondWithSuccess(w, http.StatusOK, "Account deleted successfully", nil)
+}
+
+// generateTokens starts a session for the requesting device and returns its
+// tokens, which carry the user's roles and permissions
+func (h *UserHandler) generateTokens(r *http.Request, userId int) (*TokenResponse, error) {
+	grant, err := h.UserService.AccessGrant(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, sessionID, err := h.TokenService.GenerateRefreshToken(userId, clientDevice(r))
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := h.TokenService.GenerateAccessToken(userId, sessionID, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.tokenResponse(accessToken, refreshToken), nil
+}
+
+func (h *UserHandler) tokenResponse(accessToken, refreshToken string) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.TokenService.Config.AccessTokenExpiry / time.Second),
+	}
+}
+
+// setTokenCookies sets the tokens as cookies
+func (h *UserHandler) setTokenCookies(w http.ResponseWriter, tokens *TokenResponse) {
+	h.setCookie(w, "access_token", tokens.AccessToken, h.TokenService.Config.AccessTokenExpiry, "/")
+	h.setCookie(w, "refresh_token", tokens.RefreshToken, h.TokenService.Config.RefreshTokenExpiry, refreshTokenPath)
+}
+
+// refreshTokenFrom returns the refresh token of the request body, reporting
+// inBody, or else of the cookie. An empty body is allowed; a body that is
+// not valid JSON is an error.
+func refreshTokenFrom(r *http.Request) (token string, inBody bool, err error) {
+	var input RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		return "", false, err
+	}
+	if input.RefreshToken != "" {
+		return input.RefreshToken, true, nil
+	}
+
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		return cookie.Value, false, nil
+	}
+	return "", false, nil
+}
+
+// clientDevice describes the client of a request for its session
+func clientDevice(r *http.Request) jwt.Device {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = strings.ToValidUTF8(userAgent[:255], "")
+	}
+	return jwt.Device{UserAgent: userAgent, IP: ip}
 }
 
 // setCookie simplifies setting HTTP-only cookies
@@ -203,6 +702,19 @@ func (h *UserHandler) setCookie(w http.ResponseWriter, name, value string, expir
 	})
 }
 
+// clearCookie removes a cookie set by setCookie
+func (h *UserHandler) clearCookie(w http.ResponseWriter, name, path string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     path,
+		MaxAge:   -1,
+	})
+}
+
 // respondWithError sends a JSON error response
 func respondWithError(w http.ResponseWriter, statusCode int, message string, errors map[string]string) {
 	w.WriteHeader(statusCode)
@@ -220,3 +732,56 @@ func respondWithSuccess(w http.ResponseWriter, statusCode int, message string, d
 		Data:    data,
 	})
 }
+
+// respondWithServiceError maps known service errors to HTTP status codes
+func respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	var throttled *services.LoginThrottledError
+	var policyErr *password.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		violations := make(map[string]string, len(policyErr.Violations))
+		for rule, message := range policyErr.Violations {
+			violations["Password."+rule] = message
+		}
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error(), violations)
+	case errors.Is(err, services.ErrExpenseNotFound), errors.Is(err, services.ErrCategoryNotFound),
+		errors.Is(err, services.ErrBudgetNotFound), errors.Is(err, services.ErrRecurringExpenseNotFound),
+		errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrExchangeRateNotFound),
+		errors.Is(err, services.ErrAttachmentNotFound), errors.Is(err, services.ErrImportProfileNotFound),
+		errors.Is(err, services.ErrAccountNotFound), errors.Is(err, services.ErrSessionNotFound),
+		errors.Is(err, services.ErrPersonalTokenNotFound), errors.Is(err, services.ErrDataExportNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrInvalidCategory), errors.Is(err, services.ErrCategoryCycle),
+		errors.Is(err, services.ErrBudgetNotStarted), errors.Is(err, services.ErrNotAnOccurrence),
+		errors.Is(err, services.ErrInvalidRecurrenceRule), errors.Is(err, money.ErrNoRate),
+		errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, ecb.ErrInvalidFile), errors.Is(err, ecb.ErrUnknownFormat),
+		errors.Is(err, importer.ErrUnknownFormat), errors.Is(err, importer.ErrInvalidMapping),
+		errors.Is(err, importer.ErrProfileRequired), errors.Is(err, importer.ErrInvalidStatement),
+		errors.Is(err, services.ErrInvalidAccount), errors.Is(err, services.ErrAccountIdentifierTaken),
+		errors.Is(err, export.ErrUnknownFormat), errors.Is(err, services.ErrUnknownScope),
+		errors.Is(err, services.ErrTokenExpiryInPast), errors.Is(err, services.ErrInvalidResetToken),
+		errors.Is(err, services.ErrInvalidVerificationToken),
+		errors.Is(err, services.ErrEmailAlreadyVerified), errors.Is(err, services.ErrTwoFactorEnabled),
+		errors.Is(err, services.ErrTwoFactorNotEnrolled), errors.Is(err, services.ErrTwoFactorNotEnabled),
+		errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrEmailTaken),
+		errors.Is(err, services.ErrIncorrectPassword), errors.Is(err, services.ErrUnknownRole),
+		errors.Is(err, services.ErrCannotTargetSelf), errors.Is(err, services.ErrCannotImpersonateStaff),
+		errors.Is(err, services.ErrAccountDisabled):
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error(), nil)
+	case errors.Is(err, services.ErrDataExportInProgress), errors.Is(err, services.ErrCategoryHasBudgets):
+		respondWithError(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, services.ErrInvalidDownloadLink), errors.Is(err, services.ErrStaffTarget):
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.As(err, &throttled):
+		w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+		respondWithError(w, http.StatusTooManyRequests, err.Error(), nil)
+	case errors.Is(err, services.ErrVerificationThrottled):
+		respondWithError(w, http.StatusTooManyRequests, err.Error(), nil)
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, services.ErrUnsupportedAttachmentType):
+		respondWithError(w, http.StatusUnsupportedMediaType, err.Error(), nil)
+	default:
+		respondWithError(w, http.StatusInternalServerError, fallback, nil)
+	}
+}
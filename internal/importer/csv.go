@@ -0,0 +1,303 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// dateTokens maps the placeholders accepted in profile date formats to Go layout elements
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"DD", "02"}, {"D", "2"},
+}
+
+// DateLayout converts a date format such as "DD.MM.YYYY" or "M/D/YY" into a Go time layout
+func DateLayout(format string) (string, error) {
+	var layout strings.Builder
+	hasYear, hasMonth, hasDay := false, false, false
+	for rest := format; rest != ""; {
+		matched := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(rest, t.token) {
+				layout.WriteString(t.layout)
+				rest = rest[len(t.token):]
+				switch t.token[0] {
+				case 'Y':
+					hasYear = true
+				case 'M':
+					hasMonth = true
+				case 'D':
+					hasDay = true
+				}
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if c := rest[0]; (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			return "", fmt.Errorf("unsupported date format element %q", string(c))
+		}
+		layout.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+
+	if !hasYear || !hasMonth || !hasDay {
+		return "", errors.New("date format needs a year (YYYY or YY), a month (MM, M or MMM) and a day (DD or D)")
+	}
+	return layout.String(), nil
+}
+
+// ParseCSV reads a CSV statement as described by the profile. Rows that
+// cannot be read are reported together as LineErrors.
+func ParseCSV(r io.Reader, profile *models.ImportProfile) ([]Transaction, error) {
+	layout, err := DateLayout(profile.DateFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if profile.Delimiter != "" {
+		reader.Comma = []rune(profile.Delimiter)[0]
+	}
+
+	lineErrors := LineErrors{}
+	for i := 0; i < profile.SkipRows; i++ {
+		if _, err := reader.Read(); err == io.EOF {
+			return []Transaction{}, nil
+		} else if err != nil {
+			return nil, csvError(err)
+		}
+	}
+
+	var header []string
+	if profile.HasHeader {
+		header, err = reader.Read()
+		if err == io.EOF {
+			return []Transaction{}, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := resolveColumns(profile, header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+	}
+
+	transactions := []Transaction{}
+	for !lineErrors.full() {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+
+		t, err := columns.transaction(record, layout, profile)
+		if err != nil {
+			lineErrors[line] = err.Error()
+			continue
+		}
+		t.Line = line
+		transactions = append(transactions, t)
+	}
+
+	if len(lineErrors) > 0 {
+		return nil, lineErrors
+	}
+	return transactions, nil
+}
+
+// csvColumns holds the resolved positions of the mapped columns; -1 means unmapped
+type csvColumns struct {
+	date, amount, debit, credit, payee int
+	description                        []int
+}
+
+func resolveColumns(profile *models.ImportProfile, header []string) (*csvColumns, error) {
+	find := func(ref string) (int, error) {
+		if ref == "" {
+			return -1, nil
+		}
+		if header == nil {
+			n, err := strconv.Atoi(ref)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("column %q must be a 1-based position because the file has no header", ref)
+			}
+			return n - 1, nil
+		}
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(ref)) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("column %q not found in the header", ref)
+	}
+
+	columns := &csvColumns{}
+	var err error
+	for _, c := range []struct {
+		ref    string
+		target *int
+	}{
+		{profile.DateColumn, &columns.date},
+		{profile.AmountColumn, &columns.amount},
+		{profile.DebitColumn, &columns.debit},
+		{profile.CreditColumn, &columns.credit},
+		{profile.PayeeColumn, &columns.payee},
+	} {
+		if *c.target, err = find(c.ref); err != nil {
+			return nil, err
+		}
+	}
+	for _, ref := range profile.DescriptionColumns {
+		index, err := find(ref)
+		if err != nil {
+			return nil, err
+		}
+		if index >= 0 {
+			columns.description = append(columns.description, index)
+		}
+	}
+	return columns, nil
+}
+
+func (c *csvColumns) transaction(record []string, layout string, profile *models.ImportProfile) (Transaction, error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	date, err := time.Parse(layout, field(c.date))
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid date %q, expected format %s", field(c.date), profile.DateFormat)
+	}
+
+	var amount money.Amount
+	switch profile.AmountSign {
+	case models.SignDebitCredit:
+		debit, err := parseAmount(field(c.debit), profile.DecimalSeparator, profile.Currency)
+		if err != nil {
+			return Transaction{}, err
+		}
+		credit, err := parseAmount(field(c.credit), profile.DecimalSeparator, profile.Currency)
+		if err != nil {
+			return Transaction{}, err
+		}
+		// Some banks print debits with a minus sign in the debit column
+		if debit.IsNegative() {
+			debit = debit.Neg()
+		}
+		if credit.IsNegative() {
+			credit = credit.Neg()
+		}
+		if amount, err = debit.Sub(credit); err != nil {
+			return Transaction{}, err
+		}
+	default:
+		if field(c.amount) == "" {
+			return Transaction{}, errors.New("amount is empty")
+		}
+		if amount, err = parseAmount(field(c.amount), profile.DecimalSeparator, profile.Currency); err != nil {
+			return Transaction{}, err
+		}
+		if profile.AmountSign == models.SignNegativeIsExpense {
+			amount = amount.Neg()
+		}
+	}
+
+	var description []string
+	for _, i := range c.description {
+		if value := field(i); value != "" {
+			description = append(description, value)
+		}
+	}
+
+	return Transaction{
+		Date:        date,
+		Amount:      amount,
+		Description: truncate(strings.Join(description, " "), 255),
+		Payee:       truncate(field(c.payee), 255),
+	}, nil
+}
+
+// parseAmount reads a statement amount such as "-1.234,56", "(12.00)" or
+// "1'000.00 " using the given decimal separator; an empty value is zero
+func parseAmount(value, decimalSeparator, currency string) (money.Amount, error) {
+	original := value
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return money.Zero(currency)
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+	if strings.HasSuffix(value, "-") {
+		negative = !negative
+		value = strings.TrimSuffix(value, "-")
+	}
+	if strings.HasPrefix(value, "-") {
+		negative = !negative
+		value = value[1:]
+	}
+	value = strings.TrimPrefix(value, "+")
+
+	thousands := ","
+	if decimalSeparator == "," {
+		thousands = "."
+	}
+	value = strings.NewReplacer(thousands, "", " ", "", " ", "", "'", "").Replace(value)
+	value = strings.Replace(value, decimalSeparator, ".", 1)
+
+	amount, err := money.ParseDecimal(value, currency)
+	if err != nil {
+		return money.Amount{}, fmt.Errorf("invalid amount %q", strings.TrimSpace(original))
+	}
+	if negative {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// csvError reports a malformed CSV file at the line where reading failed
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return LineErrors{parseErr.StartLine: parseErr.Err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"DD.MM.YYYY", "02.01.2006"},
+		{"M/D/YY", "1/2/06"},
+		{"YYYY-MM-DD", "2006-01-02"},
+		{"D MMM YYYY", "2 Jan 2006"},
+		{"MMMM D, YYYY", "January 2, 2006"},
+	}
+	for _, tt := range tests {
+		got, err := DateLayout(tt.format)
+		if err != nil {
+			t.Errorf("DateLayout(%q): %v", tt.format, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("DateLayout(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	for _, format := range []string{"", "DD.MM", "YYYY-MM", "DD/MM/YYYY hh:mm", "YYYYMMDDX"} {
+		if _, err := DateLayout(format); err == nil {
+			t.Errorf("DateLayout(%q) succeeded, want an error", format)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value     string
+		separator string
+		want      string
+	}{
+		{"12.34", ".", "12.34 EUR"},
+		{"-1,234.56", ".", "-1234.56 EUR"},
+		{"-1.234,56", ",", "-1234.56 EUR"},
+		{"(12.00)", ".", "-12.00 EUR"},
+		{"12.00-", ".", "-12.00 EUR"},
+		{"(12.00-)", ".", "12.00 EUR"},
+		{"1'000.00 ", ".", "1000.00 EUR"},
+		{"1 000,5", ",", "1000.50 EUR"},
+		{"+7", ".", "7.00 EUR"},
+		{"", ".", "0.00 EUR"},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.value, tt.separator, "EUR")
+		if err != nil {
+			t.Errorf("parseAmount(%q, %q): %v", tt.value, tt.separator, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseAmount(%q, %q) = %s, want %s", tt.value, tt.separator, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"abc", "1.2.3", "12.345", "1e5"} {
+		if _, err := parseAmount(value, ".", "EUR"); err == nil {
+			t.Errorf("parseAmount(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestDecimalSeparatorOf(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"12.34", "."},
+		{"12,34", ","},
+		{"1,234.56", "."},
+		{"1.234,56", ","},
+		{"1,234", "."},
+		{"-1,234", "."},
+		{"1,2345", ","},
+		{"1,234,567", ","},
+		{"100", "."},
+	}
+	for _, tt := range tests {
+		if got := decimalSeparatorOf(tt.value); got != tt.want {
+			t.Errorf("decimalSeparatorOf(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseCSVWithHeader(t *testing.T) {
+	profile := &models.ImportProfile{
+		Delimiter:          ";",
+		HasHeader:          true,
+		SkipRows:           1,
+		DateColumn:         "Buchungstag",
+		DateFormat:         "DD.MM.YYYY",
+		AmountSign:         models.SignNegativeIsExpense,
+		AmountColumn:       "betrag",
+		DecimalSeparator:   ",",
+		DescriptionColumns: []string{"Verwendungszweck", "Referenz"},
+		PayeeColumn:        "Empfänger",
+		Currency:           "EUR",
+	}
+	file := "Kontoauszug Januar\n" +
+		"\ufeffBuchungstag;Empfänger;Verwendungszweck;Referenz;Betrag\n" +
+		"02.01.2024;Bäckerei Müller;Brötchen;;-3,40\n" +
+		"\n" +
+		"03.01.2024;ACME GmbH;Gehalt;Jan;2.500,00\n"
+
+	transactions, err := ParseCSV(strings.NewReader(file), profile)
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("ParseCSV returned %d transactions, want 2", len(transactions))
+	}
+
+	first := transactions[0]
+	if first.Line != 3 || first.Date.Format("2006-01-02") != "2024-01-02" || first.Amount.String() != "3.40 EUR" ||
+		first.Description != "Brötchen" || first.Payee != "Bäckerei Müller" {
+		t.Errorf("first transaction = %+v", first)
+	}
+	second := transactions[1]
+	if second.Line != 5 || second.Amount.String() != "-2500.00 EUR" || second.Description != "Gehalt Jan" {
+		t.Errorf("second transaction = %+v", second)
+	}
+}
+
+func TestParseCSVDebitCredit(t *testing.T) {
+	profile := &models.ImportProfile{
+		DateColumn:         "1",
+		DateFormat:         "M/D/YYYY",
+		AmountSign:         models.SignDebitCredit,
+		DebitColumn:        "3",
+		CreditColumn:       "4",
+		DecimalSeparator:   ".",
+		DescriptionColumns: []string{"2"},
+		Currency:           "USD",
+	}
+	file := "1/5/2024,Coffee,4.50,\n" +
+		"1/6/2024,Refund,,-20.00\n" +
+		"1/7/2024,Fee,-1.00,\n"
+
+	transactions, err := ParseCSV(strings.NewReader(file), profile)
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	var got []string
+	for _, tr := range transactions {
+		got = append(got, tr.Amount.String())
+	}
+	if want := "4.50 USD,-20.00 USD,1.00 USD"; strings.Join(got, ",") != want {
+		t.Errorf("amounts = %v, want %s", got, want)
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	profile := &models.ImportProfile{
+		HasHeader:        true,
+		DateColumn:       "Date",
+		DateFormat:       "YYYY-MM-DD",
+		AmountSign:       models.SignPositiveIsExpense,
+		AmountColumn:     "Amount",
+		DecimalSeparator: ".",
+		Currency:         "USD",
+	}
+
+	file := "Date,Amount\n2024-01-05,1.00\n2024-13-01,2.00\n2024-01-07,\n2024-01-08,abc\n"
+	_, err := ParseCSV(strings.NewReader(file), profile)
+	var lineErrors LineErrors
+	if !errors.As(err, &lineErrors) {
+		t.Fatalf("ParseCSV: err = %v, want LineErrors", err)
+	}
+	if len(lineErrors) != 3 || lineErrors[3] == "" || lineErrors[4] != "amount is empty" || lineErrors[5] == "" {
+		t.Errorf("line errors = %v", lineErrors)
+	}
+
+	_, err = ParseCSV(strings.NewReader("When,Amount\n"), profile)
+	if !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("missing column: err = %v, want ErrInvalidMapping", err)
+	}
+
+	noHeader := *profile
+	noHeader.HasHeader = false
+	_, err = ParseCSV(strings.NewReader("2024-01-05,1.00\n"), &noHeader)
+	if !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("named column without header: err = %v, want ErrInvalidMapping", err)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	profile := &models.ImportProfile{
+		DateColumn: "1", DateFormat: "YYYY-MM-DD", AmountSign: models.SignPositiveIsExpense,
+		AmountColumn: "2", DescriptionColumns: []string{"3"}, DecimalSeparator: ".", Currency: "USD",
+	}
+	file := "2024-01-05,3.00,Coffee\n2024-01-05,3.00,coffee \n2024-01-06,3.00,Coffee\n"
+	transactions, err := ParseCSV(strings.NewReader(file), profile)
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+
+	hashes := Hashes(transactions)
+	if hashes[0] == hashes[1] {
+		t.Error("two identical purchases on one day share a hash")
+	}
+	if hashes[0] == hashes[2] {
+		t.Error("purchases on different days share a hash")
+	}
+	again := Hashes(transactions)
+	for i := range hashes {
+		if hashes[i] != again[i] {
+			t.Errorf("hash %d changed on re-import", i)
+		}
+	}
+
+	// Lines with a bank-assigned ID are identified by it alone
+	withID := []Transaction{transactions[0], transactions[2]}
+	withID[0].ExternalID, withID[1].ExternalID = "T1", "T1"
+	if h := Hashes(withID); h[0] != h[1] {
+		t.Error("lines with the same external ID have different hashes")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc"},
+		{"café", 4, "caf"},
+		{"日本", 4, "日"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
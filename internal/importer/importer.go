@@ -0,0 +1,190 @@
+// Package importer reads bank statements into Transactions, a common
+// intermediate form that the import pipeline turns into expenses.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// Formats
+const (
+	FormatCSV = "csv"
+	// FormatOFX also covers QFX, Quicken's variant of OFX
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+)
+
+// maxLineErrors stops collecting errors once a file is clearly unreadable
+const maxLineErrors = 50
+
+var (
+	// ErrUnknownFormat is returned for statement formats without a parser
+	ErrUnknownFormat = errors.New("unknown statement format")
+	// ErrInvalidMapping is returned for profiles that are malformed or do not fit the file
+	ErrInvalidMapping = errors.New("invalid column mapping")
+	// ErrInvalidStatement is returned for files that are not statements of the expected format
+	ErrInvalidStatement = errors.New("invalid statement file")
+	// ErrProfileRequired is returned when a CSV statement is imported without a mapping profile
+	ErrProfileRequired = errors.New("a mapping profile is required to import CSV statements")
+)
+
+// Transaction is one statement line. Amount is positive for money going out
+// (an expense) and negative for money coming in.
+type Transaction struct {
+	Line        int          `json:"line"`
+	Date        time.Time    `json:"date"`
+	Amount      money.Amount `json:"amount"`
+	Description string       `json:"description"`
+	Payee       string       `json:"payee"`
+	// ExternalID is a bank-assigned transaction ID, when the format has one
+	ExternalID string `json:"external_id,omitempty"`
+	// Account identifies the bank account the line belongs to, when known
+	Account string `json:"account,omitempty"`
+}
+
+// LineErrors maps statement line numbers to what is wrong with them
+type LineErrors map[int]string
+
+func (e LineErrors) Error() string {
+	lines := make([]int, 0, len(e))
+	for line := range e {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+
+	messages := make([]string, len(lines))
+	for i, line := range lines {
+		messages[i] = fmt.Sprintf("line %d: %s", line, e[line])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Fields returns the errors keyed as "line N", the shape of ErrorResponse.Errors
+func (e LineErrors) Fields() map[string]string {
+	fields := make(map[string]string, len(e))
+	for line, message := range e {
+		fields["line "+strconv.Itoa(line)] = message
+	}
+	return fields
+}
+
+// full reports whether enough errors were collected to stop reading
+func (e LineErrors) full() bool {
+	return len(e) >= maxLineErrors
+}
+
+// Hashes returns a stable identity for every transaction, used to recognise
+// lines that were already imported. Lines with a bank-assigned ExternalID
+// are identified by it; other lines by their content plus how many identical
+// lines precede them, so two equal purchases on one day stay distinct while
+// re-importing the same file yields the same hashes.
+func Hashes(transactions []Transaction) []string {
+	hashes := make([]string, len(transactions))
+	seen := map[string]int{}
+	for i, t := range transactions {
+		var identity string
+		if t.ExternalID != "" {
+			identity = "id|" + t.Account + "|" + t.ExternalID
+		} else {
+			content := strings.Join([]string{
+				t.Account,
+				t.Date.Format("2006-01-02"),
+				t.Amount.String(),
+				strings.ToLower(strings.Join(strings.Fields(t.Description), " ")),
+				strings.ToLower(strings.Join(strings.Fields(t.Payee), " ")),
+			}, "|")
+			identity = "content|" + content + "|" + strconv.Itoa(seen[content])
+			seen[content]++
+		}
+
+		sum := sha256.Sum256([]byte(identity))
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// decimalSeparatorOf guesses the decimal separator of an amount in a format
+// without a fixed one: the last of "." and "," unless a lone "," is followed
+// by exactly three digits, which makes it a thousands separator
+func decimalSeparatorOf(value string) string {
+	dot, comma := strings.LastIndex(value, "."), strings.LastIndex(value, ",")
+	if comma < 0 || dot > comma {
+		return "."
+	}
+	if dot < 0 && strings.Count(value, ",") == 1 && len(strings.TrimRight(value[comma+1:], " -)")) == 3 {
+		return "."
+	}
+	return ","
+}
+
+// decodeText returns data as a string, reading it as Latin-1 when it is not
+// valid UTF-8, as older OFX and QIF exports often are
+func decodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// Parse reads a statement in the given format. CSV statements need a profile
+// describing their columns; currency applies to statements that do not state
+// their own, which are QIF files and some OFX files.
+func Parse(r io.Reader, format string, profile *models.ImportProfile, currency string) ([]Transaction, error) {
+	switch format {
+	case FormatCSV:
+		if profile == nil {
+			return nil, ErrProfileRequired
+		}
+		return ParseCSV(r, profile)
+	case FormatOFX, "qfx":
+		return ParseOFX(r, currency)
+	case FormatQIF:
+		return ParseQIF(r, currency)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// FormatFromName guesses the statement format from a file name
+func FormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".qif":
+		return FormatQIF
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxTransaction collects the elements of one STMTTRN aggregate
+type ofxTransaction struct {
+	line                                      int
+	posted, amount, fitID, name, memo, symbol string
+}
+
+// ofxStatement is the account and currency of the statement being read
+type ofxStatement struct {
+	bankID, accountID, currency string
+}
+
+// ParseOFX reads an OFX or QFX statement, either the SGML flavour (OFX 1.x)
+// or the XML one (OFX 2.x). Every STMTTRN becomes a transaction carrying its
+// FITID as ExternalID and the statement's account number as Account. currency
+// is used when the statement does not declare one. Transactions that cannot
+// be read are reported together as LineErrors, at the line of their STMTTRN.
+func ParseOFX(r io.Reader, currency string) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := decodeText(data)
+
+	start := indexFold(content, "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no <OFX> element found", ErrInvalidStatement)
+	}
+	line := 1 + strings.Count(content[:start], "\n")
+
+	var (
+		stack        []string
+		statement    ofxStatement
+		current      *ofxTransaction
+		afterOpen    bool
+		transactions = []Transaction{}
+		lineErrors   = LineErrors{}
+	)
+
+	finish := func(t *ofxTransaction) {
+		transaction, err := t.transaction(statement, currency)
+		if err != nil {
+			lineErrors[t.line] = err.Error()
+			return
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	// closeTo pops the stack up to and including name. SGML leaves have no
+	// closing tag, so anything above name is implicitly closed as well.
+	closeTo := func(name string) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i] != name {
+				continue
+			}
+			for _, open := range stack[i:] {
+				if open == "STMTTRN" && current != nil {
+					finish(current)
+					current = nil
+				}
+			}
+			stack = stack[:i]
+			return
+		}
+	}
+
+	rest := content[start:]
+	for rest != "" && !lineErrors.full() {
+		open := strings.IndexByte(rest, '<')
+		if open < 0 {
+			break
+		}
+		if text := strings.TrimSpace(rest[:open]); text != "" && afterOpen {
+			// Text after an opening tag is the value of a leaf element
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			setOFXValue(&statement, current, stack, name, html.UnescapeString(text))
+		}
+		line += strings.Count(rest[:open], "\n")
+		rest = rest[open:]
+
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			return nil, LineErrors{line: "unterminated tag"}
+		}
+		tag := strings.ToUpper(strings.TrimSpace(rest[1:end]))
+		tagLine := line
+		line += strings.Count(rest[:end], "\n")
+		rest = rest[end+1:]
+		afterOpen = false
+
+		switch {
+		case strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+			// Processing instructions and comments
+		case strings.HasPrefix(tag, "/"):
+			closeTo(strings.TrimSpace(tag[1:]))
+		case strings.HasSuffix(tag, "/"):
+			// An empty XML element carries no value
+		default:
+			if i := strings.IndexAny(tag, " \t\r\n"); i >= 0 {
+				tag = tag[:i]
+			}
+			switch tag {
+			case "STMTRS", "CCSTMTRS":
+				statement = ofxStatement{}
+			case "STMTTRN":
+				if current != nil {
+					finish(current)
+				}
+				current = &ofxTransaction{line: tagLine}
+			}
+			stack = append(stack, tag)
+			afterOpen = true
+		}
+	}
+	if current != nil && !lineErrors.full() {
+		finish(current)
+	}
+
+	if len(lineErrors) > 0 {
+		return nil, lineErrors
+	}
+	return transactions, nil
+}
+
+// setOFXValue stores the value of the leaf element name, whose open
+// ancestors are stack, in the statement or the current transaction
+func setOFXValue(s *ofxStatement, current *ofxTransaction, stack []string, name, value string) {
+	parent := ""
+	if len(stack) > 0 {
+		parent = stack[len(stack)-1]
+	}
+
+	switch parent {
+	case "BANKACCTFROM", "CCACCTFROM":
+		switch name {
+		case "BANKID":
+			s.bankID = value
+		case "ACCTID":
+			s.accountID = value
+		}
+		return
+	case "STMTRS", "CCSTMTRS":
+		if name == "CURDEF" {
+			s.currency = strings.ToUpper(value)
+		}
+		return
+	}
+
+	if current == nil {
+		return
+	}
+	switch {
+	case parent == "STMTTRN":
+		switch name {
+		case "DTPOSTED":
+			current.posted = value
+		case "TRNAMT":
+			current.amount = value
+		case "FITID":
+			current.fitID = value
+		case "NAME":
+			current.name = value
+		case "MEMO":
+			current.memo = value
+		}
+	case parent == "PAYEE" && name == "NAME":
+		current.name = value
+	case parent == "CURRENCY" && name == "CURSYM":
+		// The amount is in this currency rather than the statement's; with
+		// ORIGCURRENCY it was already converted, so that one is ignored
+		current.symbol = strings.ToUpper(value)
+	}
+}
+
+func (t *ofxTransaction) transaction(statement ofxStatement, fallbackCurrency string) (Transaction, error) {
+	// DTPOSTED is YYYYMMDD, optionally followed by a time and a time zone
+	if len(t.posted) < 8 {
+		return Transaction{}, fmt.Errorf("invalid DTPOSTED %q", t.posted)
+	}
+	date, err := time.Parse("20060102", t.posted[:8])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid DTPOSTED %q", t.posted)
+	}
+
+	if t.amount == "" {
+		return Transaction{}, errors.New("TRNAMT is missing")
+	}
+	currency := t.symbol
+	if currency == "" {
+		currency = statement.currency
+	}
+	if currency == "" {
+		currency = fallbackCurrency
+	}
+	amount, err := parseAmount(t.amount, decimalSeparatorOf(t.amount), currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	account := statement.accountID
+	if statement.bankID != "" && account != "" {
+		account = statement.bankID + ":" + account
+	}
+
+	description := t.memo
+	if description == "" {
+		description = t.name
+	}
+
+	// OFX amounts are signed from the account holder's view: debits are negative
+	return Transaction{
+		Date:        date,
+		Amount:      amount.Neg(),
+		Description: truncate(description, 255),
+		Payee:       truncate(t.name, 255),
+		ExternalID:  t.fitID,
+		Account:     truncate(account, 100),
+		Line:        t.line,
+	}, nil
+}
+
+// indexFold is strings.Index ignoring ASCII case
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
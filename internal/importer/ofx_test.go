@@ -0,0 +1,152 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<CURDEF>usd
+<BANKACCTFROM>
+<BANKID>121000248
+<ACCTID>000123
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240105120000[-5:EST]
+<TRNAMT>-42.50
+<FITID>2024010501
+<NAME>Grocery &amp; Co
+<MEMO>Weekly shopping
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240106
+<TRNAMT>1000.00
+<FITID>2024010601
+<PAYEE>
+<NAME>Employer Inc
+</PAYEE>
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240107
+<TRNAMT>-10,00
+<FITID>2024010701
+<NAME>Cafe Paris
+<CURRENCY>
+<CURRATE>1.1
+<CURSYM>EUR
+</CURRENCY>
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>GBP</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <DTPOSTED>20240301</DTPOSTED>
+            <TRNAMT>-5.99</TRNAMT>
+            <FITID>A1</FITID>
+            <NAME>Streaming</NAME>
+            <MEMO/>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFXSGML(t *testing.T) {
+	transactions, err := ParseOFX(strings.NewReader(sgmlStatement), "EUR")
+	if err != nil {
+		t.Fatalf("ParseOFX: %v", err)
+	}
+	if len(transactions) != 3 {
+		t.Fatalf("ParseOFX returned %d transactions, want 3", len(transactions))
+	}
+
+	tests := []struct {
+		line        int
+		date        string
+		amount      string
+		description string
+		payee       string
+		externalID  string
+	}{
+		{16, "2024-01-05", "42.50 USD", "Weekly shopping", "Grocery & Co", "2024010501"},
+		{24, "2024-01-06", "-1000.00 USD", "Employer Inc", "Employer Inc", "2024010601"},
+		{33, "2024-01-07", "10.00 EUR", "Cafe Paris", "Cafe Paris", "2024010701"},
+	}
+	for i, tt := range tests {
+		got := transactions[i]
+		if got.Line != tt.line || got.Date.Format("2006-01-02") != tt.date || got.Amount.String() != tt.amount ||
+			got.Description != tt.description || got.Payee != tt.payee || got.ExternalID != tt.externalID ||
+			got.Account != "121000248:000123" {
+			t.Errorf("transaction %d = %+v, want %+v", i, got, tt)
+		}
+	}
+}
+
+func TestParseOFXXML(t *testing.T) {
+	transactions, err := ParseOFX(strings.NewReader(xmlStatement), "EUR")
+	if err != nil {
+		t.Fatalf("ParseOFX: %v", err)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("ParseOFX returned %d transactions, want 1", len(transactions))
+	}
+	got := transactions[0]
+	if got.Amount.String() != "5.99 GBP" || got.Account != "4111" || got.Description != "Streaming" || got.ExternalID != "A1" {
+		t.Errorf("transaction = %+v", got)
+	}
+}
+
+func TestParseOFXFallbackCurrency(t *testing.T) {
+	statement := "<OFX><STMTTRN><DTPOSTED>20240101<TRNAMT>-1.00<FITID>X</STMTTRN></OFX>"
+	transactions, err := ParseOFX(strings.NewReader(statement), "CHF")
+	if err != nil {
+		t.Fatalf("ParseOFX: %v", err)
+	}
+	if len(transactions) != 1 || transactions[0].Amount.String() != "1.00 CHF" {
+		t.Errorf("transactions = %+v", transactions)
+	}
+}
+
+func TestParseOFXErrors(t *testing.T) {
+	if _, err := ParseOFX(strings.NewReader("Date,Amount\n"), "USD"); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("not OFX: err = %v, want ErrInvalidStatement", err)
+	}
+
+	statement := "<OFX>\n<STMTTRN>\n<DTPOSTED>2024\n<TRNAMT>-1.00\n</STMTTRN>\n<STMTTRN>\n<DTPOSTED>20240101\n</STMTTRN>\n</OFX>"
+	_, err := ParseOFX(strings.NewReader(statement), "USD")
+	var lineErrors LineErrors
+	if !errors.As(err, &lineErrors) {
+		t.Fatalf("ParseOFX: err = %v, want LineErrors", err)
+	}
+	if len(lineErrors) != 2 || !strings.Contains(lineErrors[2], "DTPOSTED") || lineErrors[6] != "TRNAMT is missing" {
+		t.Errorf("line errors = %v", lineErrors)
+	}
+}
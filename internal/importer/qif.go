@@ -0,0 +1,236 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// qifRecord holds the fields of one QIF record, up to its "^" terminator
+type qifRecord struct {
+	line                               int
+	account, date, amount, payee, memo string
+}
+
+// qifDate is a QIF date split into its three numeric parts
+type qifDate struct {
+	first, second, year int
+	// iso dates are always year, month, day
+	iso bool
+}
+
+// ParseQIF reads a QIF statement. Only bank, cash, credit card and asset or
+// liability sections hold transactions; category lists, memorized
+// transactions and investment sections are ignored. QIF has no currency, so
+// every amount is in currency. Records that cannot be read are reported
+// together as LineErrors, at the line where the record starts.
+//
+// QIF dates are month first unless a date in the file only makes sense day
+// first, as exports from non-US locales are.
+func ParseQIF(r io.Reader, currency string) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(decodeText(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		records      []*qifRecord
+		current      *qifRecord
+		account      string
+		inAccount    bool
+		transactions bool
+	)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if text == "" {
+			continue
+		}
+
+		if text[0] == '!' {
+			header := strings.ToLower(strings.TrimSpace(text[1:]))
+			// Option headers such as "!Option:AutoSwitch" do not change the section
+			if strings.HasPrefix(header, "option:") || strings.HasPrefix(header, "clear:") {
+				continue
+			}
+			inAccount = header == "account"
+			transactions = isTransactionSection(header)
+			current = nil
+			continue
+		}
+
+		if text[0] == '^' {
+			if current != nil && transactions && !inAccount {
+				records = append(records, current)
+			}
+			current = nil
+			continue
+		}
+
+		code, value := text[0], strings.TrimSpace(text[1:])
+		if inAccount {
+			if code == 'N' {
+				account = value
+			}
+			continue
+		}
+		if current == nil {
+			current = &qifRecord{line: line, account: account}
+		}
+		switch code {
+		case 'D':
+			current.date = value
+		case 'T', 'U':
+			if current.amount == "" {
+				current.amount = value
+			}
+		case 'P':
+			current.payee = value
+		case 'M':
+			current.memo = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+	}
+	// The terminator of the last record is optional in some exports
+	if current != nil && transactions && !inAccount {
+		records = append(records, current)
+	}
+
+	dates := make([]qifDate, len(records))
+	lineErrors := LineErrors{}
+	dayFirst := false
+	for i, record := range records {
+		date, err := splitQIFDate(record.date)
+		if err != nil {
+			lineErrors[record.line] = err.Error()
+			continue
+		}
+		if !date.iso && date.first > 12 {
+			dayFirst = true
+		}
+		dates[i] = date
+	}
+
+	result := []Transaction{}
+	for i, record := range records {
+		if lineErrors.full() {
+			break
+		}
+		if _, failed := lineErrors[record.line]; failed {
+			continue
+		}
+
+		t, err := record.transaction(dates[i], dayFirst, currency)
+		if err != nil {
+			lineErrors[record.line] = err.Error()
+			continue
+		}
+		result = append(result, t)
+	}
+
+	if len(lineErrors) > 0 {
+		return nil, lineErrors
+	}
+	return result, nil
+}
+
+func (r *qifRecord) transaction(date qifDate, dayFirst bool, currency string) (Transaction, error) {
+	month, day := date.first, date.second
+	if dayFirst && !date.iso {
+		month, day = day, month
+	}
+	posted := time.Date(date.year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || month > 12 || day < 1 || posted.Day() != day {
+		return Transaction{}, fmt.Errorf("invalid date %q", r.date)
+	}
+
+	if r.amount == "" {
+		return Transaction{}, errors.New("amount is missing")
+	}
+	amount, err := parseAmount(r.amount, decimalSeparatorOf(r.amount), currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	description := r.memo
+	if description == "" {
+		description = r.payee
+	}
+
+	// QIF amounts are negative for payments out of the account
+	return Transaction{
+		Line:        r.line,
+		Date:        posted,
+		Amount:      amount.Neg(),
+		Description: truncate(description, 255),
+		Payee:       truncate(r.payee, 255),
+		Account:     truncate(r.account, 100),
+	}, nil
+}
+
+// splitQIFDate reads dates such as "12/31/2024", "1/ 5/24", "1/5'04" or
+// "2024-01-05". A year written after an apostrophe is in the 2000s.
+func splitQIFDate(value string) (qifDate, error) {
+	if value == "" {
+		return qifDate{}, errors.New("date is missing")
+	}
+	invalid := fmt.Errorf("invalid date %q", value)
+
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ':
+			return -1
+		case '\'', '-', '.':
+			return '/'
+		}
+		return r
+	}, value)
+	parts := strings.Split(normalized, "/")
+	if len(parts) != 3 {
+		return qifDate{}, invalid
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return qifDate{}, invalid
+		}
+		numbers[i] = n
+	}
+
+	// ISO dates put the year first
+	if len(parts[0]) == 4 {
+		return qifDate{first: numbers[1], second: numbers[2], year: numbers[0], iso: true}, nil
+	}
+
+	year := numbers[2]
+	switch {
+	case len(parts[2]) == 4:
+	case strings.Contains(value, "'"):
+		year += 2000
+	case year < 70:
+		year += 2000
+	default:
+		year += 1900
+	}
+	return qifDate{first: numbers[0], second: numbers[1], year: year}, nil
+}
+
+// isTransactionSection reports whether a QIF "!Type:" header starts a list of transactions
+func isTransactionSection(header string) bool {
+	switch header {
+	case "type:bank", "type:cash", "type:ccard", "type:oth a", "type:oth l":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseQIF(t *testing.T) {
+	statement := "\xef\xbb\xbf!Option:AutoSwitch\n" +
+		"!Account\n" +
+		"NChecking\n" +
+		"TBank\n" +
+		"^\n" +
+		"!Clear:AutoSwitch\n" +
+		"!Type:Bank\n" +
+		"D1/ 5'24\n" +
+		"T-1,234.56\n" +
+		"PLandlord\n" +
+		"MRent January\n" +
+		"^\n" +
+		"D01/06/2024\n" +
+		"U250.00\n" +
+		"T250.00\n" +
+		"PEmployer\n" +
+		"^\n" +
+		"!Type:Cat\n" +
+		"NGroceries\n" +
+		"^\n" +
+		"!Type:Cash\n" +
+		"D2024-01-07\n" +
+		"T-3.20\n" +
+		"PKiosk"
+
+	transactions, err := ParseQIF(strings.NewReader(statement), "USD")
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+
+	tests := []struct {
+		line        int
+		date        string
+		amount      string
+		description string
+		payee       string
+	}{
+		{8, "2024-01-05", "1234.56 USD", "Rent January", "Landlord"},
+		{13, "2024-01-06", "-250.00 USD", "Employer", "Employer"},
+		{22, "2024-01-07", "3.20 USD", "Kiosk", "Kiosk"},
+	}
+	if len(transactions) != len(tests) {
+		t.Fatalf("ParseQIF returned %d transactions, want %d: %+v", len(transactions), len(tests), transactions)
+	}
+	for i, tt := range tests {
+		got := transactions[i]
+		if got.Line != tt.line || got.Date.Format("2006-01-02") != tt.date || got.Amount.String() != tt.amount ||
+			got.Description != tt.description || got.Payee != tt.payee || got.Account != "Checking" {
+			t.Errorf("transaction %d = %+v, want %+v", i, got, tt)
+		}
+	}
+}
+
+func TestParseQIFDayFirst(t *testing.T) {
+	// The 25th cannot be a month, so every date of the file is day first
+	statement := "!Type:Bank\nD03/02/2024\nT-1.00\n^\nD25/02/2024\nT-2.00\n^\n"
+	transactions, err := ParseQIF(strings.NewReader(statement), "EUR")
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+	if len(transactions) != 2 ||
+		transactions[0].Date.Format("2006-01-02") != "2024-02-03" ||
+		transactions[1].Date.Format("2006-01-02") != "2024-02-25" {
+		t.Errorf("transactions = %+v", transactions)
+	}
+}
+
+func TestSplitQIFDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  qifDate
+	}{
+		{"12/31/2024", qifDate{first: 12, second: 31, year: 2024}},
+		{"1/ 5/24", qifDate{first: 1, second: 5, year: 2024}},
+		{"1/5'04", qifDate{first: 1, second: 5, year: 2004}},
+		{"1/5/99", qifDate{first: 1, second: 5, year: 1999}},
+		{"05.01.2024", qifDate{first: 5, second: 1, year: 2024}},
+		{"2024-01-05", qifDate{first: 1, second: 5, year: 2024, iso: true}},
+	}
+	for _, tt := range tests {
+		got, err := splitQIFDate(tt.value)
+		if err != nil {
+			t.Errorf("splitQIFDate(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("splitQIFDate(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "2024", "1/5", "a/b/c", "1/5/2024/1"} {
+		if _, err := splitQIFDate(value); err == nil {
+			t.Errorf("splitQIFDate(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestParseQIFErrors(t *testing.T) {
+	statement := "!Type:Bank\nD02/30/2024\nT-1.00\n^\nD02/01/2024\nPNo amount\n^\nDyesterday\nT1.00\n^\n"
+	_, err := ParseQIF(strings.NewReader(statement), "USD")
+	var lineErrors LineErrors
+	if !errors.As(err, &lineErrors) {
+		t.Fatalf("ParseQIF: err = %v, want LineErrors", err)
+	}
+	if len(lineErrors) != 3 || lineErrors[2] == "" || lineErrors[5] != "amount is missing" || lineErrors[8] == "" {
+		t.Errorf("line errors = %v", lineErrors)
+	}
+}
@@ -1,102 +1,326 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Config defines JWT configuration settings
+// Config defines JWT configuration settings. Access and refresh tokens are
+// signed with separate keyrings.
 type Config struct {
-	AccessSecret       []byte
-	RefreshSecret      []byte
+	AccessKeys         *Keyring
+	RefreshKeys        *Keyring
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
 }
 
 // DefaultConfig provides default JWT settings
 var DefaultConfig = Config{
-	AccessSecret:       []byte("access_secret_12345"),
-	RefreshSecret:      []byte("refresh_secret_67890"),
+	AccessKeys:         NewSecretKeyring([]byte("access_secret_12345")),
+	RefreshKeys:        NewSecretKeyring([]byte("refresh_secret_67890")),
 	AccessTokenExpiry:  10 * time.Second,
 	RefreshTokenExpiry: 7 * 24 * time.Hour,
 }
 
+// purposeMFA marks MFA challenge tokens, which only prove the password step of a login
+const purposeMFA = "mfa"
+
+// mfaTokenExpiry is how long the second step of a login may take
+const mfaTokenExpiry = 5 * time.Minute
+
+var (
+	// ErrTokenRevoked is returned for refresh tokens that were revoked or never issued
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrTokenReused is returned when a refresh token that was already rotated
+	// is presented again; its whole family is revoked and the user must log in again
+	ErrTokenReused = errors.New("refresh token reuse detected")
+	// ErrSessionEnded is returned for access tokens of sessions that were signed out
+	ErrSessionEnded = errors.New("session has ended")
+)
+
+// Device describes the client a session was started or last used from
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionStore persists sessions and the IDs (jti) of their refresh tokens,
+// so that both can be revoked before they expire. The refresh tokens rotated
+// from one login form a family whose ID is the session ID.
+type SessionStore interface {
+	// StartSession records a new session together with its first refresh token
+	StartSession(sessionID, tokenID string, userID int, expiresAt time.Time, device Device) error
+	// Rotate replaces oldID with newID in the same family and returns the
+	// session ID. It returns ErrTokenReused, after ending the session, when
+	// oldID was already rotated, and ErrTokenRevoked when it is unknown,
+	// revoked or expired.
+	Rotate(oldID, newID string, expiresAt time.Time, device Device) (string, error)
+	// IsActive reports whether the token was issued and is not revoked or expired
+	IsActive(tokenID string) (bool, error)
+	// Revoke ends the session of a refresh token
+	Revoke(tokenID string) error
+	// IsSessionActive reports whether a session exists and was not ended; it
+	// is called for every authenticated request
+	IsSessionActive(sessionID string) (bool, error)
+	// StartImpersonation records a session in which impersonatorID acts as
+	// the user; it has no refresh token and ends at expiresAt
+	StartImpersonation(sessionID string, userID, impersonatorID int, expiresAt time.Time, device Device) error
+}
+
 // Claims defines custom JWT claims
 type Claims struct {
 	UserId int `json:"user_id"`
+	// SessionID identifies the login the token belongs to
+	SessionID string `json:"sid,omitempty"`
+	// EmailVerified is set in access tokens of users who had verified their email when it was issued
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// Roles and Permissions are those of staff users when the token was issued
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// ImpersonatorID is set in access tokens of staff users acting as the user
+	ImpersonatorID int `json:"impersonator_id,omitempty"`
+	// Purpose tells MFA challenge tokens apart from refresh tokens; it is empty for access and refresh tokens
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// AccessGrant is what an access token allows its user, besides identifying
+// the user and session
+type AccessGrant struct {
+	EmailVerified  bool
+	Roles          []string
+	Permissions    []string
+	ImpersonatorID int
+}
+
 // TokenService handles JWT operations
 type TokenService struct {
 	Config Config
+	Store  SessionStore
 }
 
 // NewTokenService creates a new instance of TokenService
-func NewTokenService(config Config) *TokenService {
-	return &TokenService{Config: config}
+func NewTokenService(config Config, store SessionStore) *TokenService {
+	return &TokenService{Config: config, Store: store}
 }
 
-// GenerateAccessToken generates a short-lived access token
-func (ts *TokenService) GenerateAccessToken(userId int) (string, error) {
+// GenerateAccessToken generates a short-lived access token for a session
+func (ts *TokenService) GenerateAccessToken(userId int, sessionID string, grant AccessGrant) (string, error) {
 	claims := &Claims{
-		UserId: userId,
+		UserId:         userId,
+		SessionID:      sessionID,
+		EmailVerified:  grant.EmailVerified,
+		Roles:          grant.Roles,
+		Permissions:    grant.Permissions,
+		ImpersonatorID: grant.ImpersonatorID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ts.Config.AccessTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	log.Println("Access Secret for Signing:", string(ts.Config.AccessSecret)) // Debug
-	return token.SignedString(ts.Config.AccessSecret)
+	return ts.Config.AccessKeys.sign(claims)
+}
+
+// GenerateRefreshToken starts a new session and returns its first refresh
+// token, whose unique ID (jti) is recorded in the store, and the session ID
+func (ts *TokenService) GenerateRefreshToken(userId int, device Device) (string, string, error) {
+	sessionID, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	expiresAt := time.Now().Add(ts.Config.RefreshTokenExpiry)
+	if err := ts.Store.StartSession(sessionID, tokenID, userId, expiresAt, device); err != nil {
+		return "", "", err
+	}
+
+	signed, err := ts.signRefreshToken(tokenID, userId, sessionID, expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, sessionID, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one of the same
+// session; the old token stops working. Presenting an already rotated token
+// again ends the session and returns ErrTokenReused. The returned claims are
+// those of the new token.
+func (ts *TokenService) RotateRefreshToken(tokenString string, device Device) (string, *Claims, error) {
+	claims, err := ts.validateToken(tokenString, ts.Config.RefreshKeys, "")
+	if err != nil {
+		return "", nil, err
+	}
+	if claims.ID == "" {
+		return "", nil, ErrTokenRevoked
+	}
+
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	expiresAt := time.Now().Add(ts.Config.RefreshTokenExpiry)
+	sessionID, err := ts.Store.Rotate(claims.ID, tokenID, expiresAt, device)
+	if err != nil {
+		return "", nil, err
+	}
+
+	signed, err := ts.signRefreshToken(tokenID, claims.UserId, sessionID, expiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	claims.ID = tokenID
+	claims.SessionID = sessionID
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	return signed, claims, nil
+}
+
+// GenerateImpersonationToken starts a session in which the staff user
+// grant.ImpersonatorID acts as the user and returns its access token and the
+// session ID. The session cannot be refreshed; it ends with the access token.
+func (ts *TokenService) GenerateImpersonationToken(userId int, grant AccessGrant, device Device) (string, string, error) {
+	if grant.ImpersonatorID == 0 {
+		return "", "", errors.New("impersonation needs an impersonator")
+	}
+	sessionID, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	expiresAt := time.Now().Add(ts.Config.AccessTokenExpiry)
+	if err := ts.Store.StartImpersonation(sessionID, userId, grant.ImpersonatorID, expiresAt, device); err != nil {
+		return "", "", err
+	}
+
+	signed, err := ts.GenerateAccessToken(userId, sessionID, grant)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, sessionID, nil
 }
 
-// GenerateRefreshToken generates a long-lived refresh token
-func (ts *TokenService) GenerateRefreshToken(userId int) (string, error) {
+// signRefreshToken signs a refresh token with the given ID
+func (ts *TokenService) signRefreshToken(tokenID string, userId int, sessionID string, expiresAt time.Time) (string, error) {
 	claims := &Claims{
-		UserId: userId,
+		UserId:    userId,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ts.Config.RefreshTokenExpiry)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(ts.Config.RefreshSecret)
+	return ts.Config.RefreshKeys.sign(claims)
 }
 
-// ValidateAccessToken validates an access token
+// ValidateAccessToken validates an access token, rejecting tokens of
+// sessions that were signed out
 func (ts *TokenService) ValidateAccessToken(tokenString string) (*Claims, error) {
-	log.Println("Access Secret for Validation:", string(ts.Config.AccessSecret)) // Debug
-	return ts.validateToken(tokenString, ts.Config.AccessSecret)
+	claims, err := ts.validateToken(tokenString, ts.Config.AccessKeys, "")
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return nil, ErrSessionEnded
+	}
+
+	active, err := ts.Store.IsSessionActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionEnded
+	}
+	return claims, nil
 }
 
-// ValidateRefreshToken validates a refresh token
+// ValidateRefreshToken validates a refresh token, rejecting tokens whose ID
+// is unknown to the store or was revoked
 func (ts *TokenService) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return ts.validateToken(tokenString, ts.Config.RefreshSecret)
-}
-
-// validateToken validates and parses a JWT token
-func (ts *TokenService) validateToken(tokenString string, secret []byte) (*Claims, error) {
-	log.Println("Validating token:", tokenString) // Debug
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if token.Method != jwt.SigningMethodHS256 {
-			return nil, errors.New("invalid token")
-		}
-		return secret, nil
-	})
+	claims, err := ts.validateToken(tokenString, ts.Config.RefreshKeys, "")
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, ErrTokenRevoked
+	}
+
+	active, err := ts.Store.IsActive(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// RevokeRefreshToken ends the session of a validly signed refresh token
+func (ts *TokenService) RevokeRefreshToken(tokenString string) error {
+	claims, err := ts.validateToken(tokenString, ts.Config.RefreshKeys, "")
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return ErrTokenRevoked
+	}
+	return ts.Store.Revoke(claims.ID)
+}
+
+// newTokenID returns a random 128-bit token ID in hex
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateMFAToken issues the token that a user whose password was correct
+// exchanges, together with a second factor, for a session. It is signed with
+// the refresh keys, whose public keys are never published.
+func (ts *TokenService) GenerateMFAToken(userId int) (string, error) {
+	claims := &Claims{
+		UserId:  userId,
+		Purpose: purposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return ts.Config.RefreshKeys.sign(claims)
+}
+
+// ValidateMFAToken validates an MFA challenge token
+func (ts *TokenService) ValidateMFAToken(tokenString string) (*Claims, error) {
+	return ts.validateToken(tokenString, ts.Config.RefreshKeys, purposeMFA)
+}
+
+// validateToken validates and parses a JWT token signed by a key of the
+// keyring, rejecting tokens issued for another purpose
+func (ts *TokenService) validateToken(tokenString string, keys *Keyring, purpose string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keys.verifyKey,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}))
 	if err != nil {
-		log.Println("Token validation error:", err) // Debug
 		return nil, err
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		log.Println("Invalid claims or token not valid") // Debug
+	if !ok || !token.Valid || claims.Purpose != purpose {
 		return nil, errors.New("invalid token")
 	}
-	log.Println("Validated Claims:", claims) // Debug
 	return claims, nil
 }
+
+// JWKS returns the public keys that verify access tokens
+func (ts *TokenService) JWKS() JWKS {
+	return ts.Config.AccessKeys.JWKS()
+}
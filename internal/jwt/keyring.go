@@ -0,0 +1,278 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LegacyKeyID is the ID of the key built from a single secret. Tokens signed
+// before keyrings existed carry no kid and are verified with this key.
+const LegacyKeyID = "default"
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	// ErrUnknownKey is returned for tokens signed with a key that is not in
+	// the keyring, or whose verification grace period is over
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+	// ErrInvalidKeyring is returned when a keyring file cannot be loaded
+	ErrInvalidKeyring = errors.New("invalid keyring")
+)
+
+// Key is one signing key. Keys other than the keyring's signing key only
+// verify tokens, and stop doing so after VerifyUntil when it is set, which
+// gives tokens signed before a rotation a grace period.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// SignKey is the HMAC secret or the private key; it may be nil for keys that only verify
+	SignKey interface{}
+	// VerifyKey is the HMAC secret or the public key
+	VerifyKey   interface{}
+	VerifyUntil time.Time
+}
+
+// Keyring holds the keys that verify tokens and the one that signs new ones
+type Keyring struct {
+	keys    map[string]*Key
+	signing *Key
+}
+
+// NewKeyring creates a keyring signing with the key signingID
+func NewKeyring(signingID string, keys ...*Key) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := ring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidKeyring, key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	ring.signing = ring.keys[signingID]
+	if ring.signing == nil {
+		return nil, fmt.Errorf("%w: signing key %q not found", ErrInvalidKeyring, signingID)
+	}
+	if ring.signing.SignKey == nil {
+		return nil, fmt.Errorf("%w: signing key %q has no private key", ErrInvalidKeyring, signingID)
+	}
+	if !ring.signing.VerifyUntil.IsZero() {
+		return nil, fmt.Errorf("%w: signing key %q is being retired", ErrInvalidKeyring, signingID)
+	}
+	return ring, nil
+}
+
+// NewSecretKeyring creates a keyring with a single HS256 key, the way tokens
+// were signed before keyrings existed
+func NewSecretKeyring(secret []byte) *Keyring {
+	key := &Key{ID: LegacyKeyID, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+	return &Keyring{keys: map[string]*Key{LegacyKeyID: key}, signing: key}
+}
+
+// sign signs claims with the signing key and sets its ID as the kid header
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.SignKey)
+}
+
+// verifyKey picks the key of a parsed token by its kid header. The token's
+// algorithm must be the key's, so that a public key is never used as an HMAC secret.
+func (k *Keyring) verifyKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = LegacyKeyID
+	}
+
+	key, ok := k.keys[kid]
+	if !ok || (!key.VerifyUntil.IsZero() && time.Now().After(key.VerifyUntil)) {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("invalid token")
+	}
+	return key.VerifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 curve and public key
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that currently verify tokens, so that other
+// services can verify them without a shared secret. HMAC keys are secret and
+// never published.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range k.keys {
+		if !key.VerifyUntil.IsZero() && now.After(key.VerifyUntil) {
+			continue
+		}
+
+		jwk := JWK{Use: "sig", Algorithm: key.Method.Alg(), KeyID: key.ID}
+		switch public := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+// keyringFile is the JSON layout of a keyring file
+type keyringFile struct {
+	SigningKey string `json:"signing_key"`
+	Keys       []struct {
+		ID  string `json:"kid"`
+		Alg string `json:"alg"`
+		// Secret is the HMAC secret of HS256 keys
+		Secret string `json:"secret"`
+		// PrivateKeyFile and PublicKeyFile are PEM files of RS256 and EdDSA
+		// keys; keys that only verify need just the public key
+		PrivateKeyFile string     `json:"private_key_file"`
+		PublicKeyFile  string     `json:"public_key_file"`
+		VerifyUntil    *time.Time `json:"verify_until"`
+	} `json:"keys"`
+}
+
+// LoadKeyring reads a keyring from a JSON file such as
+//
+//	{
+//	  "signing_key": "2024-06",
+//	  "keys": [
+//	    {"kid": "2024-06", "alg": "EdDSA", "private_key_file": "2024-06.pem"},
+//	    {"kid": "default", "alg": "HS256", "secret": "...", "verify_until": "2024-06-08T00:00:00Z"}
+//	  ]
+//	}
+//
+// Key file paths are relative to the keyring file. To rotate keys, add a new
+// key, make it the signing key and give the old one a verify_until at least
+// as far away as the lifetime of the tokens it signed.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyring, err)
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]*Key, 0, len(file.Keys))
+	for _, entry := range file.Keys {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("%w: key without kid", ErrInvalidKeyring)
+		}
+		key := &Key{ID: entry.ID}
+		if entry.VerifyUntil != nil {
+			key.VerifyUntil = *entry.VerifyUntil
+		}
+
+		switch entry.Alg {
+		case AlgHS256:
+			if entry.Secret == "" {
+				return nil, fmt.Errorf("%w: key %q has no secret", ErrInvalidKeyring, entry.ID)
+			}
+			key.Method = jwt.SigningMethodHS256
+			key.SignKey = []byte(entry.Secret)
+			key.VerifyKey = key.SignKey
+		case AlgRS256, AlgEdDSA:
+			key.Method = jwt.GetSigningMethod(entry.Alg)
+			if err := loadKeyPair(key, dir, entry.PrivateKeyFile, entry.PublicKeyFile); err != nil {
+				return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKeyring, entry.ID, err)
+			}
+		default:
+			return nil, fmt.Errorf("%w: key %q has unsupported alg %q", ErrInvalidKeyring, entry.ID, entry.Alg)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(file.SigningKey, keys...)
+}
+
+// loadKeyPair reads the PEM encoded private or public key of an RS256 or EdDSA key
+func loadKeyPair(key *Key, dir, privateFile, publicFile string) error {
+	resolve := func(name string) string {
+		if filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(dir, name)
+	}
+	isRSA := key.Method == jwt.SigningMethodRS256
+
+	if privateFile != "" {
+		data, err := os.ReadFile(resolve(privateFile))
+		if err != nil {
+			return err
+		}
+		var private crypto.Signer
+		if isRSA {
+			private, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+		} else {
+			var parsed crypto.PrivateKey
+			parsed, err = jwt.ParseEdPrivateKeyFromPEM(data)
+			private, _ = parsed.(crypto.Signer)
+		}
+		if err != nil {
+			return err
+		}
+		key.SignKey = private
+		key.VerifyKey = private.Public()
+		return nil
+	}
+
+	if publicFile == "" {
+		return errors.New("private_key_file or public_key_file is required")
+	}
+	data, err := os.ReadFile(resolve(publicFile))
+	if err != nil {
+		return err
+	}
+	if isRSA {
+		key.VerifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	} else {
+		key.VerifyKey, err = jwt.ParseEdPublicKeyFromPEM(data)
+	}
+	return err
+}
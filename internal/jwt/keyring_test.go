@@ -0,0 +1,291 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys are one key of every supported algorithm
+type testKeys struct {
+	hmac    *Key
+	rsa     *Key
+	ed25519 *Key
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	secret := []byte("hmac-secret-0123456789abcdef0123")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey: %v", err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey: %v", err)
+	}
+	return testKeys{
+		hmac:    &Key{ID: "hs", Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret},
+		rsa:     &Key{ID: "rs", Method: jwt.SigningMethodRS256, SignKey: rsaKey, VerifyKey: &rsaKey.PublicKey},
+		ed25519: &Key{ID: "ed", Method: jwt.SigningMethodEdDSA, SignKey: edPrivate, VerifyKey: edPublic},
+	}
+}
+
+func newTestKeyring(t *testing.T, signingID string, keys ...*Key) *Keyring {
+	t.Helper()
+	ring, err := NewKeyring(signingID, keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return ring
+}
+
+// testClaims are valid for an hour
+func testClaims(userID int) *Claims {
+	return &Claims{UserId: userID, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+}
+
+// verify checks a token the way the token service does
+func verify(ring *Keyring, token string) (*Claims, error) {
+	return (&TokenService{}).validateToken(token, ring, "")
+}
+
+// retired returns a copy of key that only verifies until the given time
+func retired(key *Key, until time.Time) *Key {
+	k := *key
+	k.VerifyUntil = until
+	return &k
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	keys := newTestKeys(t)
+	for _, key := range []*Key{keys.hmac, keys.rsa, keys.ed25519} {
+		ring := newTestKeyring(t, key.ID, key)
+		token, err := ring.sign(testClaims(42))
+		if err != nil {
+			t.Fatalf("%s: sign: %v", key.Method.Alg(), err)
+		}
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		if err != nil {
+			t.Fatalf("%s: ParseUnverified: %v", key.Method.Alg(), err)
+		}
+		if parsed.Header["kid"] != key.ID || parsed.Header["alg"] != key.Method.Alg() {
+			t.Errorf("%s: header = %v", key.Method.Alg(), parsed.Header)
+		}
+
+		claims, err := verify(ring, token)
+		if err != nil || claims.UserId != 42 {
+			t.Errorf("%s: verify = %+v, %v; want user 42", key.Method.Alg(), claims, err)
+		}
+	}
+}
+
+func TestKeyringLegacyTokens(t *testing.T) {
+	// Tokens signed before keyrings existed have no kid
+	secret := []byte("legacy-secret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims(7)).SignedString(secret)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if claims, err := verify(NewSecretKeyring(secret), token); err != nil || claims.UserId != 7 {
+		t.Errorf("verify = %+v, %v; want user 7", claims, err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	keys := newTestKeys(t)
+	oldToken, err := newTestKeyring(t, keys.hmac.ID, keys.hmac).sign(testClaims(1))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// After the rotation new tokens are signed with the new key, and the
+	// old key keeps verifying during the grace period
+	rotated := newTestKeyring(t, keys.ed25519.ID, keys.ed25519, retired(keys.hmac, time.Now().Add(time.Hour)))
+	if _, err := verify(rotated, oldToken); err != nil {
+		t.Errorf("token of the old key during the grace period: %v", err)
+	}
+	newToken, err := rotated.sign(testClaims(2))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if claims, err := verify(rotated, newToken); err != nil || claims.UserId != 2 {
+		t.Errorf("token of the new key: %+v, %v", claims, err)
+	}
+
+	expired := newTestKeyring(t, keys.ed25519.ID, keys.ed25519, retired(keys.hmac, time.Now().Add(-time.Second)))
+	if _, err := verify(expired, oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token of the old key after the grace period: err = %v, want ErrUnknownKey", err)
+	}
+
+	// Tokens of keys that were removed altogether are unknown too
+	if _, err := verify(newTestKeyring(t, keys.ed25519.ID, keys.ed25519), oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token of a removed key: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyringRejectsAlgorithmConfusion(t *testing.T) {
+	keys := newTestKeys(t)
+	ring := newTestKeyring(t, keys.rsa.ID, keys.rsa, keys.hmac, keys.ed25519)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: mustMarshalPublic(t, keys.rsa.VerifyKey)})
+
+	forge := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, testClaims(1))
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("forging a %s token: %v", method.Alg(), err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// The classic attack: the public key, which anyone can fetch, used as an HMAC secret
+		{"HS256 with the RSA public key", forge(jwt.SigningMethodHS256, keys.rsa.ID, publicPEM)},
+		{"HS256 with the raw RSA modulus", forge(jwt.SigningMethodHS256, keys.rsa.ID, keys.rsa.VerifyKey.(*rsa.PublicKey).N.Bytes())},
+		{"EdDSA under the kid of the HMAC key", forge(jwt.SigningMethodEdDSA, keys.hmac.ID, keys.ed25519.SignKey)},
+		{"RS256 under the kid of the Ed25519 key", forge(jwt.SigningMethodRS256, keys.ed25519.ID, keys.rsa.SignKey)},
+		{"alg none", forge(jwt.SigningMethodNone, keys.rsa.ID, jwt.UnsafeAllowNoneSignatureType)},
+	}
+	for _, tt := range tests {
+		if claims, err := verify(ring, tt.token); err == nil {
+			t.Errorf("%s: verified as %+v, want an error", tt.name, claims)
+		}
+	}
+}
+
+func mustMarshalPublic(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	return der
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	keys := newTestKeys(t)
+	_, oldPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	old := &Key{ID: "ed-old", Method: jwt.SigningMethodEdDSA, VerifyKey: oldPrivate.Public(),
+		VerifyUntil: time.Now().Add(-time.Minute)}
+	ring := newTestKeyring(t, keys.ed25519.ID, keys.ed25519, keys.rsa, keys.hmac, old)
+
+	set := ring.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want the RSA and Ed25519 keys: %+v", len(set.Keys), set.Keys)
+	}
+	ed, rs := set.Keys[0], set.Keys[1]
+	if ed.KeyID != "ed" || ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != AlgEdDSA || ed.X == "" {
+		t.Errorf("Ed25519 JWK = %+v", ed)
+	}
+	if rs.KeyID != "rs" || rs.KeyType != "RSA" || rs.Algorithm != AlgRS256 || rs.N == "" || rs.E != "AQAB" {
+		t.Errorf("RSA JWK = %+v", rs)
+	}
+
+	published, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if strings.Contains(string(published), `"hs"`) || strings.Contains(string(published), string(keys.hmac.VerifyKey.([]byte))) {
+		t.Errorf("JWKS publishes the HMAC key: %s", published)
+	}
+
+	if set := newTestKeyring(t, keys.hmac.ID, keys.hmac).JWKS(); len(set.Keys) != 0 {
+		t.Errorf("JWKS of an HMAC keyring = %+v, want no keys", set.Keys)
+	}
+}
+
+func TestNewKeyringErrors(t *testing.T) {
+	keys := newTestKeys(t)
+	verifyOnly := &Key{ID: "verify-only", Method: jwt.SigningMethodRS256, VerifyKey: keys.rsa.VerifyKey}
+
+	tests := []struct {
+		name      string
+		signingID string
+		keys      []*Key
+	}{
+		{"duplicate kid", "hs", []*Key{keys.hmac, keys.hmac}},
+		{"unknown signing key", "missing", []*Key{keys.hmac}},
+		{"signing key without a private key", "verify-only", []*Key{verifyOnly}},
+		{"retiring signing key", "hs", []*Key{retired(keys.hmac, time.Now().Add(time.Hour))}},
+	}
+	for _, tt := range tests {
+		if _, err := NewKeyring(tt.signingID, tt.keys...); !errors.Is(err, ErrInvalidKeyring) {
+			t.Errorf("%s: err = %v, want ErrInvalidKeyring", tt.name, err)
+		}
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	keys := newTestKeys(t)
+
+	private, err := x509.MarshalPKCS8PrivateKey(keys.ed25519.SignKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	writeFile := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	writeFile("ed.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}))
+	writeFile("rs.pub.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: mustMarshalPublic(t, keys.rsa.VerifyKey)}))
+	writeFile("keyring.json", []byte(`{
+		"signing_key": "ed",
+		"keys": [
+			{"kid": "ed", "alg": "EdDSA", "private_key_file": "ed.pem"},
+			{"kid": "rs", "alg": "RS256", "public_key_file": "rs.pub.pem"},
+			{"kid": "hs", "alg": "HS256", "secret": "hmac-secret-0123456789abcdef0123", "verify_until": "2999-01-01T00:00:00Z"}
+		]
+	}`))
+
+	ring, err := LoadKeyring(filepath.Join(dir, "keyring.json"))
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+
+	// Tokens of every key in the file verify: the signing key's own, and
+	// those of the keys that only verify
+	own, err := ring.sign(testClaims(1))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	rsToken, _ := newTestKeyring(t, keys.rsa.ID, keys.rsa).sign(testClaims(2))
+	hsToken, _ := newTestKeyring(t, keys.hmac.ID, keys.hmac).sign(testClaims(3))
+	for i, token := range []string{own, rsToken, hsToken} {
+		if claims, err := verify(ring, token); err != nil || claims.UserId != i+1 {
+			t.Errorf("token %d: %+v, %v", i+1, claims, err)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"bad-json.json":  `{`,
+		"no-kid.json":    `{"signing_key": "a", "keys": [{"alg": "HS256", "secret": "s"}]}`,
+		"bad-alg.json":   `{"signing_key": "a", "keys": [{"kid": "a", "alg": "none"}]}`,
+		"no-secret.json": `{"signing_key": "a", "keys": [{"kid": "a", "alg": "HS256"}]}`,
+		"no-file.json":   `{"signing_key": "a", "keys": [{"kid": "a", "alg": "EdDSA"}]}`,
+	} {
+		writeFile(name, []byte(content))
+		if _, err := LoadKeyring(filepath.Join(dir, name)); !errors.Is(err, ErrInvalidKeyring) {
+			t.Errorf("%s: err = %v, want ErrInvalidKeyring", name, err)
+		}
+	}
+}
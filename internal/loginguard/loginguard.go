@@ -0,0 +1,163 @@
+// Package loginguard slows down password guessing. It counts failed logins
+// per account and per client IP, makes each key wait exponentially longer
+// between attempts and locks it out for a while after too many failures.
+package loginguard
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Attempts is the failure count of a key. Logins count as failures until
+// they succeed.
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Store keeps failure counters. Counters are forgotten ttl after their last
+// failure. Implementations must count concurrent attempts atomically; the
+// operations map onto a Redis hash with HINCRBY, HSET and EXPIRE.
+type Store interface {
+	// AddFailure counts a failure of key at the time at and returns the
+	// counter as it was before, which is zero when there was none
+	AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error)
+	// Release takes back a failure counted for key, never going below zero
+	Release(ctx context.Context, key string) error
+	// Reset forgets the counter of key
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy decides how long a key waits after a number of failures
+type Policy struct {
+	// FreeAttempts failures are allowed without waiting
+	FreeAttempts int
+	// BaseDelay is the wait after the first failure beyond FreeAttempts; it
+	// doubles with every further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter failures lock the key out for LockoutDuration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// Window is how long failures are remembered after the last one
+	Window time.Duration
+}
+
+// DefaultAccountPolicy protects single accounts
+var DefaultAccountPolicy = Policy{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAfter:    10,
+	LockoutDuration: 15 * time.Minute,
+	Window:          15 * time.Minute,
+}
+
+// DefaultIPPolicy protects against guessing across many accounts from one
+// address. It is lenient, as many users may share an address.
+var DefaultIPPolicy = Policy{
+	FreeAttempts:    20,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAfter:    100,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+// Delay returns how long to wait after failures
+func (p Policy) Delay(failures int) time.Duration {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// ttl keeps counters at least as long as their lockout
+func (p Policy) ttl() time.Duration {
+	if p.LockoutDuration > p.Window {
+		return p.LockoutDuration
+	}
+	return p.Window
+}
+
+// Guard applies an account and an IP policy to login attempts
+type Guard struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+}
+
+// New creates a Guard
+func New(store Store, account, ip Policy) *Guard {
+	return &Guard{Store: store, Account: account, IP: ip}
+}
+
+// Attempt counts a login of email from ip as failed before its credentials
+// are checked, so that concurrent guesses cannot all get past a low counter.
+// It returns how long the login has to wait, zero meaning it may go ahead,
+// and how long the next attempt has to wait if this one fails. Attempts
+// made while waiting count as well, so retrying early only extends the wait.
+func (g *Guard) Attempt(ctx context.Context, email, ip string) (wait, next time.Duration, err error) {
+	now := time.Now()
+	for _, k := range g.keys(email, ip) {
+		previous, err := g.Store.AddFailure(ctx, k.key, now, k.policy.ttl())
+		if err != nil {
+			return 0, 0, err
+		}
+		if previous.Failures > 0 {
+			if w := previous.LastFailure.Add(k.policy.Delay(previous.Failures)).Sub(now); w > wait {
+				wait = w
+			}
+		}
+		if n := k.policy.Delay(previous.Failures + 1); n > next {
+			next = n
+		}
+	}
+	return wait, next, nil
+}
+
+// Release takes back the attempt counted for ip by a login whose password
+// was right but which still needs a second factor
+func (g *Guard) Release(ctx context.Context, ip string) error {
+	return g.Store.Release(ctx, ipKey(ip))
+}
+
+// Succeed forgets the failures of an account after a successful login and
+// takes back the attempt counted for ip. The other failures of the IP are
+// kept, so that an attacker cannot clear them by logging into an account of
+// their own.
+func (g *Guard) Succeed(ctx context.Context, email, ip string) error {
+	if err := g.Store.Reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	return g.Release(ctx, ip)
+}
+
+type guardedKey struct {
+	key    string
+	policy Policy
+}
+
+func (g *Guard) keys(email, ip string) []guardedKey {
+	return []guardedKey{{accountKey(email), g.Account}, {ipKey(ip), g.IP}}
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// accountKey ignores the case of emails, so that it cannot be used to get more attempts
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,160 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+	}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{8, 10 * time.Second},
+		{9, 10 * time.Second},
+		{10, 15 * time.Minute},
+		{50, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+
+	// Without LockoutAfter the delay stays capped at MaxDelay
+	policy.LockoutAfter = 0
+	if got := policy.Delay(1000); got != policy.MaxDelay {
+		t.Errorf("Delay(1000) without lockout = %v, want %v", got, policy.MaxDelay)
+	}
+}
+
+func TestPolicyTTL(t *testing.T) {
+	if got := (Policy{Window: time.Hour, LockoutDuration: time.Minute}).ttl(); got != time.Hour {
+		t.Errorf("ttl = %v, want the window", got)
+	}
+	if got := (Policy{Window: time.Minute, LockoutDuration: time.Hour}).ttl(); got != time.Hour {
+		t.Errorf("ttl = %v, want the lockout", got)
+	}
+}
+
+func TestGuardAttempt(t *testing.T) {
+	guard := New(NewMemoryStore(), DefaultAccountPolicy, DefaultIPPolicy)
+	ctx := context.Background()
+
+	for i := 1; i <= DefaultAccountPolicy.FreeAttempts; i++ {
+		wait, _, err := guard.Attempt(ctx, "Jane@Example.com", "192.0.2.1")
+		if err != nil || wait != 0 {
+			t.Fatalf("attempt %d: wait = %v, err = %v; want no wait", i, wait, err)
+		}
+	}
+	wait, next, err := guard.Attempt(ctx, "jane@example.com ", "192.0.2.1")
+	if err != nil || wait != 0 || next != DefaultAccountPolicy.BaseDelay {
+		t.Fatalf("last free attempt: wait = %v, next = %v, err = %v", wait, next, err)
+	}
+	// The account key ignores case and spaces, so it cannot be used to get more attempts
+	wait, _, err = guard.Attempt(ctx, "JANE@example.com", "192.0.2.2")
+	if err != nil || wait <= 0 || wait > DefaultAccountPolicy.BaseDelay {
+		t.Fatalf("attempt after the free ones: wait = %v, err = %v; want up to %v", wait, err, DefaultAccountPolicy.BaseDelay)
+	}
+
+	// A successful login forgets the failures of the account
+	if err := guard.Succeed(ctx, "jane@example.com", "192.0.2.2"); err != nil {
+		t.Fatalf("Succeed: %v", err)
+	}
+	if wait, _, _ := guard.Attempt(ctx, "jane@example.com", "192.0.2.3"); wait != 0 {
+		t.Errorf("attempt after a success: wait = %v, want none", wait)
+	}
+}
+
+func TestGuardAttemptIsAtomic(t *testing.T) {
+	guard := New(NewMemoryStore(), DefaultAccountPolicy, DefaultIPPolicy)
+
+	var (
+		mu      sync.Mutex
+		allowed int
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, _, err := guard.Attempt(context.Background(), "jane@example.com", "192.0.2.1")
+			if err == nil && wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only the free attempts and the one after them, which waits for nothing yet, get through
+	if want := DefaultAccountPolicy.FreeAttempts + 1; allowed != want {
+		t.Errorf("%d of 50 concurrent attempts got through, want %d", allowed, want)
+	}
+}
+
+func TestGuardRelease(t *testing.T) {
+	ip := Policy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Minute, Window: time.Hour}
+	guard := New(NewMemoryStore(), DefaultAccountPolicy, ip)
+	ctx := context.Background()
+
+	// Logins of many accounts from one address that succeed are taken back
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		if wait, _, _ := guard.Attempt(ctx, email, "192.0.2.1"); wait != 0 {
+			t.Fatalf("login of %s waits %v", email, wait)
+		}
+		if err := guard.Succeed(ctx, email, "192.0.2.1"); err != nil {
+			t.Fatalf("Succeed: %v", err)
+		}
+	}
+
+	// Failures from the address are kept
+	guard.Attempt(ctx, "d@example.com", "192.0.2.1")
+	guard.Attempt(ctx, "e@example.com", "192.0.2.1")
+	if wait, _, _ := guard.Attempt(ctx, "f@example.com", "192.0.2.1"); wait == 0 {
+		t.Error("attempt after failures from the address does not wait")
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	start := time.Now()
+
+	store.AddFailure(ctx, "k", start, time.Minute)
+	previous, _ := store.AddFailure(ctx, "k", start.Add(time.Second), time.Minute)
+	if previous.Failures != 1 || !previous.LastFailure.Equal(start) {
+		t.Errorf("previous counter = %+v, want one failure at the start", previous)
+	}
+
+	// A counter past its ttl starts over
+	previous, _ = store.AddFailure(ctx, "k", start.Add(2*time.Minute), time.Minute)
+	if previous.Failures != 0 {
+		t.Errorf("previous counter after expiry = %+v, want none", previous)
+	}
+
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	store.Release(ctx, "k")
+	previous, _ = store.AddFailure(ctx, "k", start.Add(2*time.Minute), time.Minute)
+	if previous.Failures != 0 {
+		t.Errorf("previous counter after releasing more than was counted = %+v, want none", previous)
+	}
+}
@@ -0,0 +1,82 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStoreSize bounds the number of counters a MemoryStore keeps
+const memoryStoreSize = 100000
+
+type memoryEntry struct {
+	Attempts
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in memory. Counters are not shared between
+// instances and are lost on restart, so it suits single instances and development.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+// AddFailure implements Store
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || !at.Before(entry.expiresAt) {
+		entry = memoryEntry{}
+		if len(s.entries) >= memoryStoreSize {
+			s.evict(at)
+		}
+	}
+	previous := entry.Attempts
+	entry.Failures++
+	entry.LastFailure = at
+	entry.expiresAt = at.Add(ttl)
+	s.entries[key] = entry
+	return previous, nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok && entry.Failures > 0 {
+		entry.Failures--
+		s.entries[key] = entry
+	}
+	return nil
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// evict drops expired counters and, if the store is still full, the one
+// closest to expiring; the caller holds the lock
+func (s *MemoryStore) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		} else if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(s.entries) >= memoryStoreSize {
+		delete(s.entries, oldestKey)
+	}
+}
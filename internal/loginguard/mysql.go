@@ -0,0 +1,77 @@
+package loginguard
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// MySQLStore keeps counters in the login_attempt_counters table, which
+// shares them between instances
+type MySQLStore struct {
+	DB *sql.DB
+}
+
+// NewMySQLStore creates a MySQLStore
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{DB: db}
+}
+
+// AddFailure implements Store. An expired counter starts over at one.
+func (s *MySQLStore) AddFailure(ctx context.Context, key string, at time.Time, ttl time.Duration) (Attempts, error) {
+	at = at.UTC().Truncate(time.Second)
+
+	// Expired counters of other keys are removed a few at a time
+	if _, err := s.DB.ExecContext(ctx, "DELETE FROM login_attempt_counters WHERE expires_at <= ? LIMIT 100", at); err != nil {
+		return Attempts{}, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Attempts{}, err
+	}
+	defer tx.Rollback()
+
+	// Locking the counter, created empty if there is none, makes concurrent
+	// failures of the key count one after another
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_attempt_counters (counter_key, failures, last_failure_at, expires_at) VALUES (?, 0, ?, ?)
+		ON DUPLICATE KEY UPDATE counter_key = counter_key`,
+		key, at, at)
+	if err != nil {
+		return Attempts{}, err
+	}
+
+	var previous Attempts
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx,
+		"SELECT failures, last_failure_at, expires_at FROM login_attempt_counters WHERE counter_key = ?", key).
+		Scan(&previous.Failures, &previous.LastFailure, &expiresAt)
+	if err != nil {
+		return Attempts{}, err
+	}
+	if !expiresAt.After(at) {
+		previous = Attempts{}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE login_attempt_counters SET failures = ?, last_failure_at = ?, expires_at = ? WHERE counter_key = ?",
+		previous.Failures+1, at, at.Add(ttl), key)
+	if err != nil {
+		return Attempts{}, err
+	}
+	return previous, tx.Commit()
+}
+
+// Release implements Store
+func (s *MySQLStore) Release(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx,
+		"UPDATE login_attempt_counters SET failures = failures - 1 WHERE counter_key = ? AND failures > 0", key)
+	return err
+}
+
+// Reset implements Store
+func (s *MySQLStore) Reset(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM login_attempt_counters WHERE counter_key = ?", key)
+	return err
+}
@@ -0,0 +1,33 @@
+// Package mailer sends transactional email such as password reset links,
+// through SMTP or, for development and tests, to an outbox directory.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidMessage is returned for messages without a recipient or with
+// line breaks in a header field
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// validate rejects messages whose header fields could inject further headers
+func (m Message) validate() error {
+	if m.To == "" || strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return ErrInvalidMessage
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Outbox keeps messages instead of sending them, so that the application
+// runs without a mail server. Every message is logged and, when Dir is set,
+// also written to a file there.
+type Outbox struct {
+	Dir string
+}
+
+// NewOutbox creates an Outbox, creating dir if needed; an empty dir only logs
+func NewOutbox(dir string) (*Outbox, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &Outbox{Dir: dir}, nil
+}
+
+// Send implements Mailer
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+
+	if o.Dir == "" {
+		log.Printf("Outbox message:\n%s", content)
+		return nil
+	}
+
+	file, err := os.CreateTemp(o.Dir, time.Now().UTC().Format("20060102T150405")+"-*.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("Outbox message to %s written to %s", msg.To, filepath.Base(file.Name()))
+	return nil
+}
@@ -0,0 +1,110 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig holds the settings of an SMTP server
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password are optional; PLAIN authentication is only used when Username is set
+	Username string
+	Password string
+	// From is the sender address, e.g. "Expense Manager <no-reply@example.com>"
+	From string
+}
+
+// SMTP sends messages through an SMTP server, using STARTTLS when the server offers it
+type SMTP struct {
+	config SMTPConfig
+	from   *mail.Address
+}
+
+// NewSMTP creates an SMTP mailer
+func NewSMTP(config SMTPConfig) (*SMTP, error) {
+	if config.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %v", config.From, err)
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &SMTP{config: config, from: from}, nil
+}
+
+// Send implements Mailer
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	data, err := s.encode(msg, to)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	// net/smtp has no context support, so a cancelled context only stops waiting
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.from.Address, []string{to.Address}, data)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// encode renders a message as a quoted-printable UTF-8 text email
+func (s *SMTP) encode(msg Message, to *mail.Address) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), s.config.Host)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&b)
+	if _, err := body.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -2,26 +2,38 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/services"
 )
 
-// AuthMiddleware validates access tokens and adds the user ID to the request context
-func AuthMiddleware(tokenService *jwt.TokenService) func(http.Handler) http.Handler {
+// AuthMiddleware validates access tokens from the Authorization header or
+// the access_token cookie and adds the user and session IDs to the request
+// context, with the roles and permissions of staff users. Staff impersonating
+// a user may only read. Personal access tokens are accepted as bearer tokens
+// on routes wrapped in RequireScope; their scopes are added to the context as well.
+func AuthMiddleware(tokenService *jwt.TokenService, personalTokens *services.PersonalAccessTokenService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Retrieve access token from cookies
-			accessTokenCookie, err := r.Cookie("access_token")
-			if err != nil {
+			// Retrieve the access token from the Authorization header or the cookie
+			accessToken, ok := accessTokenFrom(r)
+			if !ok {
 				http.Error(w, "Unauthorized: Missing access token", http.StatusUnauthorized)
 				return
 			}
 
+			if strings.HasPrefix(accessToken, services.PersonalAccessTokenPrefix) {
+				servePersonalToken(w, r, next, personalTokens, accessToken)
+				return
+			}
+
 			// Validate the access token
-			claims, err := tokenService.ValidateAccessToken(accessTokenCookie.Value)
-			log.Println(claims)
+			claims, err := tokenService.ValidateAccessToken(accessToken)
 			if err != nil {
 				if err.Error() == "token is expired" {
 					http.Error(w, "Unauthorized: Access token expired", http.StatusUnauthorized)
@@ -31,9 +43,97 @@ func AuthMiddleware(tokenService *jwt.TokenService) func(http.Handler) http.Hand
 				return
 			}
 
-			// Add user ID to the context
+			// Staff acting as a user can look but not change anything
+			if claims.ImpersonatorID != 0 && !isReadOnly(r.Method) {
+				http.Error(w, "Forbidden: Impersonation sessions are read-only", http.StatusForbidden)
+				return
+			}
+
+			// Add user and session IDs, roles and permissions to the context
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserId)
+			ctx = context.WithValue(ctx, "session_id", claims.SessionID)
+			ctx = context.WithValue(ctx, "email_verified", claims.EmailVerified)
+			ctx = context.WithValue(ctx, "roles", claims.Roles)
+			ctx = context.WithValue(ctx, "permissions", claims.Permissions)
+			ctx = context.WithValue(ctx, "impersonator_id", claims.ImpersonatorID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// servePersonalToken authenticates a request made with a personal access
+// token. Such tokens may only call routes that declare a scope.
+func servePersonalToken(w http.ResponseWriter, r *http.Request, next http.Handler,
+	personalTokens *services.PersonalAccessTokenService, token string) {
+	userId, scopes, err := personalTokens.AuthenticatePersonalToken(token)
+	if errors.Is(err, services.ErrInvalidPersonalToken) {
+		http.Error(w, "Unauthorized: Invalid personal access token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Println("Personal access token lookup failed:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		http.Error(w, "Forbidden: Personal access tokens cannot use this endpoint", http.StatusForbidden)
+		return
+	}
+	if _, ok := route.GetHandler().(*scopedHandler); !ok {
+		http.Error(w, "Forbidden: Personal access tokens cannot use this endpoint", http.StatusForbidden)
+		return
+	}
+
+	// Personal access tokens are created in a session, which the email verification policy already applied to
+	ctx := context.WithValue(r.Context(), "user_id", userId)
+	ctx = context.WithValue(ctx, "scopes", scopes)
+	ctx = context.WithValue(ctx, "email_verified", true)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// accessTokenFrom returns the bearer token of the Authorization header, which
+// API clients send, or else the access_token cookie browsers send
+func accessTokenFrom(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", false
+		}
+		return token, true
+	}
+
+	cookie, err := r.Cookie("access_token")
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// UserIDFromContext returns the authenticated user ID stored by AuthMiddleware
+func UserIDFromContext(ctx context.Context) int {
+	userId, _ := ctx.Value("user_id").(int)
+	return userId
+}
+
+// SessionIDFromContext returns the session ID of the access token stored by AuthMiddleware
+func SessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value("session_id").(string)
+	return sessionID
+}
+
+// EmailVerifiedFromContext reports whether the access token stored by
+// AuthMiddleware says the user had verified their email
+func EmailVerifiedFromContext(ctx context.Context) bool {
+	verified, _ := ctx.Value("email_verified").(bool)
+	return verified
+}
+
+// ImpersonatorIDFromContext returns the staff user acting as the
+// authenticated user, or 0 when the user is not being impersonated
+func ImpersonatorIDFromContext(ctx context.Context) int {
+	impersonatorID, _ := ctx.Value("impersonator_id").(int)
+	return impersonatorID
+}
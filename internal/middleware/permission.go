@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/henok-tesfu/expense-manager/internal/rbac"
+)
+
+// RequirePermission only lets requests through whose access token grants
+// permission. It must run after AuthMiddleware, either for a whole subrouter
+// with Use or around a single route handler. Personal access tokens never
+// carry permissions.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rbac.Has(PermissionsFromContext(r.Context()), permission) {
+				http.Error(w, "Forbidden: Missing the "+permission+" permission", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RolesFromContext returns the roles of the access token stored by AuthMiddleware
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value("roles").([]string)
+	return roles
+}
+
+// PermissionsFromContext returns the permissions of the access token stored by AuthMiddleware
+func PermissionsFromContext(ctx context.Context) []string {
+	permissions, _ := ctx.Value("permissions").([]string)
+	return permissions
+}
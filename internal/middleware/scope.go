@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// scopedHandler is a route handler that personal access tokens may call when
+// they have its scope. AuthMiddleware rejects personal access tokens on
+// routes whose handler is not a scopedHandler.
+type scopedHandler struct {
+	scope string
+	next  http.Handler
+}
+
+// RequireScope wraps the handler of a route that personal access tokens may
+// call when they have scope. Requests authenticated with a session are not
+// limited by scopes.
+func RequireScope(scope string, next http.HandlerFunc) http.Handler {
+	return &scopedHandler{scope: scope, next: next}
+}
+
+func (h *scopedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if scopes, ok := ScopesFromContext(r.Context()); ok && !hasScope(scopes, h.scope) {
+		http.Error(w, "Forbidden: Personal access token lacks the "+h.scope+" scope", http.StatusForbidden)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// ScopesFromContext returns the scopes of a personal access token stored by
+// AuthMiddleware; ok is false for requests authenticated with a session
+func ScopesFromContext(ctx context.Context) (scopes []string, ok bool) {
+	scopes, ok = ctx.Value("scopes").([]string)
+	return scopes, ok
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// Policies for users who have not verified their email
+const (
+	// UnverifiedAccessFull lets unverified users do everything
+	UnverifiedAccessFull = "full"
+	// UnverifiedAccessReadOnly lets unverified users read but not change anything
+	UnverifiedAccessReadOnly = "read-only"
+	// UnverifiedAccessNone only lets unverified users call routes wrapped in AllowUnverified
+	UnverifiedAccessNone = "none"
+)
+
+// unverifiedHandler is a route handler that unverified users may always call
+type unverifiedHandler struct {
+	http.Handler
+}
+
+// AllowUnverified wraps the handler of a route that users who have not
+// verified their email may call whatever the policy, such as resending the
+// verification email
+func AllowUnverified(next http.HandlerFunc) http.Handler {
+	return &unverifiedHandler{Handler: next}
+}
+
+// RequireVerifiedEmail limits what users who have not verified their email
+// may do according to policy. It must run after AuthMiddleware. Access tokens
+// record whether the user was verified when they were issued; for tokens of
+// unverified users the database is asked, as the user may have verified since.
+func RequireVerifiedEmail(policy string, verification *services.EmailVerificationService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy == UnverifiedAccessFull || EmailVerifiedFromContext(r.Context()) || allowsUnverified(r, policy) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			verified, err := verification.IsEmailVerified(UserIDFromContext(r.Context()))
+			if err != nil {
+				log.Println("Email verification lookup failed:", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !verified {
+				http.Error(w, "Forbidden: Verify your email to use this endpoint", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowsUnverified reports whether the policy lets unverified users make the request
+func allowsUnverified(r *http.Request, policy string) bool {
+	if route := mux.CurrentRoute(r); route != nil {
+		if _, ok := route.GetHandler().(*unverifiedHandler); ok {
+			return true
+		}
+	}
+	return policy == UnverifiedAccessReadOnly && isReadOnly(r.Method)
+}
+
+// isReadOnly reports whether requests with the method only read
+func isReadOnly(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
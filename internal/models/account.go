@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Account is a bank account or card the user's expenses are paid from.
+// Identifier matches the account number found in imported statements.
+type Account struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	Identifier *string   `json:"identifier"`
+	Currency   *string   `json:"currency"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+const accountColumns = "id, user_id, name, identifier, currency, created_at, updated_at"
+
+// Create a new account
+func CreateAccount(a *Account) (int64, error) {
+	result, err := database.DB.Exec("INSERT INTO accounts (user_id, name, identifier, currency) VALUES (?, ?, ?, ?)",
+		a.UserID, a.Name, a.Identifier, a.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// EnsureAccount returns the ID of the user's account with the given
+// identifier, creating it when there is none. created reports whether the
+// account is new; concurrent calls never create the same account twice.
+func EnsureAccount(a *Account) (id int64, created bool, err error) {
+	result, err := database.DB.Exec(`INSERT INTO accounts (user_id, name, identifier, currency) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`,
+		a.UserID, a.Name, a.Identifier, a.Currency)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if id, err = result.LastInsertId(); err != nil {
+		return 0, false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	return id, n == 1, nil
+}
+
+// Get an account by ID, scoped to its owner
+func GetAccountByID(userID, id int) (*Account, error) {
+	row := database.DB.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE id = ? AND user_id = ?", id, userID)
+	a, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// Get the account of a user with the given statement identifier
+func GetAccountByIdentifier(userID int, identifier string) (*Account, error) {
+	row := database.DB.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE user_id = ? AND identifier = ?", userID, identifier)
+	a, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// List all accounts of a user
+func ListAccounts(userID int) ([]*Account, error) {
+	rows, err := database.DB.Query("SELECT "+accountColumns+" FROM accounts WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*Account{}
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// Update an existing account
+func UpdateAccount(a *Account) error {
+	_, err := database.DB.Exec("UPDATE accounts SET name = ?, identifier = ?, currency = ? WHERE id = ? AND user_id = ?",
+		a.Name, a.Identifier, a.Currency, a.ID, a.UserID)
+	return err
+}
+
+// Delete an account, returning false when no row belongs to the user. Its
+// expenses are kept without an account.
+func DeleteAccount(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM accounts WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func scanAccount(s rowScanner) (*Account, error) {
+	a := &Account{}
+	err := s.Scan(&a.ID, &a.UserID, &a.Name, &a.Identifier, &a.Currency, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
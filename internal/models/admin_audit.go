@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Actions of the admin audit log
+const (
+	AdminActionDisableUser    = "user.disable"
+	AdminActionEnableUser     = "user.enable"
+	AdminActionResetTwoFactor = "user.reset_2fa"
+	AdminActionImpersonate    = "user.impersonate"
+	AdminActionGrantRole      = "role.grant"
+	AdminActionRevokeRole     = "role.revoke"
+)
+
+// AdminAuditEntry records an action a staff user took on a user
+type AdminAuditEntry struct {
+	ID int64 `json:"id"`
+	// ActorID is the staff user who took the action; nil once they are purged
+	ActorID      *int      `json:"actor_id"`
+	Action       string    `json:"action"`
+	TargetUserID *int      `json:"target_user_id"`
+	Detail       string    `json:"detail"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AdminAuditFilter narrows down the admin audit log
+type AdminAuditFilter struct {
+	ActorID      int
+	TargetUserID int
+	Action       string
+	Limit        int
+	Offset       int
+}
+
+// RecordAdminAction adds an entry to the admin audit log
+func RecordAdminAction(e *AdminAuditEntry) error {
+	_, err := database.DB.Exec(`INSERT INTO admin_audit_log (actor_id, action, target_user_id, detail, ip, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?)`, e.ActorID, e.Action, e.TargetUserID, e.Detail, e.IP, e.UserAgent)
+	return err
+}
+
+// ListAdminAuditLog returns the entries matching the filter, newest first
+func ListAdminAuditLog(filter AdminAuditFilter) ([]*AdminAuditEntry, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.TargetUserID != 0 {
+		conditions = append(conditions, "target_user_id = ?")
+		args = append(args, filter.TargetUserID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	query := `SELECT id, actor_id, action, target_user_id, detail, ip, user_agent, created_at FROM admin_audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*AdminAuditEntry{}
+	for rows.Next() {
+		e := &AdminAuditEntry{}
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetUserID, &e.Detail, &e.IP, &e.UserAgent,
+			&e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Attachment is a receipt file stored for an expense. The file itself lives
+// in blob storage under StorageKey.
+type Attachment struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	ExpenseID    int       `json:"expense_id"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey *string   `json:"-"`
+	HasThumbnail bool      `json:"has_thumbnail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+const attachmentColumns = "id, user_id, expense_id, filename, content_type, size, storage_key, thumbnail_key, created_at"
+
+// Create a new attachment
+func CreateAttachment(a *Attachment) (int64, error) {
+	result, err := database.DB.Exec(`INSERT INTO attachments
+		(user_id, expense_id, filename, content_type, size, storage_key, thumbnail_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.UserID, a.ExpenseID, a.Filename, a.ContentType, a.Size, a.StorageKey, a.ThumbnailKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get an attachment of an expense, scoped to its owner
+func GetAttachment(userID, expenseID, id int) (*Attachment, error) {
+	row := database.DB.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ? AND expense_id = ? AND user_id = ?",
+		id, expenseID, userID)
+	a, err := scanAttachment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// List the attachments of an expense, scoped to its owner
+func ListAttachments(userID, expenseID int) ([]*Attachment, error) {
+	rows, err := database.DB.Query("SELECT "+attachmentColumns+" FROM attachments WHERE expense_id = ? AND user_id = ? ORDER BY id",
+		expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// List every attachment of a user
+func ListUserAttachments(userID int) ([]*Attachment, error) {
+	rows, err := database.DB.Query("SELECT "+attachmentColumns+" FROM attachments WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Delete an attachment, returning false when no row belongs to the user
+func DeleteAttachment(userID, expenseID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM attachments WHERE id = ? AND expense_id = ? AND user_id = ?",
+		id, expenseID, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func scanAttachment(s rowScanner) (*Attachment, error) {
+	a := &Attachment{}
+	err := s.Scan(&a.ID, &a.UserID, &a.ExpenseID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey,
+		&a.ThumbnailKey, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	a.HasThumbnail = a.ThumbnailKey != nil
+	return a, nil
+}
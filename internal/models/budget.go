@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// Budget periods
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+	PeriodYearly  = "yearly"
+	PeriodCustom  = "custom"
+)
+
+type Budget struct {
+	ID         int          `json:"id"`
+	UserID     int          `json:"user_id"`
+	CategoryID *int         `json:"category_id"`
+	Name       string       `json:"name"`
+	Amount     money.Amount `json:"amount"`
+	Period     string       `json:"period"`
+	StartDate  time.Time    `json:"start_date"`
+	EndDate    *time.Time   `json:"end_date"`
+	Rollover   bool         `json:"rollover"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+const budgetColumns = "id, user_id, category_id, name, CONCAT(amount, ' ', currency), period, start_date, end_date, rollover, created_at, updated_at"
+
+// Create a new budget
+func CreateBudget(b *Budget) (int64, error) {
+	result, err := database.DB.Exec(`INSERT INTO budgets (user_id, category_id, name, amount, currency, period, start_date, end_date, rollover)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		b.UserID, b.CategoryID, b.Name, b.Amount, b.Amount.Currency(), b.Period, b.StartDate, b.EndDate, b.Rollover)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get a budget by ID, scoped to its owner
+func GetBudgetByID(userID, id int) (*Budget, error) {
+	row := database.DB.QueryRow("SELECT "+budgetColumns+" FROM budgets WHERE id = ? AND user_id = ?", id, userID)
+	b, err := scanBudget(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+// List all budgets of a user
+func ListBudgets(userID int) ([]*Budget, error) {
+	rows, err := database.DB.Query("SELECT "+budgetColumns+" FROM budgets WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []*Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// Update an existing budget
+func UpdateBudget(b *Budget) error {
+	_, err := database.DB.Exec(`UPDATE budgets
+		SET category_id = ?, name = ?, amount = ?, currency = ?, period = ?, start_date = ?, end_date = ?, rollover = ?
+		WHERE id = ? AND user_id = ?`,
+		b.CategoryID, b.Name, b.Amount, b.Amount.Currency(), b.Period, b.StartDate, b.EndDate, b.Rollover, b.ID, b.UserID)
+	return err
+}
+
+// CategoryHasBudgets reports whether any budget of the user is limited to the category
+func CategoryHasBudgets(userID, categoryID int) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM budgets WHERE user_id = ? AND category_id = ?)",
+		userID, categoryID).Scan(&exists)
+	return exists, err
+}
+
+// Delete a budget, returning false when no row belongs to the user
+func DeleteBudget(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM budgets WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func scanBudget(s rowScanner) (*Budget, error) {
+	b := &Budget{}
+	err := s.Scan(&b.ID, &b.UserID, &b.CategoryID, &b.Name, &b.Amount, &b.Period, &b.StartDate, &b.EndDate,
+		&b.Rollover, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+type Category struct {
+	ID        int         `json:"id"`
+	UserID    int         `json:"user_id"`
+	ParentID  *int        `json:"parent_id"`
+	Name      string      `json:"name"`
+	Colour    string      `json:"colour"`
+	Icon      string      `json:"icon"`
+	Children  []*Category `json:"children,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+const categoryColumns = "id, user_id, parent_id, name, colour, icon, created_at, updated_at"
+
+// Create a new category
+func CreateCategory(c *Category) (int64, error) {
+	return insertCategory(database.DB, c)
+}
+
+// Create a tree of categories in one transaction, following each Children slice
+func CreateCategoryTree(userID int, roots []*Category) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var insert func(parentID *int, categories []*Category) error
+	insert = func(parentID *int, categories []*Category) error {
+		for _, c := range categories {
+			node := *c
+			node.UserID = userID
+			node.ParentID = parentID
+			id, err := insertCategory(tx, &node)
+			if err != nil {
+				return err
+			}
+			newID := int(id)
+			if err := insert(&newID, c.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := insert(nil, roots); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get a category by ID, scoped to its owner
+func GetCategoryByID(userID, id int) (*Category, error) {
+	row := database.DB.QueryRow("SELECT "+categoryColumns+" FROM categories WHERE id = ? AND user_id = ?", id, userID)
+	c, err := scanCategory(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// List all categories of a user as a flat slice ordered by name
+func ListCategories(userID int) ([]*Category, error) {
+	rows, err := database.DB.Query("SELECT "+categoryColumns+" FROM categories WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// Update an existing category
+func UpdateCategory(c *Category) error {
+	_, err := database.DB.Exec("UPDATE categories SET parent_id = ?, name = ?, colour = ?, icon = ? WHERE id = ? AND user_id = ?",
+		c.ParentID, c.Name, c.Colour, c.Icon, c.ID, c.UserID)
+	return err
+}
+
+// Delete a category in one transaction: its expenses, recurring expenses and
+// their exceptions move to reassignTo (nil leaves them uncategorised), its
+// budgets move to reassignTo when it is set, and its children move up to its
+// own parent
+func DeleteCategory(c *Category, reassignTo *int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE expenses SET category_id = ? WHERE category_id = ? AND user_id = ?",
+		reassignTo, c.ID, c.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE recurring_expenses SET category_id = ? WHERE category_id = ? AND user_id = ?",
+		reassignTo, c.ID, c.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE recurring_expense_exceptions x
+		JOIN recurring_expenses r ON r.id = x.recurring_expense_id
+		SET x.category_id = ? WHERE x.category_id = ? AND r.user_id = ?`,
+		reassignTo, c.ID, c.UserID); err != nil {
+		return err
+	}
+	if reassignTo != nil {
+		if _, err := tx.Exec("UPDATE budgets SET category_id = ? WHERE category_id = ? AND user_id = ?",
+			reassignTo, c.ID, c.UserID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE categories SET parent_id = ? WHERE parent_id = ? AND user_id = ?",
+		c.ParentID, c.ID, c.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = ? AND user_id = ?", c.ID, c.UserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertCategory(db execer, c *Category) (int64, error) {
+	result, err := db.Exec("INSERT INTO categories (user_id, parent_id, name, colour, icon) VALUES (?, ?, ?, ?, ?)",
+		c.UserID, c.ParentID, c.Name, c.Colour, c.Icon)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func scanCategory(s rowScanner) (*Category, error) {
+	c := &Category{}
+	err := s.Scan(&c.ID, &c.UserID, &c.ParentID, &c.Name, &c.Colour, &c.Icon, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Statuses of data exports
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a requested export of all the data of a user. Once ready,
+// the archive lives in blob storage under StorageKey until ExpiresAt.
+type DataExport struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Status      string     `json:"status"`
+	StorageKey  *string    `json:"-"`
+	Size        *int64     `json:"size"`
+	Error       *string    `json:"error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// DownloadURL is a signed, expiring link to the archive of a ready export
+	DownloadURL *string `json:"download_url,omitempty"`
+}
+
+const dataExportColumns = "id, user_id, status, storage_key, size, error, created_at, started_at, completed_at, expires_at"
+
+// Create a pending data export for a user
+func CreateDataExport(userID int) (int64, error) {
+	result, err := database.DB.Exec("INSERT INTO data_exports (user_id, status) VALUES (?, ?)", userID, DataExportPending)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get a data export, scoped to its owner
+func GetDataExport(userID, id int) (*DataExport, error) {
+	return getDataExport("SELECT "+dataExportColumns+" FROM data_exports WHERE id = ? AND user_id = ?", id, userID)
+}
+
+// Get a data export of any user, for links whose signature names it
+func GetDataExportByID(id int) (*DataExport, error) {
+	return getDataExport("SELECT "+dataExportColumns+" FROM data_exports WHERE id = ?", id)
+}
+
+// List the data exports of a user, newest first
+func ListDataExports(userID int) ([]*DataExport, error) {
+	return queryDataExports("SELECT "+dataExportColumns+" FROM data_exports WHERE user_id = ? ORDER BY id DESC", userID)
+}
+
+// HasUnfinishedDataExport reports whether a user has a data export that is pending or running
+func HasUnfinishedDataExport(userID int) (bool, error) {
+	var unfinished bool
+	err := database.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM data_exports WHERE user_id = ? AND status IN (?, ?))",
+		userID, DataExportPending, DataExportRunning).Scan(&unfinished)
+	return unfinished, err
+}
+
+// ClaimDataExport marks the oldest pending data export as running and
+// returns it, or nil when none is pending. Each export is claimed by one
+// caller even when several instances claim at once.
+func ClaimDataExport() (*DataExport, error) {
+	for {
+		var id int
+		err := database.DB.QueryRow("SELECT id FROM data_exports WHERE status = ? ORDER BY id LIMIT 1",
+			DataExportPending).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := database.DB.Exec("UPDATE data_exports SET status = ?, started_at = ? WHERE id = ? AND status = ?",
+			DataExportRunning, time.Now().UTC(), id, DataExportPending)
+		if err != nil {
+			return nil, err
+		}
+		claimed, err := rowsFound(result)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return GetDataExportByID(id)
+		}
+	}
+}
+
+// CompleteDataExport marks a running data export as ready with its archive
+func CompleteDataExport(id int, storageKey string, size int64, expiresAt time.Time) error {
+	_, err := database.DB.Exec(`UPDATE data_exports SET status = ?, storage_key = ?, size = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?`, DataExportReady, storageKey, size, time.Now().UTC(), expiresAt, id)
+	return err
+}
+
+// FailDataExport marks a data export as failed with a message for the user
+func FailDataExport(id int, message string) error {
+	_, err := database.DB.Exec("UPDATE data_exports SET status = ?, error = ?, completed_at = ? WHERE id = ?",
+		DataExportFailed, message, time.Now().UTC(), id)
+	return err
+}
+
+// FailStaleDataExports marks data exports that started running before t as
+// failed, as the instance running them stopped, and returns how many it marked
+func FailStaleDataExports(t time.Time, message string) (int64, error) {
+	result, err := database.DB.Exec("UPDATE data_exports SET status = ?, error = ?, completed_at = ? WHERE status = ? AND started_at < ?",
+		DataExportFailed, message, time.Now().UTC(), DataExportRunning, t)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// List the ready data exports that expired before t or belong to deleted users
+func ListExpiredDataExports(t time.Time) ([]*DataExport, error) {
+	return queryDataExports("SELECT "+dataExportColumns+` FROM data_exports
+		WHERE status = ? AND (expires_at < ? OR user_id IN (SELECT id FROM users WHERE deleted_at IS NOT NULL))
+		ORDER BY id`, DataExportReady, t)
+}
+
+// Delete a data export
+func DeleteDataExport(id int) error {
+	_, err := database.DB.Exec("DELETE FROM data_exports WHERE id = ?", id)
+	return err
+}
+
+func getDataExport(query string, args ...interface{}) (*DataExport, error) {
+	e, err := scanDataExport(database.DB.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+func queryDataExports(query string, args ...interface{}) ([]*DataExport, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []*DataExport{}
+	for rows.Next() {
+		e, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, e)
+	}
+	return exports, rows.Err()
+}
+
+func scanDataExport(s rowScanner) (*DataExport, error) {
+	e := &DataExport{}
+	err := s.Scan(&e.ID, &e.UserID, &e.Status, &e.StorageKey, &e.Size, &e.Error, &e.CreatedAt, &e.StartedAt,
+		&e.CompletedAt, &e.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
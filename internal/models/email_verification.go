@@ -0,0 +1,63 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// CreateEmailVerificationToken stores the SHA-256 hash of a verification
+// token for a user, sent at createdAt
+func CreateEmailVerificationToken(userID int, tokenHash string, createdAt, expiresAt time.Time) error {
+	_, err := database.DB.Exec(`INSERT INTO email_verification_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)`, userID, tokenHash, expiresAt, createdAt)
+	return err
+}
+
+// LastEmailVerificationSentAt returns when the latest verification token of
+// a user was created, or nil when there is none
+func LastEmailVerificationSentAt(userID int) (*time.Time, error) {
+	var sentAt *time.Time
+	err := database.DB.QueryRow("SELECT MAX(created_at) FROM email_verification_tokens WHERE user_id = ?", userID).Scan(&sentAt)
+	return sentAt, err
+}
+
+// VerifyEmail marks the email of the user of an unused, unexpired
+// verification token as verified and uses up all of the user's tokens. ok is
+// false when the token cannot be used.
+func VerifyEmail(tokenHash string) (ok bool, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var (
+		userID    int
+		expiresAt time.Time
+		usedAt    *time.Time
+	)
+	err = tx.QueryRow("SELECT user_id, expires_at, used_at FROM email_verification_tokens WHERE token_hash = ? FOR UPDATE",
+		tokenHash).Scan(&userID, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	if usedAt != nil || !now.Before(expiresAt) {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("UPDATE users SET email_verified_at = ? WHERE id = ? AND email_verified_at IS NULL",
+		now, userID); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec("UPDATE email_verification_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		now, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// ExchangeRate says how many units of Quote one unit of Base was worth on Date
+type ExchangeRate struct {
+	ID        int       `json:"id"`
+	Date      time.Time `json:"date"`
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      string    `json:"rate"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FindExchangeRate returns the rate for the pair on the given date or, when
+// that day has none (weekends, holidays), on the nearest earlier date
+func FindExchangeRate(base, quote string, on time.Time) (*ExchangeRate, error) {
+	rate := &ExchangeRate{}
+	err := database.DB.QueryRow(`SELECT id, date, base, quote, rate, created_at, updated_at FROM exchange_rates
+		WHERE base = ? AND quote = ? AND date <= ?
+		ORDER BY date DESC LIMIT 1`, base, quote, on).
+		Scan(&rate.ID, &rate.Date, &rate.Base, &rate.Quote, &rate.Rate, &rate.CreatedAt, &rate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rate, err
+}
+
+// SaveExchangeRates inserts the rates in one transaction, replacing any rate
+// already stored for the same pair and date
+func SaveExchangeRates(rates []*ExchangeRate) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO exchange_rates (date, base, quote, rate) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE rate = VALUES(rate)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.Exec(rate.Date, rate.Base, rate.Quote, rate.Rate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
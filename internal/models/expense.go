@@ -0,0 +1,232 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+type Expense struct {
+	ID          int          `json:"id"`
+	UserID      int          `json:"user_id"`
+	Amount      money.Amount `json:"amount"`
+	Date        time.Time    `json:"date"`
+	Description string       `json:"description"`
+	CategoryID  *int         `json:"category_id"`
+	AccountID   *int         `json:"account_id"`
+	Payee       string       `json:"payee"`
+	// RecurringExpenseID and OccurrenceDate link expenses materialized from a recurring expense
+	RecurringExpenseID *int       `json:"recurring_expense_id"`
+	OccurrenceDate     *time.Time `json:"occurrence_date"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// DailySpending is the total spent in one currency on one day. CategoryID
+// is only set by SumExpensesByCategoryAndDay.
+type DailySpending struct {
+	Date       time.Time
+	CategoryID *int
+	Amount     money.Amount
+}
+
+// ExpenseFilter narrows down the expenses returned by ListExpenses
+type ExpenseFilter struct {
+	From *time.Time
+	To   *time.Time
+	// CategoryIDs matches expenses in any of the given categories
+	CategoryIDs []int
+	Payee       string
+	Limit       int
+	Offset      int
+}
+
+// expenseColumns reads amount and currency as one "<decimal> <currency>" value for money.Amount
+const expenseColumns = "id, user_id, CONCAT(amount, ' ', currency), date, description, category_id, account_id, payee, recurring_expense_id, occurrence_date, created_at, updated_at"
+
+// Create a new expense
+func CreateExpense(e *Expense) (int64, error) {
+	result, err := database.DB.Exec(`INSERT INTO expenses (user_id, amount, currency, date, description, category_id, account_id, payee)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.UserID, e.Amount, e.Amount.Currency(), e.Date, e.Description, e.CategoryID, e.AccountID, e.Payee)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get an expense by ID, scoped to its owner
+func GetExpenseByID(userID, id int) (*Expense, error) {
+	row := database.DB.QueryRow("SELECT "+expenseColumns+" FROM expenses WHERE id = ? AND user_id = ?", id, userID)
+	e, err := scanExpense(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// List the expenses of a user matching the filter, newest first
+func ListExpenses(userID int, filter ExpenseFilter) ([]*Expense, error) {
+	expenses := []*Expense{}
+	err := EachExpense(userID, filter, func(e *Expense) error {
+		expenses = append(expenses, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// EachExpense calls fn for every expense of a user matching the filter,
+// newest first, without holding them all in memory. An error from fn stops
+// the iteration and is returned.
+func EachExpense(userID int, filter ExpenseFilter, fn func(*Expense) error) error {
+	where, args := filter.whereClause(userID)
+	query := "SELECT " + expenseColumns + " FROM expenses WHERE " + where + " ORDER BY date DESC, id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanExpense(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Update an existing expense
+func UpdateExpense(e *Expense) error {
+	_, err := database.DB.Exec(`UPDATE expenses
+		SET amount = ?, currency = ?, date = ?, description = ?, category_id = ?, account_id = ?, payee = ?
+		WHERE id = ? AND user_id = ?`,
+		e.Amount, e.Amount.Currency(), e.Date, e.Description, e.CategoryID, e.AccountID, e.Payee, e.ID, e.UserID)
+	return err
+}
+
+// Delete an expense, returning false when no row belongs to the user
+func DeleteExpense(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM expenses WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// SumExpensesByDay totals a user's expenses per day and currency in [from, to).
+// An empty categoryIDs slice includes every expense.
+func SumExpensesByDay(userID int, categoryIDs []int, from, to time.Time) ([]DailySpending, error) {
+	query := "SELECT date, CONCAT(SUM(amount), ' ', currency) FROM expenses WHERE user_id = ? AND date >= ? AND date < ?"
+	args := []interface{}{userID, from, to}
+	if len(categoryIDs) > 0 {
+		query += " AND category_id IN (?" + strings.Repeat(", ?", len(categoryIDs)-1) + ")"
+		for _, id := range categoryIDs {
+			args = append(args, id)
+		}
+	}
+	query += " GROUP BY date, currency ORDER BY date"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []DailySpending{}
+	for rows.Next() {
+		var d DailySpending
+		if err := rows.Scan(&d.Date, &d.Amount); err != nil {
+			return nil, err
+		}
+		totals = append(totals, d)
+	}
+	return totals, rows.Err()
+}
+
+// SumExpensesByCategoryAndDay totals a user's expenses per category, day and currency in [from, to)
+func SumExpensesByCategoryAndDay(userID int, from, to time.Time) ([]DailySpending, error) {
+	rows, err := database.DB.Query(`SELECT date, category_id, CONCAT(SUM(amount), ' ', currency) FROM expenses
+		WHERE user_id = ? AND date >= ? AND date < ?
+		GROUP BY category_id, date, currency ORDER BY date`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []DailySpending{}
+	for rows.Next() {
+		var d DailySpending
+		if err := rows.Scan(&d.Date, &d.CategoryID, &d.Amount); err != nil {
+			return nil, err
+		}
+		totals = append(totals, d)
+	}
+	return totals, rows.Err()
+}
+
+// whereClause builds the SQL conditions and arguments for the filter
+func (f ExpenseFilter) whereClause(userID int) (string, []interface{}) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{userID}
+
+	if f.From != nil {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, *f.To)
+	}
+	if len(f.CategoryIDs) > 0 {
+		conditions = append(conditions, "category_id IN (?"+strings.Repeat(", ?", len(f.CategoryIDs)-1)+")")
+		for _, id := range f.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if f.Payee != "" {
+		conditions = append(conditions, "payee = ?")
+		args = append(args, f.Payee)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExpense(s rowScanner) (*Expense, error) {
+	e := &Expense{}
+	err := s.Scan(&e.ID, &e.UserID, &e.Amount, &e.Date, &e.Description, &e.CategoryID, &e.AccountID, &e.Payee,
+		&e.RecurringExpenseID, &e.OccurrenceDate, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// rowsFound reports whether an UPDATE or DELETE matched at least one row
+func rowsFound(result sql.Result) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Reasons of failed logins
+const (
+	FailedLoginInvalidCredentials = "invalid_credentials"
+	FailedLoginInvalidCode        = "invalid_code"
+	FailedLoginLockedOut          = "locked_out"
+)
+
+// FailedLogin is an audit record of a login that failed
+type FailedLogin struct {
+	Email     string
+	IP        string
+	UserAgent string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// RecordFailedLogin stores a failed login, linked to the user when the email is registered
+func RecordFailedLogin(f *FailedLogin) error {
+	_, err := database.DB.Exec(`INSERT INTO failed_logins (user_id, email, ip, user_agent, reason)
+		SELECT (SELECT id FROM users WHERE email = ?), ?, ?, ?, ?`,
+		f.Email, f.Email, f.IP, f.UserAgent, f.Reason)
+	return err
+}
+
+// List the failed logins linked to a user, newest first
+func ListFailedLogins(userID int) ([]*FailedLogin, error) {
+	rows, err := database.DB.Query(`SELECT email, ip, user_agent, reason, created_at FROM failed_logins
+		WHERE user_id = ? ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logins := []*FailedLogin{}
+	for rows.Next() {
+		f := &FailedLogin{}
+		if err := rows.Scan(&f.Email, &f.IP, &f.UserAgent, &f.Reason, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		logins = append(logins, f)
+	}
+	return logins, rows.Err()
+}
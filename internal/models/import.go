@@ -0,0 +1,88 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// ImportedExpense is an expense read from a bank statement together with the
+// hash that identifies its statement line across imports
+type ImportedExpense struct {
+	Expense *Expense
+	Hash    string
+}
+
+// ExistingImportHashes returns which of the given import hashes the user already has expenses for
+func ExistingImportHashes(userID int, hashes []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+
+	// Query in chunks to stay well below placeholder limits on large statements
+	const chunkSize = 500
+	for start := 0; start < len(hashes); start += chunkSize {
+		chunk := hashes[start:min(start+chunkSize, len(hashes))]
+		args := []interface{}{userID}
+		for _, hash := range chunk {
+			args = append(args, hash)
+		}
+
+		rows, err := database.DB.Query("SELECT import_hash FROM expenses WHERE user_id = ? AND import_hash IN (?"+
+			strings.Repeat(", ?", len(chunk)-1)+")", args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			existing[hash] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// CreateImportedExpenses inserts all expenses in one transaction. Lines that
+// were imported before, even concurrently, are skipped thanks to the unique
+// import hash. It returns how many expenses were actually inserted.
+func CreateImportedExpenses(expenses []ImportedExpense) (int64, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO expenses
+		(user_id, amount, currency, date, description, category_id, account_id, payee, import_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var inserted int64
+	for _, imported := range expenses {
+		e := imported.Expense
+		result, err := stmt.Exec(e.UserID, e.Amount, e.Amount.Currency(), e.Date, e.Description, e.CategoryID, e.AccountID,
+			e.Payee, imported.Hash)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
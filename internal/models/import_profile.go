@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Amount sign conventions of bank statements
+const (
+	// SignNegativeIsExpense: one signed column, money going out is negative
+	SignNegativeIsExpense = "negative_is_expense"
+	// SignPositiveIsExpense: one signed column, money going out is positive
+	SignPositiveIsExpense = "positive_is_expense"
+	// SignDebitCredit: separate unsigned debit (out) and credit (in) columns
+	SignDebitCredit = "debit_credit"
+)
+
+// ImportProfile describes how to read the CSV statements of one bank.
+// Columns are referenced by header name, or by 1-based position for files
+// without a header row.
+type ImportProfile struct {
+	ID                 int       `json:"id"`
+	UserID             int       `json:"user_id"`
+	Name               string    `json:"name"`
+	Delimiter          string    `json:"delimiter"`
+	HasHeader          bool      `json:"has_header"`
+	SkipRows           int       `json:"skip_rows"`
+	DateColumn         string    `json:"date_column"`
+	DateFormat         string    `json:"date_format"`
+	AmountSign         string    `json:"amount_sign"`
+	AmountColumn       string    `json:"amount_column"`
+	DebitColumn        string    `json:"debit_column"`
+	CreditColumn       string    `json:"credit_column"`
+	DecimalSeparator   string    `json:"decimal_separator"`
+	DescriptionColumns []string  `json:"description_columns"`
+	PayeeColumn        string    `json:"payee_column"`
+	Currency           string    `json:"currency"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+const importProfileColumns = `id, user_id, name, delimiter, has_header, skip_rows, date_column, date_format, amount_sign,
+	amount_column, debit_column, credit_column, decimal_separator, description_columns, payee_column, currency,
+	created_at, updated_at`
+
+// Create a new import profile
+func CreateImportProfile(p *ImportProfile) (int64, error) {
+	descriptionColumns, err := json.Marshal(p.DescriptionColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := database.DB.Exec(`INSERT INTO import_profiles
+		(user_id, name, delimiter, has_header, skip_rows, date_column, date_format, amount_sign, amount_column,
+		debit_column, credit_column, decimal_separator, description_columns, payee_column, currency)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.UserID, p.Name, p.Delimiter, p.HasHeader, p.SkipRows, p.DateColumn, p.DateFormat, p.AmountSign, p.AmountColumn,
+		p.DebitColumn, p.CreditColumn, p.DecimalSeparator, string(descriptionColumns), p.PayeeColumn, p.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get an import profile by ID, scoped to its owner
+func GetImportProfileByID(userID, id int) (*ImportProfile, error) {
+	row := database.DB.QueryRow("SELECT "+importProfileColumns+" FROM import_profiles WHERE id = ? AND user_id = ?", id, userID)
+	p, err := scanImportProfile(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// List all import profiles of a user
+func ListImportProfiles(userID int) ([]*ImportProfile, error) {
+	rows, err := database.DB.Query("SELECT "+importProfileColumns+" FROM import_profiles WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []*ImportProfile{}
+	for rows.Next() {
+		p, err := scanImportProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// Update an existing import profile
+func UpdateImportProfile(p *ImportProfile) error {
+	descriptionColumns, err := json.Marshal(p.DescriptionColumns)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(`UPDATE import_profiles
+		SET name = ?, delimiter = ?, has_header = ?, skip_rows = ?, date_column = ?, date_format = ?, amount_sign = ?,
+		amount_column = ?, debit_column = ?, credit_column = ?, decimal_separator = ?, description_columns = ?,
+		payee_column = ?, currency = ?
+		WHERE id = ? AND user_id = ?`,
+		p.Name, p.Delimiter, p.HasHeader, p.SkipRows, p.DateColumn, p.DateFormat, p.AmountSign,
+		p.AmountColumn, p.DebitColumn, p.CreditColumn, p.DecimalSeparator, string(descriptionColumns),
+		p.PayeeColumn, p.Currency, p.ID, p.UserID)
+	return err
+}
+
+// Delete an import profile, returning false when no row belongs to the user
+func DeleteImportProfile(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM import_profiles WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func scanImportProfile(s rowScanner) (*ImportProfile, error) {
+	p := &ImportProfile{}
+	var descriptionColumns []byte
+	err := s.Scan(&p.ID, &p.UserID, &p.Name, &p.Delimiter, &p.HasHeader, &p.SkipRows, &p.DateColumn, &p.DateFormat,
+		&p.AmountSign, &p.AmountColumn, &p.DebitColumn, &p.CreditColumn, &p.DecimalSeparator, &descriptionColumns,
+		&p.PayeeColumn, &p.Currency, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(descriptionColumns, &p.DescriptionColumns); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// CreatePasswordResetToken stores the SHA-256 hash of a reset token for a user
+func CreatePasswordResetToken(userID int, tokenHash string, expiresAt time.Time) error {
+	_, err := database.DB.Exec("INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		userID, tokenHash, expiresAt)
+	return err
+}
+
+// LastPasswordResetSentAt returns when the latest reset token of a user was
+// created, or nil when there is none
+func LastPasswordResetSentAt(userID int) (*time.Time, error) {
+	var sentAt *time.Time
+	err := database.DB.QueryRow("SELECT MAX(created_at) FROM password_reset_tokens WHERE user_id = ?", userID).Scan(&sentAt)
+	return sentAt, err
+}
+
+// GetPasswordResetUser returns the user of an unused, unexpired reset token, or nil
+func GetPasswordResetUser(tokenHash string) (*User, error) {
+	var userID int
+	err := database.DB.QueryRow("SELECT user_id FROM password_reset_tokens WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		tokenHash, time.Now().UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return GetUserByID(userID)
+}
+
+// ResetPassword sets a new password for the user of an unused, unexpired
+// reset token in one transaction. The token and every other open token of
+// the user are used up, all of the user's sessions are ended and their IDs
+// returned, and every refresh token of the user is revoked. ok is false when
+// the token cannot be used.
+func ResetPassword(tokenHash, passwordHash string) (sessionIDs []string, ok bool, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	var (
+		userID    int
+		expiresAt time.Time
+		usedAt    *time.Time
+	)
+	err = tx.QueryRow("SELECT user_id, expires_at, used_at FROM password_reset_tokens WHERE token_hash = ? FOR UPDATE",
+		tokenHash).Scan(&userID, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now().UTC()
+	if usedAt != nil || !now.Before(expiresAt) {
+		return nil, false, nil
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = ? WHERE id = ?", passwordHash, userID); err != nil {
+		return nil, false, err
+	}
+	if _, err := tx.Exec("UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		now, userID); err != nil {
+		return nil, false, err
+	}
+	if sessionIDs, err = revokeSessionsTx(tx, userID, "TRUE"); err != nil {
+		return nil, false, err
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		now, userID); err != nil {
+		return nil, false, err
+	}
+	return sessionIDs, true, tx.Commit()
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// PersonalAccessToken is a long-lived token a user creates for scripts and
+// integrations. Only the SHA-256 hash of the token is stored; Prefix keeps
+// its first characters so that users can tell their tokens apart.
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+const personalAccessTokenColumns = "id, user_id, name, token_hash, prefix, scopes, expires_at, last_used_at, created_at"
+
+// Create a new personal access token
+func CreatePersonalAccessToken(t *PersonalAccessToken) (int64, error) {
+	result, err := database.DB.Exec(`INSERT INTO personal_access_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.Name, t.TokenHash, t.Prefix, strings.Join(t.Scopes, " "), t.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get a personal access token by ID, scoped to its owner
+func GetPersonalAccessToken(userID, id int) (*PersonalAccessToken, error) {
+	row := database.DB.QueryRow("SELECT "+personalAccessTokenColumns+" FROM personal_access_tokens WHERE id = ? AND user_id = ?", id, userID)
+	t, err := scanPersonalAccessToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// Get a personal access token by the hash of the token; tokens of disabled users are not found
+func GetPersonalAccessTokenByHash(hash string) (*PersonalAccessToken, error) {
+	row := database.DB.QueryRow("SELECT "+personalAccessTokenColumns+` FROM personal_access_tokens
+		WHERE token_hash = ? AND user_id NOT IN (SELECT id FROM users WHERE disabled_at IS NOT NULL)`, hash)
+	t, err := scanPersonalAccessToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// List the personal access tokens of a user, newest first
+func ListPersonalAccessTokens(userID int) ([]*PersonalAccessToken, error) {
+	rows, err := database.DB.Query("SELECT "+personalAccessTokenColumns+` FROM personal_access_tokens
+		WHERE user_id = ? ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*PersonalAccessToken{}
+	for rows.Next() {
+		t, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// TouchPersonalAccessToken records that a token was used at the given time
+func TouchPersonalAccessToken(id int, usedAt time.Time) error {
+	_, err := database.DB.Exec("UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?", usedAt, id)
+	return err
+}
+
+// Delete a personal access token, returning false when no row belongs to the user
+func DeletePersonalAccessToken(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func scanPersonalAccessToken(s rowScanner) (*PersonalAccessToken, error) {
+	t := &PersonalAccessToken{}
+	var scopes string
+	err := s.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.Prefix, &scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.Scopes = strings.Fields(scopes)
+	return t, nil
+}
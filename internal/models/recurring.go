@@ -0,0 +1,218 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+type RecurringExpense struct {
+	ID          int          `json:"id"`
+	UserID      int          `json:"user_id"`
+	Amount      money.Amount `json:"amount"`
+	CategoryID  *int         `json:"category_id"`
+	Description string       `json:"description"`
+	Payee       string       `json:"payee"`
+	RRule       string       `json:"rrule"`
+	StartDate   time.Time    `json:"start_date"`
+	// MaterializedThrough is the last day whose occurrences were turned into expenses
+	MaterializedThrough *time.Time `json:"materialized_through"`
+	Active              bool       `json:"active"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// OccurrenceException skips or overrides a single occurrence of a recurring expense.
+// Nil override fields keep the value of the recurring expense.
+type OccurrenceException struct {
+	ID                 int           `json:"id"`
+	RecurringExpenseID int           `json:"recurring_expense_id"`
+	OccurrenceDate     time.Time     `json:"occurrence_date"`
+	Skipped            bool          `json:"skipped"`
+	Amount             *money.Amount `json:"amount,omitempty"`
+	Date               *time.Time    `json:"date,omitempty"`
+	CategoryID         *int          `json:"category_id,omitempty"`
+	Description        *string       `json:"description,omitempty"`
+	Payee              *string       `json:"payee,omitempty"`
+}
+
+const recurringExpenseColumns = `id, user_id, CONCAT(amount, ' ', currency), category_id, description, payee, rrule,
+	start_date, materialized_through, active, created_at, updated_at`
+
+const occurrenceExceptionColumns = `id, recurring_expense_id, occurrence_date, skipped, CONCAT(amount, ' ', currency), date,
+	category_id, description, payee`
+
+// Create a new recurring expense
+func CreateRecurringExpense(r *RecurringExpense) (int64, error) {
+	result, err := database.DB.Exec(`INSERT INTO recurring_expenses
+		(user_id, amount, currency, category_id, description, payee, rrule, start_date, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.UserID, r.Amount, r.Amount.Currency(), r.CategoryID, r.Description, r.Payee, r.RRule, r.StartDate, r.Active)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// Get a recurring expense by ID, scoped to its owner
+func GetRecurringExpenseByID(userID, id int) (*RecurringExpense, error) {
+	row := database.DB.QueryRow("SELECT "+recurringExpenseColumns+" FROM recurring_expenses WHERE id = ? AND user_id = ?", id, userID)
+	r, err := scanRecurringExpense(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// List all recurring expenses of a user
+func ListRecurringExpenses(userID int) ([]*RecurringExpense, error) {
+	return queryRecurringExpenses("SELECT "+recurringExpenseColumns+" FROM recurring_expenses WHERE user_id = ? ORDER BY id", userID)
+}
+
+// List the active recurring expenses of all users that have not been
+// materialized through the given day, leaving out those of deleted users
+func ListDueRecurringExpenses(through time.Time) ([]*RecurringExpense, error) {
+	return queryRecurringExpenses(`SELECT `+recurringExpenseColumns+` FROM recurring_expenses
+		WHERE active AND start_date <= ? AND (materialized_through IS NULL OR materialized_through < ?)
+			AND user_id NOT IN (SELECT id FROM users WHERE deleted_at IS NOT NULL)
+		ORDER BY id`, through, through)
+}
+
+// Update an existing recurring expense
+func UpdateRecurringExpense(r *RecurringExpense) error {
+	_, err := database.DB.Exec(`UPDATE recurring_expenses
+		SET amount = ?, currency = ?, category_id = ?, description = ?, payee = ?, rrule = ?, start_date = ?, active = ?
+		WHERE id = ? AND user_id = ?`,
+		r.Amount, r.Amount.Currency(), r.CategoryID, r.Description, r.Payee, r.RRule, r.StartDate, r.Active, r.ID, r.UserID)
+	return err
+}
+
+// Delete a recurring expense, returning false when no row belongs to the user.
+// Expenses already materialized from it are kept.
+func DeleteRecurringExpense(userID, id int) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM recurring_expenses WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// MaterializeOccurrences inserts the expenses of a recurring expense and moves
+// its materialized_through mark in one transaction. Occurrences that already
+// have an expense are left untouched, so running it twice is harmless.
+func MaterializeOccurrences(r *RecurringExpense, expenses []*Expense, through time.Time) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, e := range expenses {
+		if _, err := tx.Exec(`INSERT INTO expenses
+			(user_id, amount, currency, date, description, category_id, payee, recurring_expense_id, occurrence_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE id = id`,
+			e.UserID, e.Amount, e.Amount.Currency(), e.Date, e.Description, e.CategoryID, e.Payee,
+			e.RecurringExpenseID, e.OccurrenceDate); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE recurring_expenses SET materialized_through = ?
+		WHERE id = ? AND (materialized_through IS NULL OR materialized_through < ?)`,
+		through, r.ID, through); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get the expense materialized for one occurrence of a recurring expense
+func GetExpenseByOccurrence(userID, recurringExpenseID int, occurrenceDate time.Time) (*Expense, error) {
+	row := database.DB.QueryRow("SELECT "+expenseColumns+` FROM expenses
+		WHERE user_id = ? AND recurring_expense_id = ? AND occurrence_date = ?`,
+		userID, recurringExpenseID, occurrenceDate)
+	e, err := scanExpense(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// List the exceptions of a recurring expense, keyed by occurrence date (YYYY-MM-DD)
+func ListOccurrenceExceptions(recurringExpenseID int) (map[string]*OccurrenceException, error) {
+	rows, err := database.DB.Query("SELECT "+occurrenceExceptionColumns+" FROM recurring_expense_exceptions WHERE recurring_expense_id = ?",
+		recurringExpenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exceptions := make(map[string]*OccurrenceException)
+	for rows.Next() {
+		e := &OccurrenceException{}
+		if err := rows.Scan(&e.ID, &e.RecurringExpenseID, &e.OccurrenceDate, &e.Skipped, &e.Amount, &e.Date,
+			&e.CategoryID, &e.Description, &e.Payee); err != nil {
+			return nil, err
+		}
+		exceptions[e.OccurrenceDate.Format("2006-01-02")] = e
+	}
+	return exceptions, rows.Err()
+}
+
+// Create or replace the exception for one occurrence
+func SaveOccurrenceException(e *OccurrenceException) error {
+	var amount, currency interface{}
+	if e.Amount != nil {
+		amount, currency = *e.Amount, e.Amount.Currency()
+	}
+
+	_, err := database.DB.Exec(`INSERT INTO recurring_expense_exceptions
+		(recurring_expense_id, occurrence_date, skipped, amount, currency, date, category_id, description, payee)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE skipped = VALUES(skipped), amount = VALUES(amount), currency = VALUES(currency),
+			date = VALUES(date), category_id = VALUES(category_id), description = VALUES(description), payee = VALUES(payee)`,
+		e.RecurringExpenseID, e.OccurrenceDate, e.Skipped, amount, currency, e.Date, e.CategoryID, e.Description, e.Payee)
+	return err
+}
+
+// Delete the exception for one occurrence, returning false when there was none
+func DeleteOccurrenceException(recurringExpenseID int, occurrenceDate time.Time) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM recurring_expense_exceptions WHERE recurring_expense_id = ? AND occurrence_date = ?",
+		recurringExpenseID, occurrenceDate)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+func queryRecurringExpenses(query string, args ...interface{}) ([]*RecurringExpense, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recurring := []*RecurringExpense{}
+	for rows.Next() {
+		r, err := scanRecurringExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		recurring = append(recurring, r)
+	}
+	return recurring, rows.Err()
+}
+
+func scanRecurringExpense(s rowScanner) (*RecurringExpense, error) {
+	r := &RecurringExpense{}
+	err := s.Scan(&r.ID, &r.UserID, &r.Amount, &r.CategoryID, &r.Description, &r.Payee, &r.RRule,
+		&r.StartDate, &r.MaterializedThrough, &r.Active, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
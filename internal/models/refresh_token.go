@@ -0,0 +1,108 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Outcomes of rotating a refresh token
+const (
+	// RotationDone: the token was replaced by the new one
+	RotationDone = "done"
+	// RotationReused: the token had already been replaced, so its whole family was revoked
+	RotationReused = "reused"
+	// RotationInvalid: the token is unknown, revoked or expired
+	RotationInvalid = "invalid"
+)
+
+// RefreshToken records an issued refresh token by its JWT ID, so that it can
+// be revoked. Tokens rotated from the same login share a FamilyID; ReplacedBy
+// points to the token a rotated one was exchanged for.
+type RefreshToken struct {
+	ID         string
+	UserID     int
+	FamilyID   string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *string
+	CreatedAt  time.Time
+}
+
+// Get a refresh token record by its JWT ID
+func GetRefreshToken(id string) (*RefreshToken, error) {
+	t, err := scanRefreshToken(database.DB.QueryRow("SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// RotateRefreshToken exchanges the token oldID for next, which joins its
+// family, and records the use on the family's session. Presenting a token
+// that was already exchanged means it was copied, so every token of its
+// family is revoked and its session ended instead.
+func RotateRefreshToken(oldID string, next *RefreshToken, userAgent, ip string) (string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	old, err := scanRefreshToken(tx.QueryRow("SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE id = ? FOR UPDATE", oldID))
+	if err == sql.ErrNoRows {
+		return RotationInvalid, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	if old.ReplacedBy != nil {
+		if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+			now, old.FamilyID); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec("UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+			now, old.FamilyID); err != nil {
+			return "", err
+		}
+		return RotationReused, tx.Commit()
+	}
+	if old.RevokedAt != nil || !now.Before(old.ExpiresAt) {
+		return RotationInvalid, nil
+	}
+
+	next.UserID = old.UserID
+	next.FamilyID = old.FamilyID
+	if err := insertRefreshToken(tx, next); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ?",
+		now, next.ID, old.ID); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("UPDATE sessions SET last_used_at = ?, expires_at = ?, user_agent = ?, ip = ? WHERE id = ?",
+		now, next.ExpiresAt, userAgent, ip, old.FamilyID); err != nil {
+		return "", err
+	}
+	return RotationDone, tx.Commit()
+}
+
+const refreshTokenColumns = "id, user_id, family_id, expires_at, revoked_at, replaced_by, created_at"
+
+func insertRefreshToken(db execer, t *RefreshToken) error {
+	_, err := db.Exec("INSERT INTO refresh_tokens (id, user_id, family_id, expires_at) VALUES (?, ?, ?, ?)",
+		t.ID, t.UserID, t.FamilyID, t.ExpiresAt)
+	return err
+}
+
+func scanRefreshToken(s rowScanner) (*RefreshToken, error) {
+	t := &RefreshToken{}
+	err := s.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
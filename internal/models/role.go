@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// ListUserRoles returns the roles of a user, sorted
+func ListUserRoles(userID int) ([]string, error) {
+	rows, err := database.DB.Query("SELECT role FROM user_roles WHERE user_id = ? ORDER BY role", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GrantRole gives a user a role, reporting false when they already held it.
+// grantedBy is the staff user granting it, or nil from the command line.
+func GrantRole(userID int, role string, grantedBy *int) (bool, error) {
+	result, err := database.DB.Exec("INSERT IGNORE INTO user_roles (user_id, role, granted_by) VALUES (?, ?, ?)",
+		userID, role, grantedBy)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// RevokeRole takes a role from a user, reporting false when they did not hold it
+func RevokeRole(userID int, role string) (bool, error) {
+	result, err := database.DB.Exec("DELETE FROM user_roles WHERE user_id = ? AND role = ?", userID, role)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// Session is one login on one device. Its ID is the family of the refresh
+// tokens rotated from that login, and access tokens carry it as "sid".
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// ImpersonatorID is the staff user acting as the user in an impersonation session
+	ImpersonatorID *int `json:"impersonator_id"`
+	// Current is set for the session of the request listing the sessions
+	Current bool `json:"current"`
+}
+
+const sessionColumns = "id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at, impersonator_id"
+
+// CreateSession creates a session together with its first refresh token
+func CreateSession(s *Session, token *RefreshToken) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO sessions (id, user_id, user_agent, ip, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, s.UserAgent, s.IP, s.LastUsedAt, s.ExpiresAt); err != nil {
+		return err
+	}
+	if err := insertRefreshToken(tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateImpersonationSession creates a session of s.ImpersonatorID acting as
+// the user, which has no refresh token
+func CreateImpersonationSession(s *Session) error {
+	_, err := database.DB.Exec(`INSERT INTO sessions (id, user_id, user_agent, ip, last_used_at, expires_at, impersonator_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, s.UserAgent, s.IP, s.LastUsedAt, s.ExpiresAt, s.ImpersonatorID)
+	return err
+}
+
+// Get a session by ID, scoped to its owner
+func GetSession(userID int, id string) (*Session, error) {
+	row := database.DB.QueryRow("SELECT "+sessionColumns+" FROM sessions WHERE id = ? AND user_id = ?", id, userID)
+	s, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// List the sessions of a user that are neither revoked nor expired, most recently used first
+func ListActiveSessions(userID int) ([]*Session, error) {
+	rows, err := database.DB.Query("SELECT "+sessionColumns+` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC, created_at DESC`, userID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// List every session of a user, including revoked and expired ones, newest first
+func ListSessions(userID int) ([]*Session, error) {
+	rows, err := database.DB.Query("SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// IsSessionActive reports whether a session exists and is neither revoked nor expired
+func IsSessionActive(id string) (bool, error) {
+	var active bool
+	err := database.DB.QueryRow("SELECT revoked_at IS NULL AND expires_at > ? FROM sessions WHERE id = ?",
+		time.Now().UTC(), id).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return active, err
+}
+
+// RevokeSession ends an active session of a user and revokes its refresh
+// tokens, returning false when the user has no such active session
+func RevokeSession(userID int, id string) (bool, error) {
+	revoked, err := revokeSessions(userID, "id = ?", id)
+	return len(revoked) > 0, err
+}
+
+// RevokeOtherSessions ends every active session of a user except keepID and
+// returns the IDs of the sessions it ended
+func RevokeOtherSessions(userID int, keepID string) ([]string, error) {
+	return revokeSessions(userID, "id <> ?", keepID)
+}
+
+// RevokeAllSessions ends every active session of a user and returns their IDs
+func RevokeAllSessions(userID int) ([]string, error) {
+	return revokeSessions(userID, "TRUE")
+}
+
+// revokeSessions ends the active sessions of a user matching condition in one transaction
+func revokeSessions(userID int, condition string, args ...interface{}) ([]string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := revokeSessionsTx(tx, userID, condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+// revokeSessionsTx ends the active sessions of a user matching condition,
+// together with their refresh tokens, and returns their IDs
+func revokeSessionsTx(tx *sql.Tx, userID int, condition string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query("SELECT id FROM sessions WHERE user_id = ? AND revoked_at IS NULL AND "+condition+" FOR UPDATE",
+		append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	now := time.Now().UTC()
+	placeholders := "?" + strings.Repeat(", ?", len(ids)-1)
+	idArgs := []interface{}{now}
+	for _, id := range ids {
+		idArgs = append(idArgs, id)
+	}
+	if _, err := tx.Exec("UPDATE sessions SET revoked_at = ? WHERE id IN ("+placeholders+")", idArgs...); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE revoked_at IS NULL AND family_id IN ("+
+		placeholders+")", idArgs...); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func scanSession(s rowScanner) (*Session, error) {
+	session := &Session{}
+	err := s.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt,
+		&session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt, &session.ImpersonatorID)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
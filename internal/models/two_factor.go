@@ -0,0 +1,124 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/database"
+)
+
+// TOTP is the authenticator state of a user. A secret without EnabledAt is
+// an enrolment that was not confirmed yet.
+type TOTP struct {
+	Secret    *string
+	EnabledAt *time.Time
+	// LastStep is the time step of the last code used, which cannot be used again
+	LastStep *int64
+}
+
+// GetTOTP returns the authenticator state of a user, or nil when there is no such user
+func GetTOTP(userID int) (*TOTP, error) {
+	t := &TOTP{}
+	err := database.DB.QueryRow("SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = ?", userID).
+		Scan(&t.Secret, &t.EnabledAt, &t.LastStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+// SetPendingTOTPSecret starts an enrolment, replacing any unconfirmed one.
+// It returns false when two-factor authentication is already enabled.
+func SetPendingTOTPSecret(userID int, secret string) (bool, error) {
+	result, err := database.DB.Exec("UPDATE users SET totp_secret = ?, totp_last_step = NULL WHERE id = ? AND totp_enabled_at IS NULL",
+		secret, userID)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// EnableTOTP confirms the pending enrolment of a user with the code of step
+// and replaces the user's recovery codes with codeHashes
+func EnableTOTP(userID int, step int64, codeHashes []string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET totp_enabled_at = ?, totp_last_step = ? WHERE id = ?",
+		time.Now().UTC(), step, userID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP turns two-factor authentication off and deletes the recovery codes
+func DisableTOTP(userID int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?",
+		userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records that the code of step was used, returning false when
+// a code of that or a later step was used before
+func UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := database.DB.Exec(`UPDATE users SET totp_last_step = ?
+		WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// UseRecoveryCode uses up an unused recovery code, returning false when the
+// user has no such code
+func UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := database.DB.Exec("UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// ReplaceRecoveryCodes replaces all recovery codes of a user
+func ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
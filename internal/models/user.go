@@ -2,27 +2,48 @@ package models
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/henok-tesfu/expense-manager/internal/database"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username" validate:"required,min=3"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"-" validate:"required,min=6"`
+	Password string `json:"-" validate:"required"`
+	// HomeCurrency is the ISO 4217 code reports are converted to by default
+	HomeCurrency string `json:"home_currency"`
+	// Locale is a BCP 47 language tag such as "en-US"
+	Locale string `json:"locale"`
+	// TimeZone is an IANA time zone name such as "Europe/Berlin"
+	TimeZone string `json:"time_zone"`
+	// EmailVerifiedAt is nil until the user follows the link of the verification email
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// TwoFactorEnabled is set once the user confirmed a TOTP authenticator
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// DisabledAt is set while staff have disabled the account
+	DisabledAt *time.Time `json:"disabled_at"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
-// Register a new user
-func RegisterUser(username, email, password string) (int64, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return 0, err
-	}
+// UserFilter narrows down the users staff list
+type UserFilter struct {
+	// Query matches part of the username or email
+	Query string
+	// Role matches users holding the role
+	Role string
+	// Disabled matches disabled users when true and enabled ones when false
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
 
-	result, err := database.DB.Exec("INSERT INTO users (username, email, password) VALUES (?, ?, ?)",
-		username, email, string(hashedPassword))
+// Register a new user with an already hashed password
+func RegisterUser(username, email, passwordHash, homeCurrency string) (int64, error) {
+	result, err := database.DB.Exec("INSERT INTO users (username, email, password, home_currency) VALUES (?, ?, ?, ?)",
+		username, email, passwordHash, homeCurrency)
 	if err != nil {
 		return 0, err
 	}
@@ -30,24 +51,272 @@ func RegisterUser(username, email, password string) (int64, error) {
 	return result.LastInsertId()
 }
 
-// Get user by email
+const userColumns = "id, username, email, password, home_currency, locale, time_zone, email_verified_at, totp_enabled_at IS NOT NULL, disabled_at, created_at"
+
+// Get user by email; deleted users are not found
 func GetUserByEmail(email string) (*User, error) {
-	user := &User{}
-	err := database.DB.QueryRow("SELECT id, username, email, password FROM users WHERE email = ?", email).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	row := database.DB.QueryRow("SELECT "+userColumns+" FROM users WHERE email = ? AND deleted_at IS NULL", email)
+	user, err := scanUser(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return user, err
 }
 
-// Get user by ID
+// Get user by ID; deleted users are not found
 func GetUserByID(id int) (*User, error) {
-	user := &User{}
-	err := database.DB.QueryRow("SELECT id, username, email, password FROM users WHERE id = ?", id).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	row := database.DB.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id)
+	user, err := scanUser(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	return user, err
 }
+
+// EmailExists reports whether any user has the email, including deleted
+// users whose data has not been purged yet
+func EmailExists(email string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE email = ?)", email).Scan(&exists)
+	return exists, err
+}
+
+// UpdateProfile saves the username, home currency, locale and time zone of a user
+func UpdateProfile(u *User) error {
+	_, err := database.DB.Exec("UPDATE users SET username = ?, home_currency = ?, locale = ?, time_zone = ? WHERE id = ?",
+		u.Username, u.HomeCurrency, u.Locale, u.TimeZone, u.ID)
+	return err
+}
+
+// ChangeEmail gives a user a new, unverified email. Open verification and
+// password reset links, which were sent to the old email, stop working.
+func ChangeEmail(id int, email string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	if _, err := tx.Exec("UPDATE users SET email = ?, email_verified_at = NULL WHERE id = ?", email, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE email_verification_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		now, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		now, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ChangePassword sets the password hash of a user and ends every session
+// except keepSessionID, returning the IDs of the sessions it ended
+func ChangePassword(id int, passwordHash, keepSessionID string) ([]string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET password = ? WHERE id = ?", passwordHash, id); err != nil {
+		return nil, err
+	}
+	sessionIDs, err := revokeSessionsTx(tx, id, "id <> ?", keepSessionID)
+	if err != nil {
+		return nil, err
+	}
+	return sessionIDs, tx.Commit()
+}
+
+// SoftDeleteUser marks a user as deleted, which hides them from every
+// lookup, and in one transaction ends their sessions, returning their IDs,
+// revokes their refresh tokens, and deletes their personal access tokens and
+// open links. Their data stays until PurgeUser. ok is false when there is
+// no such user or they were already deleted.
+func SoftDeleteUser(id int) (sessionIDs []string, ok bool, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec("UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", now, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok, err := rowsFound(result); err != nil || !ok {
+		return nil, false, err
+	}
+
+	if sessionIDs, err = revokeSessionsTx(tx, id, "TRUE"); err != nil {
+		return nil, false, err
+	}
+	for _, statement := range []string{
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		"UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		"UPDATE email_verification_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+	} {
+		if _, err := tx.Exec(statement, now, id); err != nil {
+			return nil, false, err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM personal_access_tokens WHERE user_id = ?", id); err != nil {
+		return nil, false, err
+	}
+	return sessionIDs, true, tx.Commit()
+}
+
+// ListUsers returns the users matching the filter, newest first; deleted users are left out
+func ListUsers(filter UserFilter) ([]*User, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		conditions = append(conditions, "(username LIKE ? OR email LIKE ?)")
+		args = append(args, pattern, pattern)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "id IN (SELECT user_id FROM user_roles WHERE role = ?)")
+		args = append(args, filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE " + strings.Join(conditions, " AND ") + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// DisableUser marks a user as disabled and ends their sessions, returning
+// their IDs, and revokes their refresh tokens in one transaction. Their
+// personal access tokens stop working while the account is disabled. ok is
+// false when the user was already disabled.
+func DisableUser(id int) (sessionIDs []string, ok bool, err error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec("UPDATE users SET disabled_at = ? WHERE id = ? AND disabled_at IS NULL", now, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok, err := rowsFound(result); err != nil || !ok {
+		return nil, false, err
+	}
+
+	if sessionIDs, err = revokeSessionsTx(tx, id, "TRUE"); err != nil {
+		return nil, false, err
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		now, id); err != nil {
+		return nil, false, err
+	}
+	return sessionIDs, true, tx.Commit()
+}
+
+// EnableUser lets a disabled user log in again, reporting false when they were not disabled
+func EnableUser(id int) (bool, error) {
+	result, err := database.DB.Exec("UPDATE users SET disabled_at = NULL WHERE id = ? AND disabled_at IS NOT NULL", id)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// ListUsersDeletedBefore returns the IDs of users deleted before t, oldest first
+func ListUsersDeletedBefore(t time.Time, limit int) ([]int, error) {
+	rows, err := database.DB.Query("SELECT id FROM users WHERE deleted_at < ? ORDER BY deleted_at LIMIT ?", t, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PurgeUser permanently deletes a deleted user. Their expenses, attachments
+// and every other row of theirs are deleted with them by the foreign keys.
+func PurgeUser(id int) error {
+	_, err := database.DB.Exec("DELETE FROM users WHERE id = ? AND deleted_at IS NOT NULL", id)
+	return err
+}
+
+func scanUser(s rowScanner) (*User, error) {
+	user := &User{}
+	err := s.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.HomeCurrency, &user.Locale,
+		&user.TimeZone, &user.EmailVerifiedAt, &user.TwoFactorEnabled, &user.DisabledAt, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update the currency reports are converted to by default
+func UpdateHomeCurrency(id int, currency string) error {
+	_, err := database.DB.Exec("UPDATE users SET home_currency = ? WHERE id = ?", currency, id)
+	return err
+}
+
+// IsEmailVerified reports whether a user has verified their email
+func IsEmailVerified(id int) (bool, error) {
+	var verified bool
+	err := database.DB.QueryRow("SELECT email_verified_at IS NOT NULL FROM users WHERE id = ?", id).Scan(&verified)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return verified, err
+}
+
+// ReplacePasswordHash changes the stored hash of a user's password from
+// oldHash to newHash. It does nothing when the password was changed in the
+// meantime, and reports whether it replaced the hash.
+func ReplacePasswordHash(userID int, oldHash, newHash string) (bool, error) {
+	result, err := database.DB.Exec("UPDATE users SET password = ? WHERE id = ? AND password = ?", newHash, userID, oldHash)
+	if err != nil {
+		return false, err
+	}
+	return rowsFound(result)
+}
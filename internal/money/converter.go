@@ -0,0 +1,127 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrNoRate is returned when no exchange rate is known for a currency pair
+var ErrNoRate = errors.New("no exchange rate available")
+
+// Converter converts amounts into another currency using the rate valid on a given date
+type Converter interface {
+	Convert(amount Amount, to string, on time.Time) (Amount, error)
+}
+
+// SameCurrencyConverter only "converts" amounts that are already in the target
+// currency and reports ErrNoRate for everything else
+type SameCurrencyConverter struct{}
+
+// Convert implements Converter
+func (SameCurrencyConverter) Convert(amount Amount, to string, on time.Time) (Amount, error) {
+	if amount.Currency() != to {
+		return Amount{}, ErrNoRate
+	}
+	return amount, nil
+}
+
+// RateSource looks up how many units of quote one unit of base was worth on a
+// date. Implementations return ErrNoRate when they know no such rate.
+type RateSource interface {
+	Rate(base, quote string, on time.Time) (*big.Rat, error)
+}
+
+// RateConverter converts amounts with the rates of a RateSource. A pair
+// without a stored rate is tried in the inverse direction and then crossed
+// through Pivot, so a table holding only EUR-based rates (as published by
+// the ECB) can still convert USD to GBP.
+type RateConverter struct {
+	Rates RateSource
+	Pivot string
+}
+
+// Convert implements Converter
+func (c RateConverter) Convert(amount Amount, to string, on time.Time) (Amount, error) {
+	if amount.Currency() == to {
+		return amount, nil
+	}
+	if !IsValidCurrency(to) {
+		return Amount{}, ErrUnknownCurrency
+	}
+
+	rate, err := c.pairRate(amount.Currency(), to, on)
+	if errors.Is(err, ErrNoRate) && c.Pivot != "" && amount.Currency() != c.Pivot && to != c.Pivot {
+		var toPivot, fromPivot *big.Rat
+		if toPivot, err = c.pairRate(amount.Currency(), c.Pivot, on); err == nil {
+			if fromPivot, err = c.pairRate(c.Pivot, to, on); err == nil {
+				rate = new(big.Rat).Mul(toPivot, fromPivot)
+			}
+		}
+	}
+	if err != nil {
+		return Amount{}, err
+	}
+
+	return amount.Exchange(rate, to)
+}
+
+// pairRate looks up the rate from base to quote, falling back to the inverse pair
+func (c RateConverter) pairRate(base, quote string, on time.Time) (*big.Rat, error) {
+	rate, err := c.Rates.Rate(base, quote, on)
+	if !errors.Is(err, ErrNoRate) {
+		return rate, err
+	}
+
+	inverse, err := c.Rates.Rate(quote, base, on)
+	if err != nil {
+		return nil, err
+	}
+	if inverse.Sign() <= 0 {
+		return nil, ErrNoRate
+	}
+	return new(big.Rat).Inv(inverse), nil
+}
+
+// Exchange multiplies the amount by rate and expresses the result in the
+// currency to, rounding half away from zero to its minor unit
+func (a Amount) Exchange(rate *big.Rat, to string) (Amount, error) {
+	fromDigits, err := MinorUnits(a.currency)
+	if err != nil {
+		return Amount{}, err
+	}
+	toDigits, err := MinorUnits(to)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	value := new(big.Rat).SetInt64(a.minor)
+	value.Mul(value, rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toDigits-fromDigits))), nil))
+	if toDigits >= fromDigits {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	numerator := new(big.Int).Abs(value.Num())
+	quotient, remainder := new(big.Int).QuoRem(numerator, value.Denom(), new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if value.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	if !quotient.IsInt64() {
+		return Amount{}, ErrOverflow
+	}
+
+	return Amount{minor: quotient.Int64(), currency: to}, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,295 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency code")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooPrecise       = errors.New("amount has more decimal places than the currency allows")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrInvalidRatios    = errors.New("ratios must be non-negative and not all zero")
+)
+
+// Amount is an exact monetary value stored as an integer number of minor units
+// (e.g. cents) together with its ISO 4217 currency code.
+//
+// Amounts are written as "<decimal> <currency>", e.g. "12.34 USD", both in
+// JSON and in String.
+type Amount struct {
+	minor    int64
+	currency string
+}
+
+// New creates an amount from a number of minor units
+func New(minor int64, currency string) (Amount, error) {
+	if !IsValidCurrency(currency) {
+		return Amount{}, ErrUnknownCurrency
+	}
+	return Amount{minor: minor, currency: currency}, nil
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(currency string) (Amount, error) {
+	return New(0, currency)
+}
+
+// Parse parses an amount written as "<decimal> <currency>", e.g. "-12.34 USD"
+func Parse(s string) (Amount, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Amount{}, ErrInvalidAmount
+	}
+	return ParseDecimal(fields[0], fields[1])
+}
+
+// ParseDecimal parses a plain decimal such as "12.34" in the given currency.
+// Trailing zeros beyond the currency's minor units are accepted, so values
+// read from wider DECIMAL columns ("12.3400") parse cleanly.
+func ParseDecimal(value, currency string) (Amount, error) {
+	digits, err := MinorUnits(currency)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "-"):
+		negative = true
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Amount{}, ErrInvalidAmount
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Amount{}, ErrInvalidAmount
+	}
+	if len(fraction) > digits {
+		if strings.Trim(fraction[digits:], "0") != "" {
+			return Amount{}, ErrTooPrecise
+		}
+		fraction = fraction[:digits]
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	minor := new(big.Int)
+	if _, ok := minor.SetString("0"+whole+fraction, 10); !ok {
+		return Amount{}, ErrInvalidAmount
+	}
+	if negative {
+		minor.Neg(minor)
+	}
+	if !minor.IsInt64() {
+		return Amount{}, ErrOverflow
+	}
+
+	return Amount{minor: minor.Int64(), currency: currency}, nil
+}
+
+// Minor returns the amount in minor units
+func (a Amount) Minor() int64 {
+	return a.minor
+}
+
+// Currency returns the ISO 4217 currency code
+func (a Amount) Currency() string {
+	return a.currency
+}
+
+// IsZero reports whether the amount is zero
+func (a Amount) IsZero() bool {
+	return a.minor == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (a Amount) IsPositive() bool {
+	return a.minor > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (a Amount) IsNegative() bool {
+	return a.minor < 0
+}
+
+// Neg returns the amount with its sign flipped
+func (a Amount) Neg() Amount {
+	return Amount{minor: -a.minor, currency: a.currency}
+}
+
+// Add returns a + b; both amounts must share a currency
+func (a Amount) Add(b Amount) (Amount, error) {
+	if a.currency != b.currency {
+		return Amount{}, ErrCurrencyMismatch
+	}
+	sum := a.minor + b.minor
+	if (b.minor > 0 && sum < a.minor) || (b.minor < 0 && sum > a.minor) {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{minor: sum, currency: a.currency}, nil
+}
+
+// Sub returns a - b; both amounts must share a currency
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b.minor == math.MinInt64 {
+		return Amount{}, ErrOverflow
+	}
+	return a.Add(b.Neg())
+}
+
+// Cmp compares two amounts of the same currency, returning -1, 0 or +1
+func (a Amount) Cmp(b Amount) (int, error) {
+	if a.currency != b.currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case a.minor < b.minor:
+		return -1, nil
+	case a.minor > b.minor:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Allocate splits the amount proportionally to ratios without losing minor
+// units: the remainder left after rounding down is handed out one minor unit
+// at a time, starting with the first share.
+func (a Amount) Allocate(ratios ...int) ([]Amount, error) {
+	total := int64(0)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, ErrInvalidRatios
+		}
+		total += int64(ratio)
+	}
+	if total == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	shares := make([]Amount, len(ratios))
+	remainder := a.minor
+	for i, ratio := range ratios {
+		share := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(int64(ratio)))
+		share.Quo(share, big.NewInt(total))
+		shares[i] = Amount{minor: share.Int64(), currency: a.currency}
+		remainder -= shares[i].minor
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i++ {
+		if ratios[i%len(ratios)] == 0 {
+			continue
+		}
+		shares[i%len(ratios)].minor += step
+		remainder -= step
+	}
+
+	return shares, nil
+}
+
+// Decimal formats the amount as a plain decimal without currency, e.g. "-12.34"
+func (a Amount) Decimal() string {
+	digits := currencies[a.currency]
+
+	sign := ""
+	value := new(big.Int).SetInt64(a.minor)
+	if value.Sign() < 0 {
+		sign = "-"
+		value.Neg(value)
+	}
+
+	s := value.String()
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// String formats the amount as "<decimal> <currency>"
+func (a Amount) String() string {
+	return a.Decimal() + " " + a.currency
+}
+
+// MarshalJSON encodes the amount as a JSON string, e.g. "12.34 USD"
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes an amount from a JSON string such as "12.34 USD"
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("money: amount must be a string: %w", err)
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner. It accepts either "<decimal> <currency>"
+// (e.g. from CONCAT(amount, ' ', currency)) or a bare DECIMAL value, in which
+// case the receiver must already carry its currency.
+func (a *Amount) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", src)
+	}
+
+	var (
+		parsed Amount
+		err    error
+	)
+	if strings.Contains(strings.TrimSpace(s), " ") {
+		parsed, err = Parse(s)
+	} else {
+		parsed, err = ParseDecimal(s, a.currency)
+	}
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, writing the amount as a DECIMAL string.
+// The currency must be stored in its own column.
+func (a Amount) Value() (driver.Value, error) {
+	return a.Decimal(), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
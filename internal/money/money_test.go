@@ -0,0 +1,306 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) Amount {
+	t.Helper()
+	a, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return a
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		minor    int64
+		err      error
+	}{
+		{"12.34", "USD", 1234, nil},
+		{"-12.34", "USD", -1234, nil},
+		{"+0.5", "USD", 50, nil},
+		{".5", "USD", 50, nil},
+		{"7.", "USD", 700, nil},
+		{"12.3400", "USD", 1234, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1.000", "JPY", 1, nil},
+		{"1.234", "KWD", 1234, nil},
+		{"12.345", "USD", 0, ErrTooPrecise},
+		{"1.5", "JPY", 0, ErrTooPrecise},
+		{"", "USD", 0, ErrInvalidAmount},
+		{".", "USD", 0, ErrInvalidAmount},
+		{"-", "USD", 0, ErrInvalidAmount},
+		{"1,000.00", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"--1", "USD", 0, ErrInvalidAmount},
+		{"92233720368547758.07", "USD", math.MaxInt64, nil},
+		{"92233720368547758.08", "USD", 0, ErrOverflow},
+		{"1.00", "XYZ", 0, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.value, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseDecimal(%q, %q): err = %v, want %v", tt.value, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && (got.Minor() != tt.minor || got.Currency() != tt.currency) {
+			t.Errorf("ParseDecimal(%q, %q) = %d %s, want %d %s", tt.value, tt.currency,
+				got.Minor(), got.Currency(), tt.minor, tt.currency)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{"12.34 USD", "12.34 USD", nil},
+		{"  -0.05   EUR ", "-0.05 EUR", nil},
+		{"1000 JPY", "1000 JPY", nil},
+		{"12.34", "", ErrInvalidAmount},
+		{"12.34 USD extra", "", ErrInvalidAmount},
+		{"12.34 usd", "", ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q): err = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && got.String() != tt.out {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got.String(), tt.out)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{0, "USD", "0.00"},
+		{5, "USD", "0.05"},
+		{-5, "USD", "-0.05"},
+		{123456, "USD", "1234.56"},
+		{-1500, "JPY", "-1500"},
+		{1, "KWD", "0.001"},
+		{1, "CLF", "0.0001"},
+		{math.MinInt64, "USD", "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		a, err := New(tt.minor, tt.currency)
+		if err != nil {
+			t.Fatalf("New(%d, %q): %v", tt.minor, tt.currency, err)
+		}
+		if got := a.Decimal(); got != tt.want {
+			t.Errorf("New(%d, %q).Decimal() = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		sum, dif string
+		err      error
+	}{
+		{"1.10 USD", "2.25 USD", "3.35 USD", "-1.15 USD", nil},
+		{"-1.00 EUR", "1.00 EUR", "0.00 EUR", "-2.00 EUR", nil},
+		{"1.00 USD", "1.00 EUR", "", "", ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+		sum, err := a.Add(b)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s + %s: err = %v, want %v", tt.a, tt.b, err, tt.err)
+			continue
+		}
+		dif, err := a.Sub(b)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s - %s: err = %v, want %v", tt.a, tt.b, err, tt.err)
+			continue
+		}
+		if tt.err == nil && (sum.String() != tt.sum || dif.String() != tt.dif) {
+			t.Errorf("%s +/- %s = %s, %s; want %s, %s", tt.a, tt.b, sum, dif, tt.sum, tt.dif)
+		}
+	}
+}
+
+func TestAddSubOverflow(t *testing.T) {
+	max, _ := New(math.MaxInt64, "USD")
+	min, _ := New(math.MinInt64, "USD")
+	one, _ := New(1, "USD")
+
+	if _, err := max.Add(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("max + 1: err = %v, want ErrOverflow", err)
+	}
+	if _, err := min.Sub(one); !errors.Is(err, ErrOverflow) {
+		t.Errorf("min - 1: err = %v, want ErrOverflow", err)
+	}
+	if _, err := one.Sub(min); !errors.Is(err, ErrOverflow) {
+		t.Errorf("1 - min: err = %v, want ErrOverflow", err)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount string
+		ratios []int
+		want   []string
+		err    error
+	}{
+		{"100.00 USD", []int{1, 1, 1}, []string{"33.34 USD", "33.33 USD", "33.33 USD"}, nil},
+		{"0.05 USD", []int{3, 7}, []string{"0.02 USD", "0.03 USD"}, nil},
+		{"-10.00 USD", []int{1, 2}, []string{"-3.34 USD", "-6.66 USD"}, nil},
+		{"0.02 USD", []int{0, 1, 1}, []string{"0.00 USD", "0.01 USD", "0.01 USD"}, nil},
+		{"0.01 USD", []int{0, 1}, []string{"0.00 USD", "0.01 USD"}, nil},
+		{"1000 JPY", []int{1, 1, 1}, []string{"334 JPY", "333 JPY", "333 JPY"}, nil},
+		{"1.00 USD", []int{0, 0}, nil, ErrInvalidRatios},
+		{"1.00 USD", []int{1, -1}, nil, ErrInvalidRatios},
+		{"1.00 USD", nil, nil, ErrInvalidRatios},
+	}
+	for _, tt := range tests {
+		shares, err := mustParse(t, tt.amount).Allocate(tt.ratios...)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Allocate(%s, %v): err = %v, want %v", tt.amount, tt.ratios, err, tt.err)
+			continue
+		}
+		if len(shares) != len(tt.want) {
+			t.Errorf("Allocate(%s, %v) = %v, want %v", tt.amount, tt.ratios, shares, tt.want)
+			continue
+		}
+		for i, share := range shares {
+			if share.String() != tt.want[i] {
+				t.Errorf("Allocate(%s, %v) = %v, want %v", tt.amount, tt.ratios, shares, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Amount Amount `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"-12.30 EUR"}`), &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"amount":"-12.30 EUR"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	for _, in := range []string{`{"amount":12.3}`, `{"amount":"12.3"}`, `{"amount":"12.345 EUR"}`} {
+		if err := json.Unmarshal([]byte(in), &v); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	var a Amount
+	if err := a.Scan([]byte("12.34 USD")); err != nil || a.String() != "12.34 USD" {
+		t.Errorf("Scan with currency = %v, %v", a, err)
+	}
+
+	// A bare DECIMAL keeps the currency the amount already has
+	b, _ := Zero("JPY")
+	if err := b.Scan("1500.0000"); err != nil || b.String() != "1500 JPY" {
+		t.Errorf("Scan of bare decimal = %v, %v", b, err)
+	}
+
+	if err := b.Scan(1.5); err == nil {
+		t.Error("Scan(float64) succeeded, want an error")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		amount string
+		rate   string
+		to     string
+		want   string
+	}{
+		{"10.00 EUR", "1.0850", "USD", "10.85 USD"},
+		{"1.00 USD", "0.005", "EUR", "0.01 EUR"},
+		{"1.00 USD", "0.0049", "EUR", "0.00 EUR"},
+		{"-1.00 USD", "0.005", "EUR", "-0.01 EUR"},
+		{"10.00 EUR", "161.23", "JPY", "1612 JPY"},
+		{"1612 JPY", "0.0062", "EUR", "9.99 EUR"},
+		{"1.000 KWD", "3.25", "USD", "3.25 USD"},
+	}
+	for _, tt := range tests {
+		rate, ok := new(big.Rat).SetString(tt.rate)
+		if !ok {
+			t.Fatalf("bad rate %q", tt.rate)
+		}
+		got, err := mustParse(t, tt.amount).Exchange(rate, tt.to)
+		if err != nil {
+			t.Errorf("%s at %s to %s: %v", tt.amount, tt.rate, tt.to, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s at %s to %s = %s, want %s", tt.amount, tt.rate, tt.to, got, tt.want)
+		}
+	}
+}
+
+// eurRates knows only EUR-based rates, like the ECB reference rates
+type eurRates map[string]string
+
+func (r eurRates) Rate(base, quote string, on time.Time) (*big.Rat, error) {
+	if base != "EUR" {
+		return nil, ErrNoRate
+	}
+	value, ok := r[quote]
+	if !ok {
+		return nil, ErrNoRate
+	}
+	rate, _ := new(big.Rat).SetString(value)
+	return rate, nil
+}
+
+func TestRateConverter(t *testing.T) {
+	converter := RateConverter{Rates: eurRates{"USD": "1.25", "GBP": "0.80"}, Pivot: "EUR"}
+	on := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		amount string
+		to     string
+		want   string
+		err    error
+	}{
+		{"10.00 EUR", "EUR", "10.00 EUR", nil},
+		{"10.00 EUR", "USD", "12.50 USD", nil},
+		{"12.50 USD", "EUR", "10.00 EUR", nil},
+		{"12.50 USD", "GBP", "8.00 GBP", nil},
+		{"10.00 EUR", "CHF", "", ErrNoRate},
+		{"10.00 USD", "CHF", "", ErrNoRate},
+		{"10.00 EUR", "XYZ", "", ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := converter.Convert(mustParse(t, tt.amount), tt.to, on)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Convert(%s, %s): err = %v, want %v", tt.amount, tt.to, err, tt.err)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("Convert(%s, %s) = %s, want %s", tt.amount, tt.to, got, tt.want)
+		}
+	}
+}
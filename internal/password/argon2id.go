@@ -0,0 +1,118 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Params are the cost parameters of argon2id
+type Params struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the OWASP recommendation of 19 MiB, two iterations and one lane
+var DefaultParams = Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2id hashes passwords with argon2id and verifies argon2id and bcrypt hashes
+type Argon2id struct {
+	Params Params
+}
+
+// NewArgon2id creates an Argon2id hasher, rejecting parameters argon2id cannot use
+func NewArgon2id(params Params) (*Argon2id, error) {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 ||
+		params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, fmt.Errorf("invalid argon2id parameters m=%d,t=%d,p=%d", params.Memory, params.Iterations,
+			params.Parallelism)
+	}
+	return &Argon2id{Params: params}, nil
+}
+
+// Hash implements Hasher, returning a hash such as
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Params.Iterations, a.Params.Memory, a.Params.Parallelism,
+		a.Params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, a.Params.Memory,
+		a.Params.Iterations, a.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify implements Hasher
+func (a *Argon2id) Verify(password, hash string) (bool, error) {
+	if isBcrypt(hash) {
+		return verifyBcrypt(password, hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+	return subtle.ConstantTimeCompare(key, computed) == 1, nil
+}
+
+// NeedsRehash implements Hasher. Hashes other than argon2id and argon2id
+// hashes with other parameters need a rehash.
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	return err != nil || params != a.Params
+}
+
+// decodeArgon2id parses a PHC-formatted argon2id hash
+func decodeArgon2id(hash string) (Params, []byte, []byte, error) {
+	// The hash starts with "$", so the first part is empty
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, fmt.Errorf("%w: unsupported argon2 version %q", ErrUnknownHash, parts[2])
+	}
+
+	var params Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations,
+		&params.Parallelism); err != nil {
+		return Params{}, nil, nil, fmt.Errorf("%w: invalid argon2id parameters %q", ErrUnknownHash, parts[3])
+	}
+	// argon2 panics on parameters it cannot use, so a corrupt hash must not reach it
+	if params.Iterations < 1 || params.Parallelism < 1 || params.Memory < 8*uint32(params.Parallelism) {
+		return Params{}, nil, nil, fmt.Errorf("%w: invalid argon2id parameters %q", ErrUnknownHash, parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, fmt.Errorf("%w: invalid salt", ErrUnknownHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, fmt.Errorf("%w: invalid key", ErrUnknownHash)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
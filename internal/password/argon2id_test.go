@@ -0,0 +1,134 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; they are not meant for real use
+var testParams = Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestHasher(t *testing.T, params Params) *Argon2id {
+	t.Helper()
+	hasher, err := NewArgon2id(params)
+	if err != nil {
+		t.Fatalf("NewArgon2id: %v", err)
+	}
+	return hasher
+}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+	hasher := newTestHasher(t, testParams)
+
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash = %q, want the PHC argon2id format", hash)
+	}
+
+	if ok, err := hasher.Verify("correct horse battery staple", hash); !ok || err != nil {
+		t.Errorf("Verify(right password) = %v, %v; want true", ok, err)
+	}
+	if ok, err := hasher.Verify("Correct horse battery staple", hash); ok || err != nil {
+		t.Errorf("Verify(wrong password) = %v, %v; want false", ok, err)
+	}
+
+	other, _ := hasher.Hash("correct horse battery staple")
+	if other == hash {
+		t.Error("two hashes of one password are equal; the salt is not random")
+	}
+}
+
+func TestArgon2idVerifiesOtherParams(t *testing.T) {
+	old := newTestHasher(t, Params{Memory: 32, Iterations: 2, Parallelism: 2, SaltLength: 8, KeyLength: 16})
+	hash, err := old.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	hasher := newTestHasher(t, testParams)
+	if ok, err := hasher.Verify("secret", hash); !ok || err != nil {
+		t.Errorf("Verify of a hash with other parameters = %v, %v; want true", ok, err)
+	}
+	if !hasher.NeedsRehash(hash) {
+		t.Error("NeedsRehash of a hash with other parameters = false, want true")
+	}
+	current, _ := hasher.Hash("secret")
+	if hasher.NeedsRehash(current) {
+		t.Error("NeedsRehash of a current hash = true, want false")
+	}
+}
+
+func TestArgon2idVerifiesBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	hasher := newTestHasher(t, testParams)
+
+	if ok, err := hasher.Verify("secret", string(legacy)); !ok || err != nil {
+		t.Errorf("Verify of a bcrypt hash = %v, %v; want true", ok, err)
+	}
+	if ok, err := hasher.Verify("wrong", string(legacy)); ok || err != nil {
+		t.Errorf("Verify of a bcrypt hash with a wrong password = %v, %v; want false", ok, err)
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Error("NeedsRehash of a bcrypt hash = false, want true")
+	}
+}
+
+func TestDecodeArgon2id(t *testing.T) {
+	params, salt, key, err := decodeArgon2id("$argon2id$v=19$m=19456,t=2,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw")
+	if err != nil {
+		t.Fatalf("decodeArgon2id: %v", err)
+	}
+	want := Params{Memory: 19456, Iterations: 2, Parallelism: 1, SaltLength: 8, KeyLength: 16}
+	if params != want || string(salt) != "somesalt" || len(key) != 16 || key[15] != 15 {
+		t.Errorf("decodeArgon2id = %+v, %q, %v", params, salt, key)
+	}
+
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=19456,t=2,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=16$m=19456,t=2,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=19456,t=2$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=19456,t=0,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=19456,t=2,p=0$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=4,t=2,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=19456,t=2,p=1$not*base64$AAECAwQFBgcICQoLDA0ODw",
+		"$argon2id$v=19$m=19456,t=2,p=1$c29tZXNhbHQ$",
+		"$argon2id$v=19$m=19456,t=2,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw$extra",
+	} {
+		if _, _, _, err := decodeArgon2id(hash); !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("decodeArgon2id(%q): err = %v, want ErrUnknownHash", hash, err)
+		}
+	}
+}
+
+func TestArgon2idVerifyRejectsUnknownHashes(t *testing.T) {
+	hasher := newTestHasher(t, testParams)
+	// A hash with zero iterations must be rejected, not handed to argon2, which panics on it
+	if ok, err := hasher.Verify("secret", "$argon2id$v=19$m=64,t=0,p=1$c29tZXNhbHQ$AAECAwQFBgcICQoLDA0ODw"); ok || !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("Verify = %v, %v; want false, ErrUnknownHash", ok, err)
+	}
+}
+
+func TestNewArgon2idRejectsWeakParams(t *testing.T) {
+	for _, params := range []Params{
+		{Memory: 64, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 0, SaltLength: 16, KeyLength: 32},
+		{Memory: 8, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32},
+		{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 8},
+	} {
+		if _, err := NewArgon2id(params); err == nil {
+			t.Errorf("NewArgon2id(%+v) succeeded, want an error", params)
+		}
+	}
+}
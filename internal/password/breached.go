@@ -0,0 +1,75 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachedList tells whether a password is known from data breaches
+type BreachedList interface {
+	Contains(password string) (bool, error)
+}
+
+// RangeDir is a local copy of a k-anonymity list of breached password
+// hashes, in the layout of the Have I Been Pwned range API: a file per
+// five-character prefix of the upper-case hex SHA-1 hash, named after the
+// prefix with or without a ".txt" extension, holding "SUFFIX:COUNT" lines.
+// A missing file means that no breached password has that prefix.
+type RangeDir struct {
+	Dir string
+}
+
+// NewRangeDir creates a RangeDir reading the range files in dir
+func NewRangeDir(dir string) (*RangeDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password list %s is not a directory", dir)
+	}
+	return &RangeDir{Dir: dir}, nil
+}
+
+// Contains implements BreachedList
+func (d *RangeDir) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(d.Dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(d.Dir, prefix))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineSuffix, count, found := strings.Cut(line, ":")
+		if !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+		// Padding entries of the range API have a count of zero
+		if found {
+			if n, err := strconv.Atoi(strings.TrimSpace(count)); err == nil && n == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, scanner.Err()
+}
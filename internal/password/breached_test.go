@@ -0,0 +1,89 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hashParts returns the range prefix and the suffix of a password's SHA-1 hash
+func hashParts(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:5], hash[5:]
+}
+
+// appendLine adds a line to a range file of dir
+func appendLine(t *testing.T, dir, name, line string) {
+	t.Helper()
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(line + "\r\n"); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+}
+
+func TestRangeDirContains(t *testing.T) {
+	dir := t.TempDir()
+
+	prefix, suffix := hashParts("breached-in-txt")
+	appendLine(t, dir, prefix+".txt", "0000000000000000000000000000000000A:3")
+	appendLine(t, dir, prefix+".txt", suffix+":42")
+
+	// Files without an extension are read too, and suffixes ignore case
+	prefix, suffix = hashParts("breached-without-extension")
+	appendLine(t, dir, prefix, strings.ToLower(suffix)+":7")
+
+	// The range API pads its answers with entries that have a count of zero
+	prefix, suffix = hashParts("padding")
+	appendLine(t, dir, prefix+".txt", suffix+":0")
+
+	// The prefix has a file, but the suffix is not in it
+	prefix, _ = hashParts("not-in-its-file")
+	appendLine(t, dir, prefix+".txt", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1")
+
+	list, err := NewRangeDir(dir)
+	if err != nil {
+		t.Fatalf("NewRangeDir: %v", err)
+	}
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"breached-in-txt", true},
+		{"breached-without-extension", true},
+		{"padding", false},
+		{"not-in-its-file", false},
+		{"no-file-for-its-prefix", false},
+	}
+	for _, tt := range tests {
+		got, err := list.Contains(tt.password)
+		if err != nil {
+			t.Errorf("Contains(%q): %v", tt.password, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestNewRangeDirErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewRangeDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("NewRangeDir of a missing directory succeeded")
+	}
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := NewRangeDir(file); err == nil {
+		t.Error("NewRangeDir of a file succeeded")
+	}
+}
@@ -0,0 +1,39 @@
+// Package password hashes passwords. New hashes are argon2id in the PHC
+// string format; bcrypt hashes from before still verify and are reported as
+// needing a rehash, so that they are upgraded the next time the user logs in.
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash is returned for hashes in a format no hasher reads
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes and verifies passwords
+type Hasher interface {
+	// Hash returns an encoded hash of password with a random salt
+	Hash(password string) (string, error)
+	// Verify reports whether password matches an encoded hash
+	Verify(password, hash string) (bool, error)
+	// NeedsRehash reports whether a hash that verifies should be replaced by
+	// one with the current algorithm and parameters
+	NeedsRehash(hash string) bool
+}
+
+// isBcrypt reports whether hash is a bcrypt hash
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// verifyBcrypt checks password against a bcrypt hash
+func verifyBcrypt(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
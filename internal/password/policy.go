@@ -0,0 +1,136 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword is wrapped by PolicyError
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// Rules of the password policy, which name the violations of a PolicyError
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleCharacterClasses = "character_classes"
+	RulePersonalInfo     = "personal_info"
+	RuleStrength         = "strength"
+	RuleBreached         = "breached"
+)
+
+// PolicyError lists the rules a password breaks, with a message for each
+type PolicyError struct {
+	Violations map[string]string
+}
+
+func (e *PolicyError) Error() string {
+	return ErrWeakPassword.Error()
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// Policy decides which passwords users may choose. Zero values turn rules off.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MinCharacterClasses is how many of lowercase letters, uppercase
+	// letters, digits and other characters a password needs
+	MinCharacterClasses int
+	// DisallowPersonalInfo rejects passwords containing the username or email
+	DisallowPersonalInfo bool
+	// MinStrength is the lowest Strength score accepted, from 0 to 4
+	MinStrength int
+	// Breached, when set, rejects passwords known from data breaches
+	Breached BreachedList
+}
+
+// DefaultPolicy asks for long passwords that are hard to guess rather than
+// for particular characters
+var DefaultPolicy = Policy{
+	MinLength:            8,
+	MaxLength:            128,
+	DisallowPersonalInfo: true,
+	MinStrength:          2,
+}
+
+// Check returns a PolicyError listing every rule password breaks for the
+// user with the given username and email, or nil when it is acceptable
+func (p *Policy) Check(password, username, email string) error {
+	violations := map[string]string{}
+	length := utf8.RuneCountInString(password)
+
+	if length < p.MinLength {
+		violations[RuleMinLength] = fmt.Sprintf("Password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations[RuleMaxLength] = fmt.Sprintf("Password must be at most %d characters long", p.MaxLength)
+	}
+	if p.MinCharacterClasses > 0 && characterClasses(password) < p.MinCharacterClasses {
+		violations[RuleCharacterClasses] = fmt.Sprintf("Password must contain at least %d of lowercase letters, "+
+			"uppercase letters, digits and symbols", p.MinCharacterClasses)
+	}
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, username, email) {
+		violations[RulePersonalInfo] = "Password must not contain your username or email"
+	}
+	if p.MinStrength > 0 && Strength(password) < p.MinStrength {
+		violations[RuleStrength] = "Password is too easy to guess; use a longer password or several unrelated words"
+	}
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations[RuleBreached] = "Password has appeared in a data breach; choose another one"
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// characterClasses counts the classes of characters in password
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// containsPersonalInfo reports whether password contains the username, the
+// email or the part of the email before the @, ignoring case. Parts shorter
+// than three characters are too likely to appear by chance to count.
+func containsPersonalInfo(password, username, email string) bool {
+	password = strings.ToLower(password)
+	local, _, _ := strings.Cut(email, "@")
+	for _, part := range []string{username, email, local} {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package password
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fakeBreachedList holds breached passwords in memory
+type fakeBreachedList struct {
+	passwords map[string]bool
+	err       error
+}
+
+func (f *fakeBreachedList) Contains(password string) (bool, error) {
+	return f.passwords[password], f.err
+}
+
+// violatedRules returns the sorted rules of a PolicyError, or nil for no error
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("err = %v, want a PolicyError", err)
+	}
+	if !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("PolicyError does not wrap ErrWeakPassword")
+	}
+	rules := []string{}
+	for rule, message := range policyErr.Violations {
+		if message == "" {
+			t.Errorf("rule %s has no message", rule)
+		}
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{
+		MinLength:            8,
+		MaxLength:            20,
+		MinCharacterClasses:  3,
+		DisallowPersonalInfo: true,
+		MinStrength:          2,
+		Breached:             &fakeBreachedList{passwords: map[string]bool{"Kx7#qmZ9vw": true}},
+	}
+
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Vq8#mZ2kLp", nil},
+		{"Vq8#mZ", []string{RuleMinLength}},
+		{"Vq8#mZ2kLpVq8#mZ2kLp1", []string{RuleMaxLength}},
+		{"vq8mz2klpwx", []string{RuleCharacterClasses}},
+		{"Vq8#janedoe", []string{RulePersonalInfo}},
+		{"P@ssw0rd2024", []string{RuleStrength}},
+		{"Kx7#qmZ9vw", []string{RuleBreached}},
+		// Every broken rule is listed
+		{"janedoe", []string{RuleCharacterClasses, RuleMinLength, RulePersonalInfo}},
+	}
+	for _, tt := range tests {
+		got := violatedRules(t, policy.Check(tt.password, "janedoe", "jane.doe@example.com"))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Check(%q) violates %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyCheckZeroValueAllowsAll(t *testing.T) {
+	if err := (&Policy{}).Check("", "jane", "jane@example.com"); err != nil {
+		t.Errorf("zero Policy: %v", err)
+	}
+}
+
+func TestPolicyCheckLengthCountsCharacters(t *testing.T) {
+	// Eight characters, but more bytes
+	policy := &Policy{MinLength: 8, MaxLength: 8}
+	if err := policy.Check("äöüßéèêë", "", ""); err != nil {
+		t.Errorf("Check of eight non-ASCII characters: %v", err)
+	}
+}
+
+func TestPolicyCheckBreachedListError(t *testing.T) {
+	lookupErr := errors.New("disk failure")
+	policy := &Policy{Breached: &fakeBreachedList{err: lookupErr}}
+	if err := policy.Check("Vq8#mZ2kLp", "", ""); !errors.Is(err, lookupErr) {
+		t.Errorf("Check: err = %v, want the lookup error", err)
+	}
+}
+
+func TestContainsPersonalInfo(t *testing.T) {
+	tests := []struct {
+		password, username, email string
+		want                      bool
+	}{
+		{"xxJANEDOExx", "janedoe", "", true},
+		{"my-jane.doe@example.com!", "", "jane.doe@example.com", true},
+		{"hello jane.doe!", "", "jane.doe@example.com", true},
+		// Three characters are enough to count
+		{"xxbobxx", "bob", "", true},
+		{"xxbobxx", "", "bob@example.com", true},
+		// Shorter parts are too likely to appear by chance
+		{"xxjoxx", "jo", "", false},
+		{"xxjoxx", "", "jo@example.com", false},
+		{"xxjoxx", " jo ", "", false},
+		{"Vq8#mZ2kLp", "janedoe", "jane.doe@example.com", false},
+		{"anything", "", "", false},
+	}
+	for _, tt := range tests {
+		if got := containsPersonalInfo(tt.password, tt.username, tt.email); got != tt.want {
+			t.Errorf("containsPersonalInfo(%q, %q, %q) = %v, want %v", tt.password, tt.username, tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// strengthThresholds are the bits of guessing entropy needed for the scores
+// 1 to 4, roughly 10^3, 10^6, 10^9 and 10^12 guesses
+var strengthThresholds = []float64{10, 20, 30, 40}
+
+// keyboardRows are walked by passwords such as "qwerty" and "asdf"
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leetReplacer undoes common substitutions such as "p@ssw0rd"
+var leetReplacer = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+	"!", "i")
+
+// commonWords are passwords and words that guessers try first
+var commonWords = []string{
+	"password", "passwort", "qwerty", "dragon", "monkey", "letmein", "football", "baseball", "basketball",
+	"soccer", "hockey", "master", "shadow", "sunshine", "princess", "welcome", "login", "admin", "administrator",
+	"secret", "freedom", "whatever", "trustno", "iloveyou", "love", "lovely", "hello", "charlie", "michael",
+	"jordan", "superman", "batman", "spiderman", "starwars", "pokemon", "ninja", "mustang", "access",
+	"flower", "summer", "winter", "spring", "autumn", "monday", "friday", "sunday", "january", "february",
+	"march", "april", "june", "july", "august", "september", "october", "november", "december", "computer",
+	"internet", "google", "facebook", "apple", "samsung", "orange", "banana", "cheese", "chocolate", "pepper",
+	"cookie", "coffee", "purple", "yellow", "silver", "golden", "diamond", "angel", "daniel", "thomas",
+	"jessica", "ashley", "jennifer", "hunter", "killer", "tiger", "eagle", "falcon", "ranger", "soldier",
+	"matrix", "hacker", "test", "testing", "guest", "user", "default", "changeme", "money", "family",
+	"friend", "forever", "happy", "lucky", "magic", "music", "pass", "qwertz", "azerty", "zaq1", "abc",
+	"expense", "expenses", "manager", "budget", "finance", "account", "bank", "dollar", "euro", "london",
+	"paris", "berlin", "america", "canada", "mexico", "china", "india", "baby", "babygirl",
+	"sweet", "heart", "blue", "green", "black", "white", "red", "star", "moon", "sun", "dog", "cat",
+	"house", "home", "school", "work", "office", "liverpool", "arsenal", "chelsea", "barcelona", "madrid",
+}
+
+// commonWordBits is the entropy of picking one of commonWords
+var commonWordBits = math.Log2(float64(len(commonWords)))
+
+// Strength estimates how hard a password is to guess, from 0 (guessed at
+// once) to 4 (very unlikely to be guessed). Like zxcvbn, it looks for the
+// patterns guessers try first: common passwords and words, also with
+// substitutions such as "p@ssw0rd", repeated characters, sequences such as
+// "abcd" or "4321", keyboard walks and years. Every other character counts
+// as a random pick from the kinds of characters the password uses.
+func Strength(password string) int {
+	runes := []rune(strings.ToLower(password))
+	normalized := []rune(leetReplacer.Replace(string(runes)))
+	if len(normalized) != len(runes) {
+		normalized = runes
+	}
+	charBits := math.Log2(float64(poolSize(password)))
+
+	var bits float64
+	for i := 0; i < len(runes); {
+		if n := commonWordAt(normalized, i); n > 0 {
+			bits += commonWordBits + variationBits(password, runes, i, n)
+			i += n
+			continue
+		}
+		if n := yearAt(runes, i); n > 0 {
+			bits += math.Log2(200)
+			i += n
+			continue
+		}
+		if n := patternAt(runes, i); n >= 3 {
+			// The start of a pattern is a free pick, its length and direction cheap to try
+			bits += charBits + math.Log2(float64(n)) + 1
+			i += n
+			continue
+		}
+		bits += charBits
+		i++
+	}
+
+	score := 0
+	for _, threshold := range strengthThresholds {
+		if bits >= threshold {
+			score++
+		}
+	}
+	return score
+}
+
+// poolSize is the number of characters of the kinds password uses
+func poolSize(password string) int {
+	var lower, upper, digit, other bool
+	size := 0
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case r < unicode.MaxASCII:
+			other = true
+		default:
+			// Characters outside ASCII are rarely tried
+			size = 100
+		}
+	}
+	for _, kind := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {other, 33}} {
+		if kind.present {
+			size += kind.size
+		}
+	}
+	if size < 10 {
+		size = 10
+	}
+	return size
+}
+
+// commonWordAt returns the length of the longest common word starting at i
+func commonWordAt(runes []rune, i int) int {
+	longest := 0
+	rest := string(runes[i:])
+	for _, word := range commonWords {
+		if len(word) >= 3 && len(word) > longest && strings.HasPrefix(rest, word) {
+			longest = len(word)
+		}
+	}
+	return longest
+}
+
+// variationBits is the entropy of the capitalization and substitutions of a
+// common word: nothing for all lowercase, a bit for a capital first letter
+// and a bit per other changed character
+func variationBits(password string, lower []rune, i, n int) float64 {
+	original := []rune(password)
+	if len(original) != len(lower) {
+		return 0
+	}
+	bits := 0.0
+	for j := i; j < i+n; j++ {
+		if original[j] != lower[j] && j == i {
+			bits++
+		} else if original[j] != lower[j] || !unicode.IsLetter(lower[j]) {
+			bits++
+		}
+	}
+	return bits
+}
+
+// yearAt returns 4 when a year between 1900 and 2099 starts at i
+func yearAt(runes []rune, i int) int {
+	if i+4 > len(runes) {
+		return 0
+	}
+	year := string(runes[i : i+4])
+	if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) {
+		return 4
+	}
+	return 0
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// patternAt returns the length of the run of repeated characters, the
+// sequence such as "abc" or "987", or the keyboard walk starting at i
+func patternAt(runes []rune, i int) int {
+	n := 1
+	for step := -1; step <= 1; step++ {
+		j := i + 1
+		for j < len(runes) && runes[j]-runes[j-1] == rune(step) {
+			j++
+		}
+		if j-i > n {
+			n = j - i
+		}
+	}
+	for _, row := range keyboardRows {
+		for _, walk := range []string{row, reverse(row)} {
+			j := i
+			for j < len(runes) && j-i < len(walk) && strings.IndexRune(walk, runes[j]) >= 0 {
+				if j > i && strings.IndexRune(walk, runes[j]) != strings.IndexRune(walk, runes[j-1])+1 {
+					break
+				}
+				j++
+			}
+			if j-i > n {
+				n = j - i
+			}
+		}
+	}
+	return n
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,39 @@
+package password
+
+import "testing"
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"password", 0},
+		{"p@ssw0rd", 0},
+		{"aaaaaaaa", 0},
+		{"12345678", 0},
+		{"qwerty2024", 1},
+		{"abcdefgh", 1},
+		{"Password1", 1},
+		{"correct horse battery staple", 4},
+		{"x7#kQ9!mZ2", 4},
+	}
+	for _, tt := range tests {
+		if got := Strength(tt.password); got != tt.want {
+			t.Errorf("Strength(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestStrengthDefaultPolicy(t *testing.T) {
+	// The default policy asks for a score of 2, which common passwords with
+	// substitutions, years and keyboard walks do not reach
+	for _, password := range []string{"p@ssw0rd", "qwerty2024", "Password1", "asdfghjkl"} {
+		if Strength(password) >= DefaultPolicy.MinStrength {
+			t.Errorf("Strength(%q) = %d, want below the default policy", password, Strength(password))
+		}
+	}
+	if Strength("correct horse battery staple") < DefaultPolicy.MinStrength {
+		t.Error("a long passphrase does not meet the default policy")
+	}
+}
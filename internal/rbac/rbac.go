@@ -0,0 +1,86 @@
+// Package rbac defines the roles staff users can hold and the permissions
+// each role grants. Roles are stored per user; permissions are derived from
+// them and carried in access tokens.
+package rbac
+
+import "sort"
+
+// Roles
+const (
+	// RoleAdmin can do everything, including granting roles
+	RoleAdmin = "admin"
+	// RoleSupport helps users with their accounts
+	RoleSupport = "support"
+)
+
+// Permissions
+const (
+	// PermissionUsersRead allows listing, searching and viewing users
+	PermissionUsersRead = "users:read"
+	// PermissionUsersDisable allows disabling and enabling accounts
+	PermissionUsersDisable = "users:disable"
+	// PermissionUsersResetTwoFactor allows turning off the two-factor authentication of users
+	PermissionUsersResetTwoFactor = "users:reset_2fa"
+	// PermissionUsersImpersonate allows acting as a user, read-only
+	PermissionUsersImpersonate = "users:impersonate"
+	// PermissionRolesManage allows granting and revoking roles
+	PermissionRolesManage = "roles:manage"
+	// PermissionAuditLogRead allows reading the audit log of staff actions
+	PermissionAuditLogRead = "audit_log:read"
+	// PermissionRatesManage allows importing the exchange rates shared by every user
+	PermissionRatesManage = "rates:manage"
+)
+
+// rolePermissions holds the permissions every role grants
+var rolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionUsersRead, PermissionUsersDisable, PermissionUsersResetTwoFactor, PermissionUsersImpersonate,
+		PermissionRolesManage, PermissionAuditLogRead, PermissionRatesManage,
+	},
+	RoleSupport: {
+		PermissionUsersRead, PermissionUsersDisable, PermissionUsersResetTwoFactor, PermissionUsersImpersonate,
+	},
+}
+
+// Roles returns every role, sorted
+func Roles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the permissions granted by any of the roles, sorted;
+// unknown roles grant nothing
+func Permissions(roles []string) []string {
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Has reports whether permission is among permissions
+func Has(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
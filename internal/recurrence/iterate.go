@@ -0,0 +1,269 @@
+package recurrence
+
+import (
+	"sort"
+	"time"
+)
+
+// calendarCycleYears is the length of the Gregorian calendar cycle: after 400
+// years dates fall on the same weekdays again. A rule that goes this long,
+// times its interval, without a match can never match again, such as
+// FREQ=MONTHLY;BYMONTHDAY=31;BYMONTH=2, so the search stops there. Rules
+// with rare matches, such as leap days, are still followed.
+const calendarCycleYears = 400
+
+// Each calls fn with every occurrence of the rule in order, starting from the
+// first day of the series, until fn returns false or the rule ends
+func (r *Rule) Each(start time.Time, fn func(time.Time) bool) {
+	start = Day(start)
+	emitted := 0
+	giveUpAfter := start.AddDate(calendarCycleYears*r.Interval, 0, 0)
+
+	for period := 0; ; period++ {
+		candidates := r.expand(start, period)
+		if len(candidates) == 0 {
+			if r.periodStart(start, period).After(giveUpAfter) {
+				return
+			}
+			continue
+		}
+		giveUpAfter = candidates[len(candidates)-1].AddDate(calendarCycleYears*r.Interval, 0, 0)
+
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return
+			}
+			if !fn(candidate) {
+				return
+			}
+			if emitted++; r.Count > 0 && emitted >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// Between returns the occurrences of a series starting on start that fall within [from, to]
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	from, to = Day(from), Day(to)
+	occurrences := []time.Time{}
+	r.Each(start, func(t time.Time) bool {
+		if t.After(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Next returns up to n occurrences of a series starting on start that fall on or after from
+func (r *Rule) Next(start, from time.Time, n int) []time.Time {
+	from = Day(from)
+	occurrences := []time.Time{}
+	if n <= 0 {
+		return occurrences
+	}
+	r.Each(start, func(t time.Time) bool {
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return len(occurrences) < n
+	})
+	return occurrences
+}
+
+// Day drops the time of day, returning midnight UTC of the same calendar date
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// expand returns the sorted candidate days of the nth period of the series
+func (r *Rule) expand(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		day := start.AddDate(0, 0, step)
+		if r.monthAllowed(day.Month()) && r.monthDayAllowed(day) && r.weekdayAllowed(day.Weekday()) {
+			return []time.Time{day}
+		}
+		return nil
+
+	case Weekly:
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+		days := []time.Weekday{start.Weekday()}
+		if len(r.ByDay) > 0 {
+			days = days[:0]
+			for _, wd := range r.ByDay {
+				days = append(days, wd.Day)
+			}
+		}
+		candidates := []time.Time{}
+		for _, wd := range days {
+			day := monday.AddDate(0, 0, (int(wd)+6)%7)
+			if r.monthAllowed(day.Month()) {
+				candidates = append(candidates, day)
+			}
+		}
+		return sortedUnique(candidates)
+
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		if !r.monthAllowed(first.Month()) {
+			return nil
+		}
+		return r.daysInMonth(first, start.Day())
+
+	case Yearly:
+		year := start.Year() + step
+		if len(r.ByMonth) == 0 && len(r.ByDay) > 0 && len(r.ByMonthDay) == 0 {
+			return r.weekdaysInRange(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC))
+		}
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		candidates := []time.Time{}
+		for _, month := range months {
+			candidates = append(candidates, r.daysInMonth(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), start.Day())...)
+		}
+		return sortedUnique(candidates)
+	}
+	return nil
+}
+
+// periodStart returns the first day of the nth period of the series
+func (r *Rule) periodStart(start time.Time, period int) time.Time {
+	step := period * r.Interval
+
+	switch r.Freq {
+	case Weekly:
+		return start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+	case Monthly:
+		return time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+	case Yearly:
+		return time.Date(start.Year()+step, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return start.AddDate(0, 0, step)
+}
+
+// daysInMonth expands BYMONTHDAY and BYDAY within the month starting at first.
+// Without either, the series' own day of month is used; months that are too
+// short for it are skipped, as RFC 5545 requires.
+func (r *Rule) daysInMonth(first time.Time, defaultDay int) []time.Time {
+	next := first.AddDate(0, 1, 0)
+	length := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if defaultDay > length {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, defaultDay-1)}
+	}
+
+	var byMonthDay []time.Time
+	for _, n := range r.ByMonthDay {
+		if n < 0 {
+			n = length + n + 1
+		}
+		if n >= 1 && n <= length {
+			byMonthDay = append(byMonthDay, first.AddDate(0, 0, n-1))
+		}
+	}
+
+	if len(r.ByDay) == 0 {
+		return sortedUnique(byMonthDay)
+	}
+	byDay := r.weekdaysInRange(first, next)
+	if len(r.ByMonthDay) == 0 {
+		return byDay
+	}
+
+	// Both parts limit each other
+	allowed := make(map[time.Time]bool, len(byMonthDay))
+	for _, day := range byMonthDay {
+		allowed[day] = true
+	}
+	candidates := []time.Time{}
+	for _, day := range byDay {
+		if allowed[day] {
+			candidates = append(candidates, day)
+		}
+	}
+	return candidates
+}
+
+// weekdaysInRange returns the days in [from, to) matching BYDAY, honouring ordinals relative to the range
+func (r *Rule) weekdaysInRange(from, to time.Time) []time.Time {
+	candidates := []time.Time{}
+	for _, wd := range r.ByDay {
+		var matches []time.Time
+		for day := from.AddDate(0, 0, (int(wd.Day)-int(from.Weekday())+7)%7); day.Before(to); day = day.AddDate(0, 0, 7) {
+			matches = append(matches, day)
+		}
+
+		switch {
+		case wd.N == 0:
+			candidates = append(candidates, matches...)
+		case wd.N > 0 && wd.N <= len(matches):
+			candidates = append(candidates, matches[wd.N-1])
+		case wd.N < 0 && -wd.N <= len(matches):
+			candidates = append(candidates, matches[len(matches)+wd.N])
+		}
+	}
+	return sortedUnique(candidates)
+}
+
+func (r *Rule) monthAllowed(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) monthDayAllowed(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, n := range r.ByMonthDay {
+		if n == day.Day() || (n < 0 && length+n+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) weekdayAllowed(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedUnique(days []time.Time) []time.Time {
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	unique := days[:0]
+	for _, day := range days {
+		if len(unique) == 0 || !day.Equal(unique[len(unique)-1]) {
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,136 @@
+package recurrence
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func formatDates(days []time.Time) string {
+	items := make([]string, len(days))
+	for i, day := range days {
+		items[i] = day.Format("2006-01-02")
+	}
+	return strings.Join(items, " ")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=weekly;INTERVAL=2;BYDAY=mo,fr", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=12", "FREQ=MONTHLY;COUNT=12;BYDAY=-1FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=2SU;UNTIL=20301231T235959Z", "FREQ=YEARLY;UNTIL=20301231;BYDAY=2SU;BYMONTH=3"},
+		{"FREQ=MONTHLY;INTERVAL=1;WKST=MO", "FREQ=MONTHLY"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20300101",
+		"FREQ=DAILY;UNTIL=2030",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=54MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;COUNT",
+	} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q): err = %v, want ErrInvalidRule", in, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		from  string
+		n     int
+		want  string
+	}{
+		{"FREQ=DAILY;INTERVAL=3", "2024-02-27", "2024-02-27", 3, "2024-02-27 2024-03-01 2024-03-04"},
+		{"FREQ=DAILY;BYDAY=SA,SU", "2024-03-01", "2024-03-01", 3, "2024-03-02 2024-03-03 2024-03-09"},
+		{"FREQ=WEEKLY", "2024-03-06", "2024-03-20", 2, "2024-03-20 2024-03-27"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "2024-03-06", "2024-03-01", 4, "2024-03-08 2024-03-18 2024-03-22 2024-04-01"},
+		// Months without a 31st are skipped
+		{"FREQ=MONTHLY", "2024-01-31", "2024-01-01", 3, "2024-01-31 2024-03-31 2024-05-31"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-15", "2024-01-01", 3, "2024-01-31 2024-02-29 2024-03-31"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "2024-01-01", "2024-01-01", 3, "2024-01-26 2024-02-23 2024-03-29"},
+		{"FREQ=MONTHLY;BYDAY=2MO,2WE", "2024-04-01", "2024-04-01", 4, "2024-04-08 2024-04-10 2024-05-08 2024-05-13"},
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", "2024-01-01", "2024-01-01", 2, "2024-09-13 2024-12-13"},
+		{"FREQ=YEARLY", "2024-02-29", "2024-01-01", 2, "2024-02-29 2028-02-29"},
+		{"FREQ=YEARLY;BYMONTH=5;BYDAY=-1MO", "2024-01-01", "2024-01-01", 2, "2024-05-27 2025-05-26"},
+		{"FREQ=YEARLY;BYDAY=1MO", "2024-01-01", "2024-01-01", 2, "2024-01-01 2025-01-06"},
+		{"FREQ=DAILY;COUNT=2", "2024-03-01", "2024-01-01", 5, "2024-03-01 2024-03-02"},
+		// COUNT counts from the start, not from the first day asked for
+		{"FREQ=DAILY;COUNT=3", "2024-03-01", "2024-03-02", 5, "2024-03-02 2024-03-03"},
+		{"FREQ=WEEKLY;UNTIL=20240315", "2024-03-01", "2024-03-01", 5, "2024-03-01 2024-03-08 2024-03-15"},
+		// Matches years apart are still found, across 2100, which is not a leap year
+		{"FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29", "2024-01-01", "2024-01-01", 3, "2024-02-29 2028-02-29 2032-02-29"},
+		{"FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29", "2024-01-01", "2096-01-01", 2, "2096-02-29 2104-02-29"},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;BYDAY=MO", "2024-01-01", "2024-01-01", 2, "2044-02-29 2072-02-29"},
+		// Rules that can never match stop instead of looping forever
+		{"FREQ=MONTHLY;BYMONTHDAY=31;BYMONTH=2", "2024-01-01", "2024-01-01", 1, ""},
+		{"FREQ=DAILY", "2024-03-01", "2024-03-01", 0, ""},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		got := formatDates(rule.Next(date(tt.start), date(tt.from), tt.n))
+		if got != tt.want {
+			t.Errorf("%s from %s: Next(%s, %d) = %q, want %q", tt.rule, tt.start, tt.from, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=TU,TH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The time of day of the bounds is ignored
+	from := time.Date(2024, 3, 5, 18, 30, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 14, 1, 0, 0, 0, time.UTC)
+
+	got := formatDates(rule.Between(date("2024-03-01"), from, to))
+	if want := "2024-03-05 2024-03-07 2024-03-12 2024-03-14"; got != want {
+		t.Errorf("Between = %q, want %q", got, want)
+	}
+}
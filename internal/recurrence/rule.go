@@ -0,0 +1,222 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// for recurring expenses. Occurrences are whole days; times are ignored.
+//
+// Supported parts: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT,
+// UNTIL, BYDAY (with an optional ordinal such as 2MO or -1FR for monthly and
+// yearly rules), BYMONTHDAY (negative values count from the end of the month)
+// and BYMONTH. Weeks start on Monday.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const dateLayout = "20060102"
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry: a weekday, optionally restricted to its Nth
+// (or, when negative, Nth-from-last) occurrence within the month or year
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+// Parse parses an RRULE value such as "FREQ=MONTHLY;BYDAY=-1FR;COUNT=12".
+// A leading "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, ErrInvalidRule
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			switch value = strings.ToUpper(value); value {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = value
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: invalid BYMONTHDAY %q", ErrInvalidRule, item)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, item := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("%w: invalid BYMONTH %q", ErrInvalidRule, item)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(n))
+			}
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				return nil, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	for _, wd := range rule.ByDay {
+		if wd.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("%w: ordinal BYDAY values need FREQ=MONTHLY or FREQ=YEARLY", ErrInvalidRule)
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+// String formats the rule as an RRULE value
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format(dateLayout))
+	}
+	if len(r.ByDay) > 0 {
+		items := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			items[i] = wd.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(items, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		items := make([]string, len(r.ByMonthDay))
+		for i, n := range r.ByMonthDay {
+			items[i] = strconv.Itoa(n)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(items, ","))
+	}
+	if len(r.ByMonth) > 0 {
+		items := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			items[i] = strconv.Itoa(int(m))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(items, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// String formats the weekday as a BYDAY item, e.g. "MO" or "-1FR"
+func (wd WeekdayNum) String() string {
+	code := strings.ToUpper(wd.Day.String()[:2])
+	if wd.N == 0 {
+		return code
+	}
+	return strconv.Itoa(wd.N) + code
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, s)
+	}
+
+	day, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, s)
+	}
+
+	n := 0
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, s)
+		}
+	}
+	return WeekdayNum{N: n, Day: day}, nil
+}
+
+// parseUntil accepts the DATE and DATE-TIME forms of UNTIL and keeps the date only
+func parseUntil(s string) (time.Time, error) {
+	if len(s) < len(dateLayout) {
+		return time.Time{}, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRule, s)
+	}
+	until, err := time.Parse(dateLayout, s[:len(dateLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRule, s)
+	}
+	return until, nil
+}
@@ -8,32 +8,192 @@ import (
 	"github.com/henok-tesfu/expense-manager/docs"
 	"github.com/henok-tesfu/expense-manager/internal/handlers"
 	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/loginguard"
+	"github.com/henok-tesfu/expense-manager/internal/mailer"
 	"github.com/henok-tesfu/expense-manager/internal/middleware"
+	"github.com/henok-tesfu/expense-manager/internal/password"
+	"github.com/henok-tesfu/expense-manager/internal/rbac"
 	"github.com/henok-tesfu/expense-manager/internal/services"
+	"github.com/henok-tesfu/expense-manager/internal/storage"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-// InitRoutes initializes all application routes
-func InitRoutes(tokenService *jwt.TokenService) *mux.Router {
-	router := mux.NewRouter()
+// Config holds the infrastructure and settings the routes depend on
+type Config struct {
+	// Storage keeps receipt attachments
+	Storage storage.Storage
+	// Mailer sends password reset and verification emails
+	Mailer mailer.Mailer
+	// PasswordResetURL is the frontend page password reset links point to
+	PasswordResetURL string
+	// EmailVerificationURL is the GET /api/verify-email endpoint as seen by users
+	EmailVerificationURL string
+	// PasswordHasher hashes new passwords and verifies stored ones
+	PasswordHasher password.Hasher
+	// PasswordPolicy decides which new passwords are accepted
+	PasswordPolicy *password.Policy
+	// LoginGuard throttles logins after failed attempts
+	LoginGuard *loginguard.Guard
+	// ExportLinkSecret signs the download links of data exports
+	ExportLinkSecret []byte
+	// TOTPIssuer names the application in authenticator apps
+	TOTPIssuer string
+	// UnverifiedAccess is one of the middleware.UnverifiedAccess policies
+	UnverifiedAccess string
+}
 
-	// Initialize services
-	userService := services.NewUserService()
+// InitRoutes initializes all application routes with the services they use
+func InitRoutes(tokenService *jwt.TokenService, s *Services, config Config) *mux.Router {
+	router := mux.NewRouter()
 
 	// Initialize handlers with dependencies
-	userHandler := handlers.NewUserHandler(userService, tokenService)
+	userHandler := handlers.NewUserHandler(s.User, tokenService, s.EmailVerification, s.TwoFactor,
+		s.LoginGuard)
+	expenseHandler := handlers.NewExpenseHandler(s.Expense)
+	attachmentHandler := handlers.NewAttachmentHandler(s.Attachment)
+	categoryHandler := handlers.NewCategoryHandler(s.Category)
+	accountHandler := handlers.NewAccountHandler(s.Account)
+	budgetHandler := handlers.NewBudgetHandler(s.Budget)
+	recurringHandler := handlers.NewRecurringHandler(s.Recurring)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(s.ExchangeRate)
+	reportHandler := handlers.NewReportHandler(s.Report)
+	importHandler := handlers.NewImportHandler(s.Import)
+	sessionHandler := handlers.NewSessionHandler(s.Session)
+	keysHandler := handlers.NewKeysHandler(tokenService)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(s.PersonalAccessToken)
+	passwordHandler := handlers.NewPasswordHandler(s.Password)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(s.EmailVerification)
+	twoFactorHandler := handlers.NewTwoFactorHandler(s.TwoFactor)
+	dataExportHandler := handlers.NewDataExportHandler(s.DataExport)
+	adminHandler := handlers.NewAdminHandler(s.Admin)
 
 	// Public routes
 	router.HandleFunc("/api/register", userHandler.Register).Methods("POST")
 	router.HandleFunc("/api/login", userHandler.Login).Methods("POST")
+	router.HandleFunc("/api/login/mfa", userHandler.LoginMFA).Methods("POST")
 	router.HandleFunc("/api/auth/refresh", userHandler.Refresh).Methods("POST")
+	router.HandleFunc("/api/auth/logout", userHandler.Logout).Methods("POST")
+	router.HandleFunc("/api/password/forgot", passwordHandler.Forgot).Methods("POST")
+	router.HandleFunc("/api/password/reset", passwordHandler.Reset).Methods("POST")
+	router.HandleFunc("/api/verify-email", emailVerificationHandler.Verify).Methods("GET")
+	router.HandleFunc("/api/exports/{id}/download", dataExportHandler.Download).Methods("GET")
+	router.HandleFunc("/.well-known/jwks.json", keysHandler.JWKS).Methods("GET")
 
-	// Protected routes
+	// Protected routes. Personal access tokens can only use the routes wrapped
+	// in scoped, when they have the scope.
 	protected := router.PathPrefix("/api").Subrouter()
+	scoped := middleware.RequireScope
 	protected.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
 		userId := r.Context().Value("user_id").(int)
 		w.Write([]byte("Hello, User " + strconv.Itoa(userId)))
 	}).Methods("GET")
+	protected.HandleFunc("/me/home-currency", userHandler.SetHomeCurrency).Methods("PUT")
+
+	// Profile routes, which users can use before verifying their email, e.g. to correct it
+	protected.Handle("/me", middleware.AllowUnverified(userHandler.Profile)).Methods("GET")
+	protected.Handle("/me", middleware.AllowUnverified(userHandler.UpdateProfile)).Methods("PATCH")
+	protected.Handle("/me", middleware.AllowUnverified(userHandler.DeleteAccount)).Methods("DELETE")
+	protected.Handle("/me/password", middleware.AllowUnverified(userHandler.ChangePassword)).Methods("POST")
+	protected.Handle("/verify-email/resend", middleware.AllowUnverified(emailVerificationHandler.Resend)).Methods("POST")
+
+	// Data export routes, which users can use before verifying their email
+	protected.Handle("/exports", middleware.AllowUnverified(dataExportHandler.Request)).Methods("POST")
+	protected.Handle("/exports", middleware.AllowUnverified(dataExportHandler.List)).Methods("GET")
+	protected.Handle("/exports/{id}", middleware.AllowUnverified(dataExportHandler.Get)).Methods("GET")
+
+	// Session routes
+	protected.HandleFunc("/sessions", sessionHandler.List).Methods("GET")
+	protected.HandleFunc("/sessions/others", sessionHandler.RevokeOthers).Methods("DELETE")
+	protected.HandleFunc("/sessions/{id}", sessionHandler.Revoke).Methods("DELETE")
+
+	// Two-factor authentication routes
+	protected.HandleFunc("/me/2fa/enroll", twoFactorHandler.Enroll).Methods("POST")
+	protected.HandleFunc("/me/2fa/confirm", twoFactorHandler.Confirm).Methods("POST")
+	protected.HandleFunc("/me/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes).Methods("POST")
+	protected.HandleFunc("/me/2fa", twoFactorHandler.Disable).Methods("DELETE")
+
+	// Personal access token routes
+	protected.HandleFunc("/personal-access-tokens", personalAccessTokenHandler.Create).Methods("POST")
+	protected.HandleFunc("/personal-access-tokens", personalAccessTokenHandler.List).Methods("GET")
+	protected.HandleFunc("/personal-access-tokens/{id}", personalAccessTokenHandler.Revoke).Methods("DELETE")
+
+	// Expense routes
+	protected.Handle("/expenses", scoped(services.ScopeExpensesWrite, expenseHandler.Create)).Methods("POST")
+	protected.Handle("/expenses", scoped(services.ScopeExpensesRead, expenseHandler.List)).Methods("GET")
+	protected.Handle("/expenses/export", scoped(services.ScopeExpensesRead, expenseHandler.Export)).Methods("GET")
+	protected.Handle("/expenses/{id}", scoped(services.ScopeExpensesRead, expenseHandler.Get)).Methods("GET")
+	protected.Handle("/expenses/{id}", scoped(services.ScopeExpensesWrite, expenseHandler.Update)).Methods("PUT")
+	protected.Handle("/expenses/{id}", scoped(services.ScopeExpensesWrite, expenseHandler.Delete)).Methods("DELETE")
+
+	// Receipt attachment routes
+	protected.Handle("/expenses/{id}/attachments", scoped(services.ScopeExpensesWrite, attachmentHandler.Upload)).Methods("POST")
+	protected.Handle("/expenses/{id}/attachments", scoped(services.ScopeExpensesRead, attachmentHandler.List)).Methods("GET")
+	protected.Handle("/expenses/{id}/attachments/{attachmentID}", scoped(services.ScopeExpensesRead, attachmentHandler.Download)).Methods("GET")
+	protected.Handle("/expenses/{id}/attachments/{attachmentID}", scoped(services.ScopeExpensesWrite, attachmentHandler.Delete)).Methods("DELETE")
+	protected.Handle("/expenses/{id}/attachments/{attachmentID}/thumbnail", scoped(services.ScopeExpensesRead, attachmentHandler.Thumbnail)).Methods("GET")
+
+	// Category routes
+	protected.Handle("/categories", scoped(services.ScopeExpensesRead, categoryHandler.List)).Methods("GET")
+	protected.Handle("/categories", scoped(services.ScopeExpensesWrite, categoryHandler.Create)).Methods("POST")
+	protected.Handle("/categories/{id}", scoped(services.ScopeExpensesWrite, categoryHandler.Update)).Methods("PUT")
+	protected.Handle("/categories/{id}", scoped(services.ScopeExpensesWrite, categoryHandler.Delete)).Methods("DELETE")
+
+	// Account routes
+	protected.Handle("/accounts", scoped(services.ScopeExpensesRead, accountHandler.List)).Methods("GET")
+	protected.Handle("/accounts", scoped(services.ScopeExpensesWrite, accountHandler.Create)).Methods("POST")
+	protected.Handle("/accounts/{id}", scoped(services.ScopeExpensesRead, accountHandler.Get)).Methods("GET")
+	protected.Handle("/accounts/{id}", scoped(services.ScopeExpensesWrite, accountHandler.Update)).Methods("PUT")
+	protected.Handle("/accounts/{id}", scoped(services.ScopeExpensesWrite, accountHandler.Delete)).Methods("DELETE")
+
+	// Budget routes
+	protected.Handle("/budgets", scoped(services.ScopeBudgetsWrite, budgetHandler.Create)).Methods("POST")
+	protected.Handle("/budgets", scoped(services.ScopeReportsRead, budgetHandler.List)).Methods("GET")
+	protected.Handle("/budgets/status", scoped(services.ScopeReportsRead, budgetHandler.Statuses)).Methods("GET")
+	protected.Handle("/budgets/{id}", scoped(services.ScopeReportsRead, budgetHandler.Get)).Methods("GET")
+	protected.Handle("/budgets/{id}", scoped(services.ScopeBudgetsWrite, budgetHandler.Update)).Methods("PUT")
+	protected.Handle("/budgets/{id}", scoped(services.ScopeBudgetsWrite, budgetHandler.Delete)).Methods("DELETE")
+	protected.Handle("/budgets/{id}/status", scoped(services.ScopeReportsRead, budgetHandler.Status)).Methods("GET")
+
+	// Recurring expense routes
+	protected.Handle("/recurring-expenses", scoped(services.ScopeExpensesWrite, recurringHandler.Create)).Methods("POST")
+	protected.Handle("/recurring-expenses", scoped(services.ScopeExpensesRead, recurringHandler.List)).Methods("GET")
+	protected.Handle("/recurring-expenses/{id}", scoped(services.ScopeExpensesRead, recurringHandler.Get)).Methods("GET")
+	protected.Handle("/recurring-expenses/{id}", scoped(services.ScopeExpensesWrite, recurringHandler.Update)).Methods("PUT")
+	protected.Handle("/recurring-expenses/{id}", scoped(services.ScopeExpensesWrite, recurringHandler.Delete)).Methods("DELETE")
+	protected.Handle("/recurring-expenses/{id}/preview", scoped(services.ScopeExpensesRead, recurringHandler.Preview)).Methods("GET")
+	protected.Handle("/recurring-expenses/{id}/occurrences/{date}", scoped(services.ScopeExpensesWrite, recurringHandler.EditOccurrence)).Methods("PUT")
+	protected.Handle("/recurring-expenses/{id}/occurrences/{date}", scoped(services.ScopeExpensesWrite, recurringHandler.RestoreOccurrence)).Methods("DELETE")
+	protected.Handle("/recurring-expenses/{id}/occurrences/{date}/skip", scoped(services.ScopeExpensesWrite, recurringHandler.SkipOccurrence)).Methods("POST")
+
+	// Exchange rate routes
+	protected.Handle("/exchange-rates", scoped(services.ScopeExpensesRead, exchangeRateHandler.Get)).Methods("GET")
+
+	// Report routes
+	protected.Handle("/reports/spending", scoped(services.ScopeReportsRead, reportHandler.Spending)).Methods("GET")
+
+	// Statement import routes
+	protected.Handle("/imports", scoped(services.ScopeExpensesWrite, importHandler.Import)).Methods("POST")
+	protected.Handle("/import-profiles", scoped(services.ScopeExpensesWrite, importHandler.CreateProfile)).Methods("POST")
+	protected.Handle("/import-profiles", scoped(services.ScopeExpensesRead, importHandler.ListProfiles)).Methods("GET")
+	protected.Handle("/import-profiles/{id}", scoped(services.ScopeExpensesRead, importHandler.GetProfile)).Methods("GET")
+	protected.Handle("/import-profiles/{id}", scoped(services.ScopeExpensesWrite, importHandler.UpdateProfile)).Methods("PUT")
+	protected.Handle("/import-profiles/{id}", scoped(services.ScopeExpensesWrite, importHandler.DeleteProfile)).Methods("DELETE")
+
+	// Admin routes for staff, each needing a permission of their roles
+	admin := protected.PathPrefix("/admin").Subrouter()
+	permitted := func(permission string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(permission)(handler)
+	}
+	admin.Handle("/users", permitted(rbac.PermissionUsersRead, adminHandler.ListUsers)).Methods("GET")
+	admin.Handle("/users/{id}", permitted(rbac.PermissionUsersRead, adminHandler.GetUser)).Methods("GET")
+	admin.Handle("/users/{id}/disable", permitted(rbac.PermissionUsersDisable, adminHandler.DisableUser)).Methods("POST")
+	admin.Handle("/users/{id}/enable", permitted(rbac.PermissionUsersDisable, adminHandler.EnableUser)).Methods("POST")
+	admin.Handle("/users/{id}/2fa", permitted(rbac.PermissionUsersResetTwoFactor, adminHandler.ResetTwoFactor)).Methods("DELETE")
+	admin.Handle("/users/{id}/impersonate", permitted(rbac.PermissionUsersImpersonate, adminHandler.Impersonate)).Methods("POST")
+	admin.Handle("/users/{id}/roles/{role}", permitted(rbac.PermissionRolesManage, adminHandler.GrantRole)).Methods("PUT")
+	admin.Handle("/users/{id}/roles/{role}", permitted(rbac.PermissionRolesManage, adminHandler.RevokeRole)).Methods("DELETE")
+	admin.Handle("/audit-log", permitted(rbac.PermissionAuditLogRead, adminHandler.AuditLog)).Methods("GET")
+	admin.Handle("/exchange-rates/import", permitted(rbac.PermissionRatesManage, exchangeRateHandler.Import)).Methods("POST")
 
 	// Serve Swagger docs
 	docs.SwaggerInfo.BasePath = "/" // Adjust the base path if needed
@@ -45,6 +205,7 @@ func InitRoutes(tokenService *jwt.TokenService) *mux.Router {
 	router.Use(middleware.ContentSecurityPolicyMiddleware("default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; object-src 'none'"))
 
 	// Apply SecureHeaders middleware globally for protected routes
-	protected.Use(middleware.AuthMiddleware(tokenService))
+	protected.Use(middleware.AuthMiddleware(tokenService, s.PersonalAccessToken))
+	protected.Use(middleware.RequireVerifiedEmail(config.UnverifiedAccess, s.EmailVerification))
 	return router
 }
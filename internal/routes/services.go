@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// Services holds the services of the application. They are built once and
+// shared by the routes and the background workers.
+type Services struct {
+	Category            *services.CategoryService
+	Account             *services.AccountService
+	Attachment          *services.AttachmentService
+	Session             *services.SessionService
+	User                *services.UserService
+	Expense             *services.ExpenseService
+	ExchangeRate        *services.ExchangeRateService
+	Budget              *services.BudgetService
+	Report              *services.ReportService
+	Import              *services.ImportService
+	Recurring           *services.RecurringService
+	PersonalAccessToken *services.PersonalAccessTokenService
+	Password            *services.PasswordService
+	EmailVerification   *services.EmailVerificationService
+	TwoFactor           *services.TwoFactorService
+	LoginGuard          *services.LoginGuardService
+	DataExport          *services.DataExportService
+	Admin               *services.AdminService
+}
+
+// NewServices builds the services of the application from its configuration
+func NewServices(tokenService *jwt.TokenService, sessionService *services.SessionService, config Config) *Services {
+	categoryService := services.NewCategoryService()
+	accountService := services.NewAccountService()
+	attachmentService := services.NewAttachmentService(config.Storage)
+	twoFactorService := services.NewTwoFactorService(config.TOTPIssuer)
+	loginGuardService := services.NewLoginGuardService(config.LoginGuard)
+	userService := services.NewUserService(categoryService, attachmentService, sessionService, twoFactorService,
+		loginGuardService, config.PasswordHasher, config.PasswordPolicy)
+	expenseService := services.NewExpenseService(categoryService, accountService, attachmentService)
+	exchangeRateService := services.NewExchangeRateService()
+
+	return &Services{
+		Category:            categoryService,
+		Account:             accountService,
+		Attachment:          attachmentService,
+		Session:             sessionService,
+		User:                userService,
+		Expense:             expenseService,
+		ExchangeRate:        exchangeRateService,
+		Budget:              services.NewBudgetService(categoryService, exchangeRateService.Converter()),
+		Report:              services.NewReportService(exchangeRateService.Converter()),
+		Import:              services.NewImportService(categoryService, accountService),
+		Recurring:           services.NewRecurringService(categoryService, expenseService),
+		PersonalAccessToken: services.NewPersonalAccessTokenService(),
+		Password: services.NewPasswordService(config.Mailer, sessionService, config.PasswordHasher,
+			config.PasswordPolicy, config.PasswordResetURL),
+		EmailVerification: services.NewEmailVerificationService(config.Mailer, config.EmailVerificationURL),
+		TwoFactor:         twoFactorService,
+		LoginGuard:        loginGuardService,
+		DataExport:        services.NewDataExportService(config.Storage, config.ExportLinkSecret),
+		Admin:             services.NewAdminService(userService, tokenService),
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+var (
+	// ErrAccountNotFound is returned when an account does not exist or belongs to another user
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrInvalidAccount is returned when a referenced account does not exist for the user
+	ErrInvalidAccount = errors.New("account does not exist")
+	// ErrAccountIdentifierTaken is returned when another account of the user has the same identifier
+	ErrAccountIdentifierTaken = errors.New("another account already has this identifier")
+)
+
+type AccountService struct{}
+
+func NewAccountService() *AccountService {
+	return &AccountService{}
+}
+
+func (as *AccountService) CreateAccount(account *models.Account) (*models.Account, error) {
+	if err := as.checkIdentifier(account); err != nil {
+		return nil, err
+	}
+
+	accountID, err := models.CreateAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetAccountByID(account.UserID, int(accountID))
+}
+
+func (as *AccountService) ListAccounts(userID int) ([]*models.Account, error) {
+	return models.ListAccounts(userID)
+}
+
+func (as *AccountService) GetAccount(userID, id int) (*models.Account, error) {
+	account, err := models.GetAccountByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+func (as *AccountService) UpdateAccount(account *models.Account) (*models.Account, error) {
+	if _, err := as.GetAccount(account.UserID, account.ID); err != nil {
+		return nil, err
+	}
+
+	if err := as.checkIdentifier(account); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateAccount(account); err != nil {
+		return nil, err
+	}
+
+	return models.GetAccountByID(account.UserID, account.ID)
+}
+
+func (as *AccountService) DeleteAccount(userID, id int) error {
+	found, err := models.DeleteAccount(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// ValidateAccount checks that an optional account reference belongs to the user
+func (as *AccountService) ValidateAccount(userID int, id *int) error {
+	if id == nil {
+		return nil
+	}
+
+	account, err := models.GetAccountByID(userID, *id)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return ErrInvalidAccount
+	}
+
+	return nil
+}
+
+// checkIdentifier makes sure no other account of the user uses the same identifier
+func (as *AccountService) checkIdentifier(account *models.Account) error {
+	if account.Identifier == nil {
+		return nil
+	}
+
+	existing, err := models.GetAccountByIdentifier(account.UserID, *account.Identifier)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != account.ID {
+		return ErrAccountIdentifierTaken
+	}
+
+	return nil
+}
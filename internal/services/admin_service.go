@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/rbac"
+)
+
+var (
+	// ErrUnknownRole is returned for roles rbac does not define
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrCannotTargetSelf is returned when staff try to disable, impersonate or change the roles of themselves
+	ErrCannotTargetSelf = errors.New("staff cannot take this action on their own account")
+	// ErrCannotImpersonateStaff is returned for impersonating users who hold a role
+	ErrCannotImpersonateStaff = errors.New("users with roles cannot be impersonated")
+	// ErrStaffTarget is returned when staff who cannot manage roles act on users who hold a role
+	ErrStaffTarget = errors.New("only staff who manage roles can take this action on users with roles")
+)
+
+// Actor is the staff user making an admin request, as recorded in the audit log
+type Actor struct {
+	UserID int
+	// Permissions are those of the actor's access token
+	Permissions []string
+	Device      jwt.Device
+}
+
+// AdminUser is a user as staff see it, with their roles
+type AdminUser struct {
+	*models.User
+	Roles []string `json:"roles"`
+}
+
+// Impersonation is the access token of a session in which staff act as a user
+type Impersonation struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	SessionID   string `json:"session_id"`
+}
+
+type AdminService struct {
+	UserService  *UserService
+	TokenService *jwt.TokenService
+}
+
+func NewAdminService(userService *UserService, tokenService *jwt.TokenService) *AdminService {
+	return &AdminService{UserService: userService, TokenService: tokenService}
+}
+
+// ListUsers returns the users matching the filter, newest first
+func (as *AdminService) ListUsers(filter models.UserFilter) ([]*models.User, error) {
+	if filter.Role != "" && !rbac.ValidRole(filter.Role) {
+		return nil, ErrUnknownRole
+	}
+	return models.ListUsers(filter)
+}
+
+// GetUser returns a user with their roles
+func (as *AdminService) GetUser(id int) (*AdminUser, error) {
+	user, err := as.UserService.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := models.ListUserRoles(id)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminUser{User: user, Roles: roles}, nil
+}
+
+// DisableUser stops a user from logging in and ends every session and token
+// of theirs until they are enabled again; reason is kept in the audit log
+func (as *AdminService) DisableUser(actor Actor, id int, reason string) (*AdminUser, error) {
+	if _, err := as.checkTarget(actor, id); err != nil {
+		return nil, err
+	}
+
+	sessionIDs, disabled, err := models.DisableUser(id)
+	if err != nil {
+		return nil, err
+	}
+	as.UserService.SessionService.forget(sessionIDs...)
+	if disabled {
+		if err := as.record(actor, models.AdminActionDisableUser, id, reason); err != nil {
+			return nil, err
+		}
+	}
+	return as.GetUser(id)
+}
+
+// EnableUser lets a disabled user log in again
+func (as *AdminService) EnableUser(actor Actor, id int) (*AdminUser, error) {
+	if _, err := as.checkTarget(actor, id); err != nil {
+		return nil, err
+	}
+
+	enabled, err := models.EnableUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		if err := as.record(actor, models.AdminActionEnableUser, id, ""); err != nil {
+			return nil, err
+		}
+	}
+	return as.GetUser(id)
+}
+
+// ResetTwoFactor turns off the two-factor authentication of a user who lost
+// their authenticator and recovery codes, so that the password alone logs in
+func (as *AdminService) ResetTwoFactor(actor Actor, id int) (*AdminUser, error) {
+	user, err := as.checkTarget(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := models.DisableTOTP(id); err != nil {
+		return nil, err
+	}
+	if err := as.record(actor, models.AdminActionResetTwoFactor, id, ""); err != nil {
+		return nil, err
+	}
+	return as.GetUser(id)
+}
+
+// Impersonate starts a session in which the staff user acts as a user, to
+// see what they see. The session is recorded in the audit log and in the
+// user's session list, cannot be refreshed and is read-only.
+func (as *AdminService) Impersonate(actor Actor, id int) (*Impersonation, error) {
+	target, err := as.checkTarget(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if target.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	// The session gets the user's data but never their roles
+	if len(target.Roles) > 0 {
+		return nil, ErrCannotImpersonateStaff
+	}
+
+	accessToken, sessionID, err := as.TokenService.GenerateImpersonationToken(id, jwt.AccessGrant{
+		EmailVerified:  target.EmailVerifiedAt != nil,
+		ImpersonatorID: actor.UserID,
+	}, actor.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	// An impersonation that cannot be audited does not happen
+	if err := as.record(actor, models.AdminActionImpersonate, id, "session "+sessionID); err != nil {
+		if _, revokeErr := models.RevokeSession(id, sessionID); revokeErr != nil {
+			log.Printf("Failed to end unaudited impersonation session %s: %v", sessionID, revokeErr)
+		}
+		as.UserService.SessionService.forget(sessionID)
+		return nil, err
+	}
+
+	return &Impersonation{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(as.TokenService.Config.AccessTokenExpiry / time.Second),
+		SessionID:   sessionID,
+	}, nil
+}
+
+// GrantRole gives a user a role, which their access tokens carry from the
+// next login or refresh
+func (as *AdminService) GrantRole(actor Actor, id int, role string) (*AdminUser, error) {
+	if !rbac.ValidRole(role) {
+		return nil, ErrUnknownRole
+	}
+	if _, err := as.checkTarget(actor, id); err != nil {
+		return nil, err
+	}
+
+	granted, err := models.GrantRole(id, role, &actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if granted {
+		if err := as.record(actor, models.AdminActionGrantRole, id, role); err != nil {
+			return nil, err
+		}
+	}
+	return as.GetUser(id)
+}
+
+// RevokeRole takes a role from a user. Their sessions are ended, as their
+// access tokens still carry the role.
+func (as *AdminService) RevokeRole(actor Actor, id int, role string) (*AdminUser, error) {
+	if !rbac.ValidRole(role) {
+		return nil, ErrUnknownRole
+	}
+	if _, err := as.checkTarget(actor, id); err != nil {
+		return nil, err
+	}
+
+	revoked, err := models.RevokeRole(id, role)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		sessionIDs, err := models.RevokeAllSessions(id)
+		if err != nil {
+			return nil, err
+		}
+		as.UserService.SessionService.forget(sessionIDs...)
+		if err := as.record(actor, models.AdminActionRevokeRole, id, role); err != nil {
+			return nil, err
+		}
+	}
+	return as.GetUser(id)
+}
+
+// ListAuditLog returns the audit log entries matching the filter, newest first
+func (as *AdminService) ListAuditLog(filter models.AdminAuditFilter) ([]*models.AdminAuditEntry, error) {
+	return models.ListAdminAuditLog(filter)
+}
+
+// checkTarget returns the user an action is taken on, making sure they are
+// not the actor and that only staff who manage roles act on other staff
+func (as *AdminService) checkTarget(actor Actor, id int) (*AdminUser, error) {
+	if actor.UserID == id {
+		return nil, ErrCannotTargetSelf
+	}
+	target, err := as.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(target.Roles) > 0 && !rbac.Has(actor.Permissions, rbac.PermissionRolesManage) {
+		return nil, ErrStaffTarget
+	}
+	return target, nil
+}
+
+// record adds an action of the actor on a user to the audit log
+func (as *AdminService) record(actor Actor, action string, targetID int, detail string) error {
+	if len(detail) > 255 {
+		detail = strings.ToValidUTF8(detail[:255], "")
+	}
+	return models.RecordAdminAction(&models.AdminAuditEntry{
+		ActorID:      &actor.UserID,
+		Action:       action,
+		TargetUserID: &targetID,
+		Detail:       detail,
+		IP:           actor.Device.IP,
+		UserAgent:    actor.Device.UserAgent,
+	})
+}
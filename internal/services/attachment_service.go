@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/storage"
+	"github.com/henok-tesfu/expense-manager/internal/thumbnail"
+)
+
+const (
+	// MaxAttachmentSize is the largest receipt file accepted, in bytes
+	MaxAttachmentSize = 10 << 20
+	// thumbnailSize bounds the width and height of generated thumbnails
+	thumbnailSize = 256
+)
+
+var (
+	// ErrAttachmentNotFound is returned when an attachment does not exist or belongs to another user
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentTooLarge is returned for files above MaxAttachmentSize
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the maximum size of 10 MB")
+	// ErrUnsupportedAttachmentType is returned for files that are neither images nor PDFs
+	ErrUnsupportedAttachmentType = errors.New("attachment must be a JPEG, PNG, GIF or WebP image or a PDF")
+)
+
+// attachmentTypes lists the accepted content types, as sniffed from the file
+// itself, and whether a thumbnail can be generated for them
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      false,
+	"application/pdf": false,
+}
+
+type AttachmentService struct {
+	Storage storage.Storage
+}
+
+func NewAttachmentService(store storage.Storage) *AttachmentService {
+	return &AttachmentService{Storage: store}
+}
+
+// Upload stores a receipt file for an expense. The content type is sniffed
+// from the file rather than trusted from the client.
+func (as *AttachmentService) Upload(ctx context.Context, userID, expenseID int, filename string, r io.Reader) (*models.Attachment, error) {
+	if err := as.checkExpense(userID, expenseID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	thumbnailable, ok := attachmentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	key, err := attachmentKey(userID, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	attachment := &models.Attachment{
+		UserID:      userID,
+		ExpenseID:   expenseID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+	}
+
+	if err := as.Storage.Put(ctx, key, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	// A receipt is still useful without a preview, so thumbnail failures are only logged
+	if thumbnailable {
+		if thumb, err := thumbnail.Generate(bytes.NewReader(data), thumbnailSize); err != nil {
+			log.Printf("Failed to generate thumbnail for %s: %v", key, err)
+		} else {
+			thumbKey := key + ".thumb.jpg"
+			if err := as.Storage.Put(ctx, thumbKey, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+				log.Printf("Failed to store thumbnail for %s: %v", key, err)
+			} else {
+				attachment.ThumbnailKey = &thumbKey
+			}
+		}
+	}
+
+	attachmentID, err := models.CreateAttachment(attachment)
+	if err != nil {
+		as.deleteBlobs(ctx, []*models.Attachment{attachment})
+		return nil, err
+	}
+
+	return models.GetAttachment(userID, expenseID, int(attachmentID))
+}
+
+func (as *AttachmentService) ListAttachments(userID, expenseID int) ([]*models.Attachment, error) {
+	if err := as.checkExpense(userID, expenseID); err != nil {
+		return nil, err
+	}
+
+	return models.ListAttachments(userID, expenseID)
+}
+
+// Open returns an attachment with its file, or its thumbnail when thumb is
+// set. The caller must close the returned reader.
+func (as *AttachmentService) Open(ctx context.Context, userID, expenseID, id int, thumb bool) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := models.GetAttachment(userID, expenseID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment == nil || (thumb && attachment.ThumbnailKey == nil) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	key := attachment.StorageKey
+	if thumb {
+		key = *attachment.ThumbnailKey
+	}
+	file, err := as.Storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, file, nil
+}
+
+func (as *AttachmentService) DeleteAttachment(ctx context.Context, userID, expenseID, id int) error {
+	attachment, err := models.GetAttachment(userID, expenseID, id)
+	if err != nil {
+		return err
+	}
+	if attachment == nil {
+		return ErrAttachmentNotFound
+	}
+
+	if _, err := models.DeleteAttachment(userID, expenseID, id); err != nil {
+		return err
+	}
+
+	as.deleteBlobs(ctx, []*models.Attachment{attachment})
+	return nil
+}
+
+// deleteBlobs removes the stored files of attachments whose rows are gone.
+// Failures only leave orphaned files behind, so they are logged.
+func (as *AttachmentService) deleteBlobs(ctx context.Context, attachments []*models.Attachment) {
+	for _, attachment := range attachments {
+		for _, key := range attachmentKeys(attachment) {
+			if err := as.Storage.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete attachment blob %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// attachmentKeys returns the storage keys of an attachment's file and thumbnail
+func attachmentKeys(a *models.Attachment) []string {
+	keys := []string{a.StorageKey}
+	if a.ThumbnailKey != nil {
+		keys = append(keys, *a.ThumbnailKey)
+	}
+	return keys
+}
+
+func (as *AttachmentService) checkExpense(userID, expenseID int) error {
+	expense, err := models.GetExpenseByID(userID, expenseID)
+	if err != nil {
+		return err
+	}
+	if expense == nil {
+		return ErrExpenseNotFound
+	}
+	return nil
+}
+
+// attachmentKey returns a new, unguessable storage key below the owner's prefix
+func attachmentKey(userID, expenseID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("receipts/%d/%d/%s", userID, expenseID, hex.EncodeToString(random)), nil
+}
+
+// cleanFilename keeps the base name of an uploaded file for display and downloads
+func cleanFilename(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "receipt"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+// deleteUserBlobs removes the stored files of every attachment of a user,
+// stopping at the first file that cannot be deleted
+func (as *AttachmentService) deleteUserBlobs(ctx context.Context, userID int) error {
+	attachments, err := models.ListUserAttachments(userID)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		for _, key := range attachmentKeys(attachment) {
+			if err := as.Storage.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+var (
+	// ErrBudgetNotFound is returned when a budget does not exist or belongs to another user
+	ErrBudgetNotFound = errors.New("budget not found")
+	// ErrBudgetNotStarted is returned when asking for the status of a budget before its start date
+	ErrBudgetNotStarted = errors.New("budget has not started yet")
+)
+
+// BudgetStatus compares a budget with the actual spending of one period.
+// All amounts are in the budget's currency unless another one was requested.
+type BudgetStatus struct {
+	Budget      *models.Budget `json:"budget"`
+	PeriodStart time.Time      `json:"period_start"`
+	PeriodEnd   time.Time      `json:"period_end"`
+	Limit       money.Amount   `json:"limit"`
+	RolledOver  money.Amount   `json:"rolled_over"`
+	Available   money.Amount   `json:"available"`
+	Spent       money.Amount   `json:"spent"`
+	Remaining   money.Amount   `json:"remaining"`
+	PercentUsed float64        `json:"percent_used"`
+	Overspent   bool           `json:"overspent"`
+	// Unconverted lists spending that could not be converted for lack of an exchange rate
+	Unconverted []money.Amount `json:"unconverted,omitempty"`
+}
+
+type BudgetService struct {
+	CategoryService *CategoryService
+	Converter       money.Converter
+}
+
+func NewBudgetService(categoryService *CategoryService, converter money.Converter) *BudgetService {
+	return &BudgetService{
+		CategoryService: categoryService,
+		Converter:       converter,
+	}
+}
+
+func (bs *BudgetService) CreateBudget(budget *models.Budget) (*models.Budget, error) {
+	if err := bs.CategoryService.ValidateCategory(budget.UserID, budget.CategoryID); err != nil {
+		return nil, err
+	}
+
+	budgetID, err := models.CreateBudget(budget)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetBudgetByID(budget.UserID, int(budgetID))
+}
+
+func (bs *BudgetService) ListBudgets(userID int) ([]*models.Budget, error) {
+	return models.ListBudgets(userID)
+}
+
+func (bs *BudgetService) GetBudget(userID, id int) (*models.Budget, error) {
+	budget, err := models.GetBudgetByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrBudgetNotFound
+	}
+
+	return budget, nil
+}
+
+func (bs *BudgetService) UpdateBudget(budget *models.Budget) (*models.Budget, error) {
+	if _, err := bs.GetBudget(budget.UserID, budget.ID); err != nil {
+		return nil, err
+	}
+
+	if err := bs.CategoryService.ValidateCategory(budget.UserID, budget.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateBudget(budget); err != nil {
+		return nil, err
+	}
+
+	return models.GetBudgetByID(budget.UserID, budget.ID)
+}
+
+func (bs *BudgetService) DeleteBudget(userID, id int) error {
+	found, err := models.DeleteBudget(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrBudgetNotFound
+	}
+
+	return nil
+}
+
+// Statuses returns the status of every budget of the user that has started by
+// asOf. A non-empty currency converts every status at the rate of asOf.
+func (bs *BudgetService) Statuses(userID int, asOf time.Time, currency string) ([]*BudgetStatus, error) {
+	budgets, err := models.ListBudgets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []*BudgetStatus{}
+	for _, budget := range budgets {
+		status, err := bs.status(budget, asOf)
+		if errors.Is(err, ErrBudgetNotStarted) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := bs.convertStatus(status, currency, asOf); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Status returns budget vs. actual for the period of the budget containing
+// asOf. A non-empty currency converts the status at the rate of asOf.
+func (bs *BudgetService) Status(userID, id int, asOf time.Time, currency string) (*BudgetStatus, error) {
+	budget, err := bs.GetBudget(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := bs.status(budget, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.convertStatus(status, currency, asOf); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (bs *BudgetService) status(budget *models.Budget, asOf time.Time) (*BudgetStatus, error) {
+	index, ok := periodIndex(budget, asOf)
+	if !ok {
+		return nil, ErrBudgetNotStarted
+	}
+
+	var categoryIDs []int
+	if budget.CategoryID != nil {
+		ids, err := bs.CategoryService.DescendantIDs(budget.UserID, *budget.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs = ids
+	}
+
+	// One query covers the current period, and every earlier one when the
+	// budget rolls over; the days are then split by period
+	start, end := periodBounds(budget, index)
+	from := start
+	if budget.Rollover {
+		from, _ = periodBounds(budget, 0)
+	}
+	daily, err := models.SumExpensesByDay(budget.UserID, categoryIDs, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// With rollover, whatever was left unspent in earlier periods carries
+	// forward; overspending never carries a debt into the next period
+	rolledOver, _ := money.Zero(budget.Amount.Currency())
+	if budget.Rollover {
+		for i := 0; i < index; i++ {
+			_, periodEnd := periodBounds(budget, i)
+			n := sort.Search(len(daily), func(j int) bool { return !daily[j].Date.Before(periodEnd) })
+			spent, _, err := bs.spent(budget, daily[:n])
+			if err != nil {
+				return nil, err
+			}
+			daily = daily[n:]
+
+			available, err := budget.Amount.Add(rolledOver)
+			if err != nil {
+				return nil, err
+			}
+			left, err := available.Sub(spent)
+			if err != nil {
+				return nil, err
+			}
+			if left.IsNegative() {
+				left, _ = money.Zero(left.Currency())
+			}
+			rolledOver = left
+		}
+	}
+
+	spent, unconverted, err := bs.spent(budget, daily)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := budget.Amount.Add(rolledOver)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := available.Sub(spent)
+	if err != nil {
+		return nil, err
+	}
+
+	percentUsed := 0.0
+	if available.IsPositive() {
+		percentUsed = math.Round(float64(spent.Minor())/float64(available.Minor())*10000) / 100
+	}
+
+	return &BudgetStatus{
+		Budget:      budget,
+		PeriodStart: start,
+		PeriodEnd:   end.AddDate(0, 0, -1),
+		Limit:       budget.Amount,
+		RolledOver:  rolledOver,
+		Available:   available,
+		Spent:       spent,
+		Remaining:   remaining,
+		PercentUsed: percentUsed,
+		Overspent:   remaining.IsNegative(),
+		Unconverted: unconverted,
+	}, nil
+}
+
+// spent totals daily spending in the budget currency. Each day's spending is
+// converted at that day's rate; amounts without a rate are returned
+// separately instead of failing the whole status.
+func (bs *BudgetService) spent(budget *models.Budget, daily []models.DailySpending) (money.Amount, []money.Amount, error) {
+	total, _ := money.Zero(budget.Amount.Currency())
+
+	unconverted := map[string]money.Amount{}
+	for _, d := range daily {
+		var err error
+		if total, err = addConverted(bs.Converter, total, d.Amount, d.Date, unconverted); err != nil {
+			return total, nil, err
+		}
+	}
+	return total, sortedAmounts(unconverted), nil
+}
+
+// convertStatus expresses the amounts of a status in another currency.
+// Spending is first totalled at daily rates in the budget currency, so only
+// the final figures are converted here.
+func (bs *BudgetService) convertStatus(status *BudgetStatus, currency string, on time.Time) error {
+	if currency == "" || currency == status.Limit.Currency() {
+		return nil
+	}
+
+	for _, amount := range []*money.Amount{&status.Limit, &status.RolledOver, &status.Available, &status.Spent, &status.Remaining} {
+		converted, err := bs.Converter.Convert(*amount, currency, on)
+		if err != nil {
+			return err
+		}
+		*amount = converted
+	}
+	return nil
+}
+
+// periodIndex returns which period of the budget contains t, counting from 0.
+// Custom budgets have a single period; later dates report their last period.
+func periodIndex(budget *models.Budget, t time.Time) (int, bool) {
+	day := truncateToDay(t)
+	if day.Before(budget.StartDate) {
+		return 0, false
+	}
+
+	switch budget.Period {
+	case models.PeriodWeekly:
+		return int(day.Sub(budget.StartDate).Hours()/24) / 7, true
+	case models.PeriodMonthly:
+		index := (day.Year()-budget.StartDate.Year())*12 + int(day.Month()) - int(budget.StartDate.Month())
+		if start, _ := periodBounds(budget, index); day.Before(start) {
+			index--
+		}
+		return index, true
+	case models.PeriodYearly:
+		index := day.Year() - budget.StartDate.Year()
+		if start, _ := periodBounds(budget, index); day.Before(start) {
+			index--
+		}
+		return index, true
+	}
+	return 0, true
+}
+
+// periodBounds returns the half-open range [start, end) of the nth period of a budget
+func periodBounds(budget *models.Budget, n int) (time.Time, time.Time) {
+	start := budget.StartDate
+	switch budget.Period {
+	case models.PeriodWeekly:
+		return start.AddDate(0, 0, 7*n), start.AddDate(0, 0, 7*(n+1))
+	case models.PeriodMonthly:
+		return addMonths(start, n), addMonths(start, n+1)
+	case models.PeriodYearly:
+		return addMonths(start, 12*n), addMonths(start, 12*(n+1))
+	}
+
+	end := start.AddDate(0, 0, 1)
+	if budget.EndDate != nil {
+		end = budget.EndDate.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// addMonths adds n months to t, clamping the day to the end of shorter months
+// (a budget starting on Jan 31 has periods starting Feb 28, Mar 31, ...)
+func addMonths(t time.Time, n int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// truncateToDay drops the time of day, keeping the date in UTC like the DATE columns
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+var (
+	// ErrCategoryNotFound is returned when a category does not exist or belongs to another user
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrInvalidCategory is returned when a referenced category does not exist for the user
+	ErrInvalidCategory = errors.New("category does not exist")
+	// ErrCategoryCycle is returned when a category would become its own ancestor
+	ErrCategoryCycle = errors.New("a category cannot be moved under itself or one of its subcategories")
+	// ErrCategoryHasBudgets is returned when deleting a top-level category with
+	// budgets without saying which category the budgets move to
+	ErrCategoryHasBudgets = errors.New("category has budgets; choose a category to move them to")
+)
+
+// defaultCategories is the category tree every new user starts with
+var defaultCategories = []*models.Category{
+	{Name: "Food & Dining", Colour: "#FF7043", Icon: "restaurant", Children: []*models.Category{
+		{Name: "Groceries", Colour: "#FF8A65", Icon: "shopping-cart"},
+		{Name: "Restaurants", Colour: "#FFAB91", Icon: "utensils"},
+	}},
+	{Name: "Transport", Colour: "#42A5F5", Icon: "car", Children: []*models.Category{
+		{Name: "Fuel", Colour: "#64B5F6", Icon: "gas-pump"},
+		{Name: "Public Transport", Colour: "#90CAF9", Icon: "bus"},
+		{Name: "Taxi", Colour: "#BBDEFB", Icon: "taxi"},
+	}},
+	{Name: "Housing", Colour: "#8D6E63", Icon: "home", Children: []*models.Category{
+		{Name: "Rent", Colour: "#A1887F", Icon: "key"},
+		{Name: "Utilities", Colour: "#BCAAA4", Icon: "bolt"},
+	}},
+	{Name: "Health", Colour: "#66BB6A", Icon: "heart"},
+	{Name: "Entertainment", Colour: "#AB47BC", Icon: "film"},
+	{Name: "Shopping", Colour: "#EC407A", Icon: "shopping-bag"},
+	{Name: "Travel", Colour: "#26A69A", Icon: "plane"},
+	{Name: "Other", Colour: "#9E9E9E", Icon: "tag"},
+}
+
+type CategoryService struct{}
+
+func NewCategoryService() *CategoryService {
+	return &CategoryService{}
+}
+
+// SeedDefaultCategories creates the default category tree for a new user
+func (cs *CategoryService) SeedDefaultCategories(userID int) error {
+	return models.CreateCategoryTree(userID, defaultCategories)
+}
+
+// Tree returns the user's categories nested under their parents
+func (cs *CategoryService) Tree(userID int) ([]*models.Category, error) {
+	categories, err := models.ListCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := []*models.Category{}
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, c)
+		}
+	}
+	return roots, nil
+}
+
+// DescendantIDs returns the ID of a category followed by the IDs of all its subcategories
+func (cs *CategoryService) DescendantIDs(userID, id int) ([]int, error) {
+	categories, err := models.ListCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int)
+	found := false
+	for _, c := range categories {
+		if c.ID == id {
+			found = true
+		}
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	if !found {
+		return nil, ErrCategoryNotFound
+	}
+
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+func (cs *CategoryService) GetCategory(userID, id int) (*models.Category, error) {
+	category, err := models.GetCategoryByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	return category, nil
+}
+
+// ValidateCategory checks that an optional category reference belongs to the user
+func (cs *CategoryService) ValidateCategory(userID int, id *int) error {
+	if id == nil {
+		return nil
+	}
+
+	category, err := models.GetCategoryByID(userID, *id)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return ErrInvalidCategory
+	}
+
+	return nil
+}
+
+func (cs *CategoryService) CreateCategory(category *models.Category) (*models.Category, error) {
+	if err := cs.ValidateCategory(category.UserID, category.ParentID); err != nil {
+		return nil, err
+	}
+
+	categoryID, err := models.CreateCategory(category)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetCategoryByID(category.UserID, int(categoryID))
+}
+
+func (cs *CategoryService) UpdateCategory(category *models.Category) (*models.Category, error) {
+	if _, err := cs.GetCategory(category.UserID, category.ID); err != nil {
+		return nil, err
+	}
+
+	if category.ParentID != nil {
+		if err := cs.ValidateCategory(category.UserID, category.ParentID); err != nil {
+			return nil, err
+		}
+
+		descendants, err := cs.DescendantIDs(category.UserID, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range descendants {
+			if id == *category.ParentID {
+				return nil, ErrCategoryCycle
+			}
+		}
+	}
+
+	if err := models.UpdateCategory(category); err != nil {
+		return nil, err
+	}
+
+	return models.GetCategoryByID(category.UserID, category.ID)
+}
+
+// DeleteCategory removes a category. Its expenses, recurring expenses and
+// budgets move to its parent when reassignTo is nil, or to the category
+// reassignTo points at otherwise; its subcategories always move up to its
+// parent. A budget cannot be left without a category, as that would make it
+// cover all spending.
+func (cs *CategoryService) DeleteCategory(userID, id int, reassignTo *int) error {
+	category, err := cs.GetCategory(userID, id)
+	if err != nil {
+		return err
+	}
+
+	target := category.ParentID
+	if reassignTo != nil {
+		if *reassignTo == id {
+			return ErrInvalidCategory
+		}
+		if err := cs.ValidateCategory(userID, reassignTo); err != nil {
+			return err
+		}
+		target = reassignTo
+	}
+	if target == nil {
+		hasBudgets, err := models.CategoryHasBudgets(userID, id)
+		if err != nil {
+			return err
+		}
+		if hasBudgets {
+			return ErrCategoryHasBudgets
+		}
+	}
+
+	return models.DeleteCategory(category, target)
+}
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/export"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/storage"
+	"github.com/henok-tesfu/expense-manager/internal/takeout"
+)
+
+const (
+	// DataExportRetention is how long a ready export can be downloaded
+	DataExportRetention = 7 * 24 * time.Hour
+	// dataExportLinkTTL is how long a download link stays valid
+	dataExportLinkTTL = time.Hour
+	// dataExportTimeout is how long an export may run before it is considered abandoned
+	dataExportTimeout = time.Hour
+	// dataExportFailure is shown to users instead of the internal error
+	dataExportFailure = "The export could not be created; please request a new one"
+)
+
+var (
+	// ErrDataExportNotFound is returned when a data export does not exist or belongs to another user
+	ErrDataExportNotFound = errors.New("data export not found")
+	// ErrDataExportInProgress is returned when a user requests an export while another is pending or running
+	ErrDataExportInProgress = errors.New("a data export is already in progress")
+	// ErrInvalidDownloadLink is returned for download links that are tampered with, expired or for removed exports
+	ErrInvalidDownloadLink = errors.New("download link is invalid or has expired")
+)
+
+type DataExportService struct {
+	Storage storage.Storage
+	// LinkSecret signs download links
+	LinkSecret []byte
+}
+
+func NewDataExportService(store storage.Storage, linkSecret []byte) *DataExportService {
+	return &DataExportService{Storage: store, LinkSecret: linkSecret}
+}
+
+// RequestExport queues an export of all the data of a user
+func (ds *DataExportService) RequestExport(userID int) (*models.DataExport, error) {
+	unfinished, err := models.HasUnfinishedDataExport(userID)
+	if err != nil {
+		return nil, err
+	}
+	if unfinished {
+		return nil, ErrDataExportInProgress
+	}
+
+	id, err := models.CreateDataExport(userID)
+	if err != nil {
+		return nil, err
+	}
+	return ds.GetExport(userID, int(id))
+}
+
+// ListExports returns the exports of a user, with download links for the ready ones
+func (ds *DataExportService) ListExports(userID int) ([]*models.DataExport, error) {
+	exports, err := models.ListDataExports(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, e := range exports {
+		ds.sign(e, now)
+	}
+	return exports, nil
+}
+
+// GetExport returns an export of a user, with a download link once it is ready
+func (ds *DataExportService) GetExport(userID, id int) (*models.DataExport, error) {
+	e, err := models.GetDataExport(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrDataExportNotFound
+	}
+	ds.sign(e, time.Now())
+	return e, nil
+}
+
+// Open checks a signed download link and opens the archive it points to; the
+// caller must close it
+func (ds *DataExportService) Open(ctx context.Context, id int, expires, signature string) (*models.DataExport, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(ds.signature(id, expiresAt))) ||
+		time.Now().Unix() > expiresAt {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	e, err := models.GetDataExportByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e == nil || e.Status != models.DataExportReady || e.StorageKey == nil || e.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	file, err := ds.Storage.Get(ctx, *e.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, file, nil
+}
+
+// ProcessNext builds the archive of the oldest pending export and reports
+// whether there was one
+func (ds *DataExportService) ProcessNext(ctx context.Context) (bool, error) {
+	e, err := models.ClaimDataExport()
+	if err != nil || e == nil {
+		return false, err
+	}
+
+	if err := ds.build(ctx, e); err != nil {
+		log.Printf("Failed to build data export %d: %v", e.ID, err)
+		return true, models.FailDataExport(e.ID, dataExportFailure)
+	}
+	return true, nil
+}
+
+// Cleanup deletes expired exports, and exports of deleted users, with their
+// archives, and fails exports abandoned by a stopped instance
+func (ds *DataExportService) Cleanup(ctx context.Context) error {
+	now := time.Now().UTC()
+	if _, err := models.FailStaleDataExports(now.Add(-dataExportTimeout), dataExportFailure); err != nil {
+		return err
+	}
+
+	expired, err := models.ListExpiredDataExports(now)
+	if err != nil {
+		return err
+	}
+	for _, e := range expired {
+		if e.StorageKey != nil {
+			if err := ds.Storage.Delete(ctx, *e.StorageKey); err != nil {
+				return err
+			}
+		}
+		if err := models.DeleteDataExport(e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build writes the archive of an export to a temporary file, which is then
+// stored, so that large exports never sit in memory
+func (ds *DataExportService) build(ctx context.Context, e *models.DataExport) error {
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	archive := takeout.NewArchive(file)
+	if err := ds.writeArchive(ctx, archive, e.UserID); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key, err := dataExportKey(e.UserID, e.ID)
+	if err != nil {
+		return err
+	}
+	if err := ds.Storage.Put(ctx, key, file, size, "application/zip"); err != nil {
+		return err
+	}
+	if err := models.CompleteDataExport(e.ID, key, size, time.Now().Add(DataExportRetention).UTC()); err != nil {
+		ds.deleteBlob(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// writeArchive adds every file of the export of a user to the archive
+func (ds *DataExportService) writeArchive(ctx context.Context, archive *takeout.Archive, userID int) error {
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if err := archive.JSON("profile.json", user); err != nil {
+		return err
+	}
+
+	categories, err := models.ListCategories(userID)
+	if err != nil {
+		return err
+	}
+	accounts, err := models.ListAccounts(userID)
+	if err != nil {
+		return err
+	}
+	names := export.Names{Categories: export.CategoryPaths(categories), Accounts: map[int]string{}}
+	for _, account := range accounts {
+		names.Accounts[account.ID] = account.Name
+	}
+
+	if err := writeExpenses(archive, userID, names); err != nil {
+		return err
+	}
+	if err := writeCategories(archive, categories, names); err != nil {
+		return err
+	}
+	if err := writeAccounts(archive, accounts); err != nil {
+		return err
+	}
+	if err := writeBudgets(archive, userID, names); err != nil {
+		return err
+	}
+	if err := writeRecurringExpenses(archive, userID, names); err != nil {
+		return err
+	}
+	if err := ds.writeAttachments(ctx, archive, userID); err != nil {
+		return err
+	}
+	return writeAuditLog(archive, userID)
+}
+
+// writeExpenses adds the expenses as JSON and as CSV, reading them once per file
+func writeExpenses(archive *takeout.Archive, userID int, names export.Names) error {
+	expenses, err := archive.JSONArray("expenses.json")
+	if err != nil {
+		return err
+	}
+	if err := models.EachExpense(userID, models.ExpenseFilter{}, func(e *models.Expense) error {
+		return expenses.Write(e)
+	}); err != nil {
+		return err
+	}
+	if err := expenses.Close(); err != nil {
+		return err
+	}
+
+	w, err := archive.Create("expenses.csv")
+	if err != nil {
+		return err
+	}
+	writer, err := export.NewWriter(export.FormatCSV, w, names)
+	if err != nil {
+		return err
+	}
+	if err := models.EachExpense(userID, models.ExpenseFilter{}, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func writeCategories(archive *takeout.Archive, categories []*models.Category, names export.Names) error {
+	if err := archive.JSON("categories.json", categories); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(categories))
+	for _, c := range categories {
+		rows = append(rows, []string{strconv.Itoa(c.ID), c.Name, names.Categories[c.ID], optionalID(c.ParentID), c.Colour, c.Icon})
+	}
+	return archive.CSV("categories.csv", []string{"ID", "Name", "Path", "Parent ID", "Colour", "Icon"}, rows)
+}
+
+func writeAccounts(archive *takeout.Archive, accounts []*models.Account) error {
+	if err := archive.JSON("accounts.json", accounts); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(accounts))
+	for _, a := range accounts {
+		rows = append(rows, []string{strconv.Itoa(a.ID), a.Name, optionalString(a.Identifier), optionalString(a.Currency)})
+	}
+	return archive.CSV("accounts.csv", []string{"ID", "Name", "Identifier", "Currency"}, rows)
+}
+
+func writeBudgets(archive *takeout.Archive, userID int, names export.Names) error {
+	budgets, err := models.ListBudgets(userID)
+	if err != nil {
+		return err
+	}
+	if err := archive.JSON("budgets.json", budgets); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(budgets))
+	for _, b := range budgets {
+		end := ""
+		if b.EndDate != nil {
+			end = b.EndDate.Format("2006-01-02")
+		}
+		rows = append(rows, []string{strconv.Itoa(b.ID), b.Name, optionalName(names.Categories, b.CategoryID),
+			b.Amount.Decimal(), b.Amount.Currency(), b.Period, b.StartDate.Format("2006-01-02"), end,
+			strconv.FormatBool(b.Rollover)})
+	}
+	return archive.CSV("budgets.csv",
+		[]string{"ID", "Name", "Category", "Amount", "Currency", "Period", "Start Date", "End Date", "Rollover"}, rows)
+}
+
+func writeRecurringExpenses(archive *takeout.Archive, userID int, names export.Names) error {
+	recurring, err := models.ListRecurringExpenses(userID)
+	if err != nil {
+		return err
+	}
+	if err := archive.JSON("recurring_expenses.json", recurring); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(recurring))
+	for _, r := range recurring {
+		rows = append(rows, []string{strconv.Itoa(r.ID), r.Amount.Decimal(), r.Amount.Currency(),
+			optionalName(names.Categories, r.CategoryID), r.Payee, r.Description, r.RRule,
+			r.StartDate.Format("2006-01-02"), strconv.FormatBool(r.Active)})
+	}
+	return archive.CSV("recurring_expenses.csv",
+		[]string{"ID", "Amount", "Currency", "Category", "Payee", "Description", "Rule", "Start Date", "Active"}, rows)
+}
+
+// writeAttachments adds the attachment list and the original files, each
+// under attachments/<expense ID>/<attachment ID>-<filename>
+func (ds *DataExportService) writeAttachments(ctx context.Context, archive *takeout.Archive, userID int) error {
+	attachments, err := models.ListUserAttachments(userID)
+	if err != nil {
+		return err
+	}
+
+	type exportedAttachment struct {
+		*models.Attachment
+		Path string `json:"path"`
+	}
+	exported := make([]exportedAttachment, 0, len(attachments))
+	rows := make([][]string, 0, len(attachments))
+	for _, a := range attachments {
+		name := path.Join("attachments", strconv.Itoa(a.ExpenseID), fmt.Sprintf("%d-%s", a.ID, cleanFilename(a.Filename)))
+		exported = append(exported, exportedAttachment{Attachment: a, Path: name})
+		rows = append(rows, []string{strconv.Itoa(a.ID), strconv.Itoa(a.ExpenseID), a.Filename, a.ContentType,
+			strconv.FormatInt(a.Size, 10), name})
+	}
+	if err := archive.JSON("attachments.json", exported); err != nil {
+		return err
+	}
+	if err := archive.CSV("attachments.csv",
+		[]string{"ID", "Expense ID", "Filename", "Content Type", "Size", "Path"}, rows); err != nil {
+		return err
+	}
+
+	for _, a := range exported {
+		if err := ds.copyBlob(ctx, archive, a.Path, a.StorageKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyBlob adds a stored file to the archive; files missing from storage are skipped
+func (ds *DataExportService) copyBlob(ctx context.Context, archive *takeout.Archive, name, key string) error {
+	file, err := ds.Storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Data export skipped missing attachment file %s", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// auditEntry is an event of the audit log: a sign-in, a staff impersonation,
+// a revoked session or a failed login
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Detail    string    `json:"detail"`
+}
+
+// writeAuditLog adds the sessions and failed logins of a user as one log, newest first
+func writeAuditLog(archive *takeout.Archive, userID int) error {
+	sessions, err := models.ListSessions(userID)
+	if err != nil {
+		return err
+	}
+	failedLogins, err := models.ListFailedLogins(userID)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]auditEntry, 0, len(sessions)+len(failedLogins))
+	for _, s := range sessions {
+		event := "session_started"
+		if s.ImpersonatorID != nil {
+			event = "impersonation_started"
+		}
+		entries = append(entries, auditEntry{Time: s.CreatedAt, Event: event, IP: s.IP,
+			UserAgent: s.UserAgent, Detail: "session " + s.ID})
+		if s.RevokedAt != nil {
+			entries = append(entries, auditEntry{Time: *s.RevokedAt, Event: "session_revoked", IP: s.IP,
+				UserAgent: s.UserAgent, Detail: "session " + s.ID})
+		}
+	}
+	for _, f := range failedLogins {
+		entries = append(entries, auditEntry{Time: f.CreatedAt, Event: "login_failed", IP: f.IP,
+			UserAgent: f.UserAgent, Detail: f.Reason})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+
+	if err := archive.JSON("audit_log.json", entries); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, []string{e.Time.UTC().Format(time.RFC3339), e.Event, e.IP, e.UserAgent, e.Detail})
+	}
+	return archive.CSV("audit_log.csv", []string{"Time", "Event", "IP", "User Agent", "Detail"}, rows)
+}
+
+// sign sets the download link of a ready export
+func (ds *DataExportService) sign(e *models.DataExport, now time.Time) {
+	if e.Status != models.DataExportReady {
+		return
+	}
+	expires := now.Add(dataExportLinkTTL)
+	if e.ExpiresAt != nil && e.ExpiresAt.Before(expires) {
+		expires = *e.ExpiresAt
+	}
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", ds.signature(e.ID, expires.Unix()))
+	link := fmt.Sprintf("/api/exports/%d/download?%s", e.ID, query.Encode())
+	e.DownloadURL = &link
+}
+
+// signature is the hex HMAC-SHA256 of an export ID and the expiry of a link to it
+func (ds *DataExportService) signature(id int, expires int64) string {
+	mac := hmac.New(sha256.New, ds.LinkSecret)
+	fmt.Fprintf(mac, "%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (ds *DataExportService) deleteBlob(ctx context.Context, key string) {
+	if err := ds.Storage.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete data export file %s: %v", key, err)
+	}
+}
+
+// dataExportKey returns a new, unguessable storage key below the owner's prefix
+func dataExportKey(userID, exportID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("exports/%d/%d-%s.zip", userID, exportID, hex.EncodeToString(random)), nil
+}
+
+func optionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
+
+func optionalName(names map[int]string, id *int) string {
+	if id == nil {
+		return ""
+	}
+	return names[*id]
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/mailer"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+const (
+	// emailVerificationTokenTTL is how long a verification link works
+	emailVerificationTokenTTL = 24 * time.Hour
+	// emailVerificationResendInterval is the least time between two verification emails to a user
+	emailVerificationResendInterval = time.Minute
+)
+
+var (
+	// ErrInvalidVerificationToken is returned for verification tokens that are unknown, used or expired
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	// ErrEmailAlreadyVerified is returned when resending the verification email of a verified user
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	// ErrVerificationThrottled is returned when a verification email was sent too recently
+	ErrVerificationThrottled = errors.New("a verification email was sent recently; please wait before asking again")
+)
+
+// EmailVerificationService confirms that users own the email they registered with
+type EmailVerificationService struct {
+	Mailer mailer.Mailer
+	// VerifyURL is the verification endpoint; the token is added as the "token" query parameter
+	VerifyURL string
+}
+
+func NewEmailVerificationService(m mailer.Mailer, verifyURL string) *EmailVerificationService {
+	return &EmailVerificationService{Mailer: m, VerifyURL: verifyURL}
+}
+
+// SendVerification emails a verification link to a new user
+func (vs *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	if err := models.CreateEmailVerificationToken(user.ID, hashToken(token), now, now.Add(emailVerificationTokenTTL)); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(vs.VerifyURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return vs.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Expense Manager email",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to Expense Manager! To confirm that this is your email, "+
+			"open this link within %d hours:\n\n%s\n\nIf you did not sign up, ignore this email.\n",
+			user.Username, int(emailVerificationTokenTTL/time.Hour), link.String()),
+	})
+}
+
+// ResendVerification emails a new verification link to an unverified user,
+// at most once every emailVerificationResendInterval
+func (vs *EmailVerificationService) ResendVerification(ctx context.Context, userID int) error {
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	sentAt, err := models.LastEmailVerificationSentAt(userID)
+	if err != nil {
+		return err
+	}
+	if sentAt != nil && time.Since(*sentAt) < emailVerificationResendInterval {
+		return ErrVerificationThrottled
+	}
+
+	return vs.SendVerification(ctx, user)
+}
+
+// VerifyEmail marks the email of the token's user as verified
+func (vs *EmailVerificationService) VerifyEmail(token string) error {
+	ok, err := models.VerifyEmail(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// IsEmailVerified reports whether a user has verified their email
+func (vs *EmailVerificationService) IsEmailVerified(userID int) (bool, error) {
+	return models.IsEmailVerified(userID)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/ecb"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// ErrExchangeRateNotFound is returned when no rate is stored for a pair on or before a date
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+// RateImport summarizes an imported rate file
+type RateImport struct {
+	Imported int `json:"imported"`
+	// Skipped lists currencies in the file that are not current ISO 4217 codes
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type ExchangeRateService struct{}
+
+func NewExchangeRateService() *ExchangeRateService {
+	return &ExchangeRateService{}
+}
+
+// Converter returns a converter backed by the stored rates, crossing
+// currencies through EUR since that is what ECB files are quoted against
+func (es *ExchangeRateService) Converter() money.Converter {
+	return money.RateConverter{Rates: es, Pivot: ecb.Base}
+}
+
+// Rate implements money.RateSource using the nearest stored rate on or before the date
+func (es *ExchangeRateService) Rate(base, quote string, on time.Time) (*big.Rat, error) {
+	rate, err := models.FindExchangeRate(base, quote, on)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, money.ErrNoRate
+	}
+
+	value, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok {
+		return nil, errors.New("malformed exchange rate " + rate.Rate)
+	}
+	return value, nil
+}
+
+// GetRate returns the stored rate for the pair valid on the given date
+func (es *ExchangeRateService) GetRate(base, quote string, on time.Time) (*models.ExchangeRate, error) {
+	rate, err := models.FindExchangeRate(base, quote, on)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, ErrExchangeRateNotFound
+	}
+
+	return rate, nil
+}
+
+// Import stores the rates of an ECB-style file, quoted against base.
+// Re-importing a file replaces the rates it contains.
+func (es *ExchangeRateService) Import(r io.Reader, format, base string) (*RateImport, error) {
+	if !money.IsValidCurrency(base) {
+		return nil, money.ErrUnknownCurrency
+	}
+
+	parsed, err := ecb.Parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	skipped := map[string]bool{}
+	rates := []*models.ExchangeRate{}
+	for _, rate := range parsed {
+		if !money.IsValidCurrency(rate.Currency) {
+			skipped[rate.Currency] = true
+			continue
+		}
+		if rate.Currency == base {
+			continue
+		}
+		rates = append(rates, &models.ExchangeRate{
+			Date:  rate.Date,
+			Base:  base,
+			Quote: rate.Currency,
+			Rate:  rate.Rate,
+		})
+	}
+
+	if err := models.SaveExchangeRates(rates); err != nil {
+		return nil, err
+	}
+
+	result := &RateImport{Imported: len(rates)}
+	for currency := range skipped {
+		result.Skipped = append(result.Skipped, currency)
+	}
+	sort.Strings(result.Skipped)
+	return result, nil
+}
+
+// addConverted converts amount at the rate of the given day and adds it to
+// total. Amounts without a rate are collected per currency in unconverted
+// instead, so one missing rate does not fail a whole report.
+func addConverted(converter money.Converter, total, amount money.Amount, on time.Time, unconverted map[string]money.Amount) (money.Amount, error) {
+	converted, err := converter.Convert(amount, total.Currency(), on)
+	if errors.Is(err, money.ErrNoRate) {
+		sum, ok := unconverted[amount.Currency()]
+		if !ok {
+			sum, _ = money.Zero(amount.Currency())
+		}
+		unconverted[amount.Currency()], err = sum.Add(amount)
+		return total, err
+	}
+	if err != nil {
+		return total, err
+	}
+	return total.Add(converted)
+}
+
+// sortedAmounts lists the amounts of a per-currency map ordered by currency
+func sortedAmounts(amounts map[string]money.Amount) []money.Amount {
+	var sorted []money.Amount
+	for _, amount := range amounts {
+		sorted = append(sorted, amount)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Currency() < sorted[j].Currency() })
+	return sorted
+}
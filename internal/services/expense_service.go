@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/henok-tesfu/expense-manager/internal/export"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// ErrExpenseNotFound is returned when an expense does not exist or belongs to another user
+var ErrExpenseNotFound = errors.New("expense not found")
+
+type ExpenseService struct {
+	CategoryService   *CategoryService
+	AccountService    *AccountService
+	AttachmentService *AttachmentService
+}
+
+func NewExpenseService(categoryService *CategoryService, accountService *AccountService,
+	attachmentService *AttachmentService) *ExpenseService {
+	return &ExpenseService{
+		CategoryService:   categoryService,
+		AccountService:    accountService,
+		AttachmentService: attachmentService,
+	}
+}
+
+func (es *ExpenseService) CreateExpense(expense *models.Expense) (*models.Expense, error) {
+	if err := es.CategoryService.ValidateCategory(expense.UserID, expense.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := es.AccountService.ValidateAccount(expense.UserID, expense.AccountID); err != nil {
+		return nil, err
+	}
+
+	expenseID, err := models.CreateExpense(expense)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetExpenseByID(expense.UserID, int(expenseID))
+}
+
+// ListExpenses lists a user's expenses; filtering by a category includes its subcategories
+func (es *ExpenseService) ListExpenses(userID int, filter models.ExpenseFilter) ([]*models.Expense, error) {
+	filter, err := es.expandFilter(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ListExpenses(userID, filter)
+}
+
+// Export writes the expenses ListExpenses would return to w in the given
+// format, streaming them from the database one at a time
+func (es *ExpenseService) Export(userID int, filter models.ExpenseFilter, format string, w io.Writer) error {
+	categories, err := models.ListCategories(userID)
+	if err != nil {
+		return err
+	}
+	accounts, err := models.ListAccounts(userID)
+	if err != nil {
+		return err
+	}
+	names := export.Names{Categories: export.CategoryPaths(categories), Accounts: map[int]string{}}
+	for _, account := range accounts {
+		names.Accounts[account.ID] = account.Name
+	}
+
+	filter, err = es.expandFilter(userID, filter)
+	if err != nil {
+		return err
+	}
+
+	writer, err := export.NewWriter(format, w, names)
+	if err != nil {
+		return err
+	}
+	if err := models.EachExpense(userID, filter, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// expandFilter makes a category filter include the subcategories
+func (es *ExpenseService) expandFilter(userID int, filter models.ExpenseFilter) (models.ExpenseFilter, error) {
+	if len(filter.CategoryIDs) == 0 {
+		return filter, nil
+	}
+
+	expanded := []int{}
+	for _, id := range filter.CategoryIDs {
+		ids, err := es.CategoryService.DescendantIDs(userID, id)
+		if err != nil {
+			return filter, err
+		}
+		expanded = append(expanded, ids...)
+	}
+	filter.CategoryIDs = expanded
+	return filter, nil
+}
+
+func (es *ExpenseService) GetExpense(userID, id int) (*models.Expense, error) {
+	expense, err := models.GetExpenseByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if expense == nil {
+		return nil, ErrExpenseNotFound
+	}
+
+	return expense, nil
+}
+
+func (es *ExpenseService) UpdateExpense(expense *models.Expense) (*models.Expense, error) {
+	if _, err := es.GetExpense(expense.UserID, expense.ID); err != nil {
+		return nil, err
+	}
+
+	if err := es.CategoryService.ValidateCategory(expense.UserID, expense.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := es.AccountService.ValidateAccount(expense.UserID, expense.AccountID); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateExpense(expense); err != nil {
+		return nil, err
+	}
+
+	return models.GetExpenseByID(expense.UserID, expense.ID)
+}
+
+// DeleteExpense deletes an expense together with the files of its attachments
+func (es *ExpenseService) DeleteExpense(userID, id int) error {
+	attachments, err := models.ListAttachments(userID, id)
+	if err != nil {
+		return err
+	}
+
+	found, err := models.DeleteExpense(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrExpenseNotFound
+	}
+
+	es.AttachmentService.deleteBlobs(context.Background(), attachments)
+	return nil
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/henok-tesfu/expense-manager/internal/importer"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// ErrImportProfileNotFound is returned when an import profile does not exist or belongs to another user
+var ErrImportProfileNotFound = errors.New("import profile not found")
+
+// Import row statuses
+const (
+	// RowNew lines would become expenses; only reported by dry runs
+	RowNew = "new"
+	// RowImported lines became expenses
+	RowImported = "imported"
+	// RowDuplicate lines were imported before
+	RowDuplicate = "duplicate"
+	// RowSkipped lines are incoming money or zero amounts, which are not expenses
+	RowSkipped = "skipped"
+)
+
+// maxDescriptionColumns bounds how many columns a profile may join into the description
+const maxDescriptionColumns = 10
+
+// ImportOptions controls a statement import
+type ImportOptions struct {
+	Format    string
+	ProfileID *int
+	// CategoryID is assigned to every imported expense
+	CategoryID *int
+	// AccountID assigns every imported expense to one account instead of
+	// matching the account numbers found in the statement
+	AccountID *int
+	// Currency applies to statements that do not state their own. It
+	// defaults to the account's currency, then the user's home currency.
+	Currency string
+	// DryRun only reports what would be imported
+	DryRun bool
+}
+
+// ImportRow is one statement line with what the import did with it
+type ImportRow struct {
+	importer.Transaction
+	AccountID *int   `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+// ImportResult summarizes a statement import or its dry run
+type ImportResult struct {
+	DryRun     bool `json:"dry_run"`
+	Total      int  `json:"total"`
+	New        int  `json:"new"`
+	Imported   int  `json:"imported"`
+	Duplicates int  `json:"duplicates"`
+	Skipped    int  `json:"skipped"`
+	// NewAccounts lists the statement account numbers that had no account
+	// yet; a real import creates an account for each
+	NewAccounts []string     `json:"new_accounts"`
+	Rows        []*ImportRow `json:"rows"`
+}
+
+type ImportService struct {
+	CategoryService *CategoryService
+	AccountService  *AccountService
+}
+
+func NewImportService(categoryService *CategoryService, accountService *AccountService) *ImportService {
+	return &ImportService{
+		CategoryService: categoryService,
+		AccountService:  accountService,
+	}
+}
+
+// Import reads a bank statement and, unless it is a dry run, records its
+// outgoing transactions as expenses in one transaction. Lines imported
+// before are recognised by their hash and never recorded twice. Expenses are
+// assigned to the account whose identifier matches the statement's account
+// number, creating one for numbers seen for the first time.
+func (is *ImportService) Import(userID int, r io.Reader, options ImportOptions) (*ImportResult, error) {
+	if err := is.CategoryService.ValidateCategory(userID, options.CategoryID); err != nil {
+		return nil, err
+	}
+
+	var account *models.Account
+	if options.AccountID != nil {
+		var err error
+		if account, err = models.GetAccountByID(userID, *options.AccountID); err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, ErrInvalidAccount
+		}
+	}
+
+	currency, err := is.statementCurrency(userID, account, options.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile *models.ImportProfile
+	if options.ProfileID != nil {
+		if profile, err = is.GetImportProfile(userID, *options.ProfileID); err != nil {
+			return nil, err
+		}
+	}
+
+	transactions, err := importer.Parse(r, options.Format, profile, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs, newAccounts, err := is.matchAccounts(userID, transactions, account, options.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := importer.Hashes(transactions)
+	existing, err := models.ExistingImportHashes(userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{
+		DryRun:      options.DryRun,
+		Total:       len(transactions),
+		NewAccounts: newAccounts,
+		Rows:        []*ImportRow{},
+	}
+	expenses := []models.ImportedExpense{}
+	for i, t := range transactions {
+		row := &ImportRow{Transaction: t, AccountID: accountIDs[i], Status: RowNew}
+		switch {
+		case !t.Amount.IsPositive():
+			row.Status = RowSkipped
+			result.Skipped++
+		case existing[hashes[i]]:
+			row.Status = RowDuplicate
+			result.Duplicates++
+		default:
+			// The same line twice in one file gets two different hashes, so
+			// only lines already stored count as duplicates
+			result.New++
+			expenses = append(expenses, models.ImportedExpense{
+				Expense: &models.Expense{
+					UserID:      userID,
+					Amount:      t.Amount,
+					Date:        t.Date,
+					Description: t.Description,
+					CategoryID:  options.CategoryID,
+					AccountID:   accountIDs[i],
+					Payee:       t.Payee,
+				},
+				Hash: hashes[i],
+			})
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if options.DryRun || len(expenses) == 0 {
+		return result, nil
+	}
+
+	inserted, err := models.CreateImportedExpenses(expenses)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = int(inserted)
+	for _, row := range result.Rows {
+		if row.Status == RowNew {
+			row.Status = RowImported
+		}
+	}
+	// Lines imported concurrently by another request were not inserted again
+	result.Duplicates += result.New - result.Imported
+	result.New = 0
+	return result, nil
+}
+
+// statementCurrency picks the currency of statements that do not state their own
+func (is *ImportService) statementCurrency(userID int, account *models.Account, currency string) (string, error) {
+	if currency != "" {
+		return currency, nil
+	}
+	if account != nil && account.Currency != nil {
+		return *account.Currency, nil
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+	return user.HomeCurrency, nil
+}
+
+// matchAccounts returns the account of every transaction: the given account
+// when there is one, otherwise the user's account with the transaction's
+// account number. Unknown account numbers are returned in newAccounts and,
+// unless it is a dry run, get an account named after them.
+func (is *ImportService) matchAccounts(userID int, transactions []importer.Transaction, account *models.Account,
+	dryRun bool) (accountIDs []*int, newAccounts []string, err error) {
+	accountIDs = make([]*int, len(transactions))
+	newAccounts = []string{}
+	if account != nil {
+		for i := range accountIDs {
+			accountIDs[i] = &account.ID
+		}
+		return accountIDs, newAccounts, nil
+	}
+
+	matched := map[string]*int{}
+	for i, t := range transactions {
+		if t.Account == "" {
+			continue
+		}
+		id, seen := matched[t.Account]
+		if !seen {
+			var created bool
+			if id, created, err = is.matchAccount(userID, t, dryRun); err != nil {
+				return nil, nil, err
+			}
+			if created {
+				newAccounts = append(newAccounts, t.Account)
+			}
+			matched[t.Account] = id
+		}
+		accountIDs[i] = id
+	}
+	return accountIDs, newAccounts, nil
+}
+
+// matchAccount returns the ID of the user's account with the transaction's
+// account number, creating the account when there is none. A dry run only
+// reports that it would be created.
+func (is *ImportService) matchAccount(userID int, t importer.Transaction, dryRun bool) (*int, bool, error) {
+	account, err := models.GetAccountByIdentifier(userID, t.Account)
+	if err != nil {
+		return nil, false, err
+	}
+	if account != nil {
+		return &account.ID, false, nil
+	}
+	if dryRun {
+		return nil, true, nil
+	}
+
+	// Accounts are created outside the expense transaction; should the import
+	// fail they are simply matched by the next attempt
+	identifier, currency := t.Account, t.Amount.Currency()
+	accountID, created, err := models.EnsureAccount(&models.Account{
+		UserID:     userID,
+		Name:       identifier,
+		Identifier: &identifier,
+		Currency:   &currency,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	id := int(accountID)
+	return &id, created, nil
+}
+
+func (is *ImportService) CreateImportProfile(profile *models.ImportProfile) (*models.ImportProfile, error) {
+	if err := validateImportProfile(profile); err != nil {
+		return nil, err
+	}
+
+	profileID, err := models.CreateImportProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetImportProfileByID(profile.UserID, int(profileID))
+}
+
+func (is *ImportService) ListImportProfiles(userID int) ([]*models.ImportProfile, error) {
+	return models.ListImportProfiles(userID)
+}
+
+func (is *ImportService) GetImportProfile(userID, id int) (*models.ImportProfile, error) {
+	profile, err := models.GetImportProfileByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, ErrImportProfileNotFound
+	}
+
+	return profile, nil
+}
+
+func (is *ImportService) UpdateImportProfile(profile *models.ImportProfile) (*models.ImportProfile, error) {
+	if _, err := is.GetImportProfile(profile.UserID, profile.ID); err != nil {
+		return nil, err
+	}
+
+	if err := validateImportProfile(profile); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateImportProfile(profile); err != nil {
+		return nil, err
+	}
+
+	return models.GetImportProfileByID(profile.UserID, profile.ID)
+}
+
+func (is *ImportService) DeleteImportProfile(userID, id int) error {
+	found, err := models.DeleteImportProfile(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrImportProfileNotFound
+	}
+
+	return nil
+}
+
+// validateImportProfile checks what the input validation cannot: the date format
+func validateImportProfile(profile *models.ImportProfile) error {
+	if _, err := importer.DateLayout(profile.DateFormat); err != nil {
+		return fmt.Errorf("%w: %v", importer.ErrInvalidMapping, err)
+	}
+	if len(profile.DescriptionColumns) > maxDescriptionColumns {
+		return fmt.Errorf("%w: at most %d description columns are supported", importer.ErrInvalidMapping, maxDescriptionColumns)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/loginguard"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// ErrTooManyLoginAttempts is wrapped by LoginThrottledError
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+
+// LoginThrottledError is returned for logins of an account or IP address
+// that has to wait after failed attempts
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v; try again in %d seconds", ErrTooManyLoginAttempts, e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds the wait up to whole seconds, as in a Retry-After header
+func (e *LoginThrottledError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// LoginGuardService throttles logins after failed attempts and keeps an audit record of them
+type LoginGuardService struct {
+	Guard *loginguard.Guard
+}
+
+func NewLoginGuardService(guard *loginguard.Guard) *LoginGuardService {
+	return &LoginGuardService{Guard: guard}
+}
+
+// Attempt counts a login of email from device before its credentials are
+// checked and returns a LoginThrottledError when it has to wait. Otherwise
+// it returns how long the next attempt has to wait if this one fails.
+func (ls *LoginGuardService) Attempt(ctx context.Context, email string, device jwt.Device) (time.Duration, error) {
+	wait, next, err := ls.Guard.Attempt(ctx, email, device.IP)
+	if err != nil {
+		return 0, err
+	}
+	if wait > 0 {
+		ls.audit(email, device, models.FailedLoginLockedOut)
+		return 0, &LoginThrottledError{RetryAfter: wait}
+	}
+	return next, nil
+}
+
+// Failed records a failed login for reason, one of the models.FailedLogin
+// reasons; Attempt has already counted it
+func (ls *LoginGuardService) Failed(email string, device jwt.Device, reason string) {
+	ls.audit(email, device, reason)
+}
+
+// PasswordAccepted takes back the attempt counted for the address of a login
+// whose password was right but which still needs a two-factor code
+func (ls *LoginGuardService) PasswordAccepted(ctx context.Context, device jwt.Device) error {
+	return ls.Guard.Release(ctx, device.IP)
+}
+
+// Succeeded forgets the failed logins of an account and takes back the
+// attempt counted for the address of the login
+func (ls *LoginGuardService) Succeeded(ctx context.Context, email string, device jwt.Device) error {
+	return ls.Guard.Succeed(ctx, email, device.IP)
+}
+
+// audit records a failed login; a failure to do so does not change the outcome of the login
+func (ls *LoginGuardService) audit(email string, device jwt.Device, reason string) {
+	err := models.RecordFailedLogin(&models.FailedLogin{
+		Email:     email,
+		IP:        device.IP,
+		UserAgent: device.UserAgent,
+		Reason:    reason,
+	})
+	if err != nil {
+		log.Printf("Failed to record failed login: %v", err)
+	}
+}
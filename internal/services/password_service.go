@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/mailer"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/password"
+)
+
+const (
+	// passwordResetTokenTTL is how long a password reset link works
+	passwordResetTokenTTL = time.Hour
+	// passwordResetResendInterval is the least time between two reset emails to a user
+	passwordResetResendInterval = 5 * time.Minute
+	// passwordResetSendTimeout bounds sending a reset email in the background
+	passwordResetSendTimeout = time.Minute
+)
+
+var (
+	// ErrInvalidResetToken is returned for reset tokens that are unknown, used or expired
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+// PasswordService lets users who forgot their password set a new one
+// through a single-use link sent by email
+type PasswordService struct {
+	Mailer         mailer.Mailer
+	SessionService *SessionService
+	Hasher         password.Hasher
+	Policy         *password.Policy
+	// ResetURL is the page of the frontend that reads the token from the
+	// "token" query parameter and submits the new password
+	ResetURL string
+}
+
+func NewPasswordService(m mailer.Mailer, sessionService *SessionService, hasher password.Hasher,
+	policy *password.Policy, resetURL string) *PasswordService {
+	return &PasswordService{Mailer: m, SessionService: sessionService, Hasher: hasher, Policy: policy,
+		ResetURL: resetURL}
+}
+
+// ForgotPassword emails a reset link to the user with the given email, at
+// most once every passwordResetResendInterval. It returns at once and does
+// the work in the background, so that neither the outcome nor the time it
+// takes tells callers which emails are registered; failures are logged.
+func (ps *PasswordService) ForgotPassword(email string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetSendTimeout)
+		defer cancel()
+		if err := ps.sendResetLink(ctx, email); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+}
+
+// sendResetLink emails a reset link to the user with the given email unless
+// one was sent recently. An unknown email is not an error.
+func (ps *PasswordService) sendResetLink(ctx context.Context, email string) error {
+	user, err := models.GetUserByEmail(email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	sentAt, err := models.LastPasswordResetSentAt(user.ID)
+	if err != nil {
+		return err
+	}
+	if sentAt != nil && time.Since(*sentAt) < passwordResetResendInterval {
+		return nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	expiresAt := time.Now().Add(passwordResetTokenTTL).UTC()
+	if err := models.CreatePasswordResetToken(user.ID, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(ps.ResetURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return ps.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Expense Manager password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your Expense Manager account. "+
+			"To choose a new password, open this link within %d minutes:\n\n%s\n\n"+
+			"If it was not you, ignore this email; your password stays the same.\n",
+			user.Username, int(passwordResetTokenTTL/time.Minute), link.String()),
+	})
+}
+
+// ResetPassword sets a new password with a reset token and signs the user
+// out of every session
+func (ps *PasswordService) ResetPassword(token, password string) error {
+	user, err := models.GetPasswordResetUser(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+	if err := ps.Policy.Check(password, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	passwordHash, err := ps.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	sessionIDs, ok, err := models.ResetPassword(hashToken(token), passwordHash)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+	ps.SessionService.forget(sessionIDs...)
+	return nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// Scopes of personal access tokens
+const (
+	// ScopeExpensesRead reads expenses, their attachments, recurring expenses, categories, accounts and exchange rates
+	ScopeExpensesRead = "expenses:read"
+	// ScopeExpensesWrite creates, changes and imports expenses and the data they reference
+	ScopeExpensesWrite = "expenses:write"
+	// ScopeReportsRead reads reports and budgets with their status
+	ScopeReportsRead = "reports:read"
+	// ScopeBudgetsWrite creates and changes budgets
+	ScopeBudgetsWrite = "budgets:write"
+)
+
+// Scopes lists every scope a personal access token can be given
+var Scopes = []string{ScopeExpensesRead, ScopeExpensesWrite, ScopeReportsRead, ScopeBudgetsWrite}
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells
+// them apart from JWTs and makes leaked tokens easy to search for
+const PersonalAccessTokenPrefix = "emp_"
+
+// personalTokenTouchInterval limits how often the last use of a token is written
+const personalTokenTouchInterval = time.Minute
+
+var (
+	// ErrPersonalTokenNotFound is returned when a token does not exist or belongs to another user
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	// ErrUnknownScope is returned for scopes that are not in Scopes
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrTokenExpiryInPast is returned when a token would be expired on creation
+	ErrTokenExpiryInPast = errors.New("expiry must be in the future")
+	// ErrInvalidPersonalToken is returned for tokens that are unknown or expired
+	ErrInvalidPersonalToken = errors.New("invalid or expired personal access token")
+)
+
+type PersonalAccessTokenService struct{}
+
+func NewPersonalAccessTokenService() *PersonalAccessTokenService {
+	return &PersonalAccessTokenService{}
+}
+
+// CreateToken creates a personal access token and returns it together with
+// the token itself, which is not stored and cannot be shown again
+func (ps *PersonalAccessTokenService) CreateToken(t *models.PersonalAccessToken) (*models.PersonalAccessToken, string, error) {
+	scopes, err := normalizeScopes(t.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	t.Scopes = scopes
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrTokenExpiryInPast
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	t.TokenHash = hashToken(token)
+	t.Prefix = token[:len(PersonalAccessTokenPrefix)+6]
+	if t.ExpiresAt != nil {
+		expiresAt := t.ExpiresAt.UTC()
+		t.ExpiresAt = &expiresAt
+	}
+
+	id, err := models.CreatePersonalAccessToken(t)
+	if err != nil {
+		return nil, "", err
+	}
+
+	created, err := models.GetPersonalAccessToken(t.UserID, int(id))
+	if err != nil {
+		return nil, "", err
+	}
+	return created, token, nil
+}
+
+func (ps *PersonalAccessTokenService) ListTokens(userID int) ([]*models.PersonalAccessToken, error) {
+	return models.ListPersonalAccessTokens(userID)
+}
+
+// RevokeToken deletes a personal access token; it stops working at once
+func (ps *PersonalAccessTokenService) RevokeToken(userID, id int) error {
+	deleted, err := models.DeletePersonalAccessToken(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+// AuthenticatePersonalToken returns the user and scopes of a valid token and records its use
+func (ps *PersonalAccessTokenService) AuthenticatePersonalToken(token string) (int, []string, error) {
+	if !strings.HasPrefix(token, PersonalAccessTokenPrefix) {
+		return 0, nil, ErrInvalidPersonalToken
+	}
+
+	t, err := models.GetPersonalAccessTokenByHash(hashToken(token))
+	if err != nil {
+		return 0, nil, err
+	}
+	now := time.Now()
+	if t == nil || (t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)) {
+		return 0, nil, ErrInvalidPersonalToken
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= personalTokenTouchInterval {
+		if err := models.TouchPersonalAccessToken(t.ID, now.UTC()); err != nil {
+			return 0, nil, err
+		}
+	}
+	return t.UserID, t.Scopes, nil
+}
+
+// normalizeScopes rejects unknown scopes and drops repeated ones
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrUnknownScope)
+	}
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		known := false
+		for _, s := range Scopes {
+			known = known || s == scope
+		}
+		if !known {
+			return nil, fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// hashToken returns the SHA-256 hash of a token in hex. Tokens are
+// random and long, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/recurrence"
+)
+
+var (
+	// ErrRecurringExpenseNotFound is returned when a recurring expense does not exist or belongs to another user
+	ErrRecurringExpenseNotFound = errors.New("recurring expense not found")
+	// ErrNotAnOccurrence is returned when a date is not an occurrence of a recurring expense
+	ErrNotAnOccurrence = errors.New("date is not an occurrence of the recurring expense")
+	// ErrInvalidRecurrenceRule is returned when an RRULE cannot be parsed
+	ErrInvalidRecurrenceRule = errors.New("invalid recurrence rule")
+)
+
+// Occurrence is one instance of a recurring expense, with any exception applied
+type Occurrence struct {
+	OccurrenceDate time.Time       `json:"occurrence_date"`
+	Skipped        bool            `json:"skipped"`
+	Materialized   bool            `json:"materialized"`
+	Expense        *models.Expense `json:"expense,omitempty"`
+}
+
+type RecurringService struct {
+	CategoryService *CategoryService
+	ExpenseService  *ExpenseService
+}
+
+func NewRecurringService(categoryService *CategoryService, expenseService *ExpenseService) *RecurringService {
+	return &RecurringService{
+		CategoryService: categoryService,
+		ExpenseService:  expenseService,
+	}
+}
+
+func (rs *RecurringService) CreateRecurringExpense(recurring *models.RecurringExpense) (*models.RecurringExpense, error) {
+	if err := rs.validate(recurring); err != nil {
+		return nil, err
+	}
+
+	recurringID, err := models.CreateRecurringExpense(recurring)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.GetRecurringExpenseByID(recurring.UserID, int(recurringID))
+}
+
+func (rs *RecurringService) ListRecurringExpenses(userID int) ([]*models.RecurringExpense, error) {
+	return models.ListRecurringExpenses(userID)
+}
+
+func (rs *RecurringService) GetRecurringExpense(userID, id int) (*models.RecurringExpense, error) {
+	recurring, err := models.GetRecurringExpenseByID(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if recurring == nil {
+		return nil, ErrRecurringExpenseNotFound
+	}
+
+	return recurring, nil
+}
+
+// UpdateRecurringExpense changes a recurring expense. The change applies to
+// occurrences that have not been materialized yet.
+func (rs *RecurringService) UpdateRecurringExpense(recurring *models.RecurringExpense) (*models.RecurringExpense, error) {
+	if _, err := rs.GetRecurringExpense(recurring.UserID, recurring.ID); err != nil {
+		return nil, err
+	}
+
+	if err := rs.validate(recurring); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateRecurringExpense(recurring); err != nil {
+		return nil, err
+	}
+
+	return models.GetRecurringExpenseByID(recurring.UserID, recurring.ID)
+}
+
+func (rs *RecurringService) DeleteRecurringExpense(userID, id int) error {
+	found, err := models.DeleteRecurringExpense(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrRecurringExpenseNotFound
+	}
+
+	return nil
+}
+
+// Preview returns the next n occurrences on or after from, as they would be recorded
+func (rs *RecurringService) Preview(userID, id int, from time.Time, n int) ([]*Occurrence, error) {
+	recurring, err := rs.GetRecurringExpense(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := recurrence.Parse(recurring.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions, err := models.ListOccurrenceExceptions(recurring.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []*Occurrence{}
+	for _, date := range rule.Next(recurring.StartDate, from, n) {
+		occurrence := &Occurrence{
+			OccurrenceDate: date,
+			Materialized:   recurring.MaterializedThrough != nil && !date.After(*recurring.MaterializedThrough),
+		}
+		if exception := exceptions[date.Format(dateKeyLayout)]; exception != nil && exception.Skipped {
+			occurrence.Skipped = true
+		} else {
+			occurrence.Expense = buildOccurrenceExpense(recurring, date, exception)
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+// SkipOccurrence makes sure a single occurrence never becomes an expense,
+// deleting the expense if it was already materialized
+func (rs *RecurringService) SkipOccurrence(userID, id int, date time.Time) error {
+	recurring, err := rs.occurrenceOf(userID, id, date)
+	if err != nil {
+		return err
+	}
+
+	if err := models.SaveOccurrenceException(&models.OccurrenceException{
+		RecurringExpenseID: recurring.ID,
+		OccurrenceDate:     date,
+		Skipped:            true,
+	}); err != nil {
+		return err
+	}
+
+	expense, err := models.GetExpenseByOccurrence(userID, recurring.ID, date)
+	if err != nil || expense == nil {
+		return err
+	}
+	return rs.ExpenseService.DeleteExpense(userID, expense.ID)
+}
+
+// EditOccurrence overrides the fields of a single occurrence, updating the
+// expense too if the occurrence was already materialized
+func (rs *RecurringService) EditOccurrence(userID, id int, override *models.OccurrenceException) (*Occurrence, error) {
+	recurring, err := rs.occurrenceOf(userID, id, override.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.CategoryService.ValidateCategory(userID, override.CategoryID); err != nil {
+		return nil, err
+	}
+
+	override.RecurringExpenseID = recurring.ID
+	override.Skipped = false
+	if err := models.SaveOccurrenceException(override); err != nil {
+		return nil, err
+	}
+
+	occurrence := &Occurrence{
+		OccurrenceDate: override.OccurrenceDate,
+		Expense:        buildOccurrenceExpense(recurring, override.OccurrenceDate, override),
+	}
+
+	expense, err := models.GetExpenseByOccurrence(userID, recurring.ID, override.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	if expense != nil {
+		// The series has no account, so keep the one given to the expense
+		occurrence.Expense.ID = expense.ID
+		occurrence.Expense.AccountID = expense.AccountID
+		if err := models.UpdateExpense(occurrence.Expense); err != nil {
+			return nil, err
+		}
+		occurrence.Materialized = true
+	}
+	return occurrence, nil
+}
+
+// RestoreOccurrence removes the skip or override of a single occurrence. A
+// restored past occurrence is materialized right away.
+func (rs *RecurringService) RestoreOccurrence(userID, id int, date time.Time) error {
+	recurring, err := rs.occurrenceOf(userID, id, date)
+	if err != nil {
+		return err
+	}
+
+	if _, err := models.DeleteOccurrenceException(recurring.ID, date); err != nil {
+		return err
+	}
+
+	if recurring.MaterializedThrough == nil || date.After(*recurring.MaterializedThrough) {
+		return nil
+	}
+	expense := buildOccurrenceExpense(recurring, date, nil)
+	return models.MaterializeOccurrences(recurring, []*models.Expense{expense}, *recurring.MaterializedThrough)
+}
+
+// MaterializeDue records every due occurrence of every active recurring
+// expense up to and including today. Each recurring expense continues from
+// where it last stopped, so missed days are caught up after downtime, and
+// occurrences that already have an expense are never recorded twice.
+func (rs *RecurringService) MaterializeDue(today time.Time) error {
+	today = recurrence.Day(today)
+
+	due, err := models.ListDueRecurringExpenses(today)
+	if err != nil {
+		return err
+	}
+
+	for _, recurring := range due {
+		if err := rs.materialize(recurring, today); err != nil {
+			log.Printf("Failed to materialize recurring expense %d: %v", recurring.ID, err)
+		}
+	}
+	return nil
+}
+
+func (rs *RecurringService) materialize(recurring *models.RecurringExpense, through time.Time) error {
+	rule, err := recurrence.Parse(recurring.RRule)
+	if err != nil {
+		return err
+	}
+
+	exceptions, err := models.ListOccurrenceExceptions(recurring.ID)
+	if err != nil {
+		return err
+	}
+
+	from := recurring.StartDate
+	if recurring.MaterializedThrough != nil {
+		from = recurring.MaterializedThrough.AddDate(0, 0, 1)
+	}
+
+	expenses := []*models.Expense{}
+	for _, date := range rule.Between(recurring.StartDate, from, through) {
+		exception := exceptions[date.Format(dateKeyLayout)]
+		if exception != nil && exception.Skipped {
+			continue
+		}
+		expenses = append(expenses, buildOccurrenceExpense(recurring, date, exception))
+	}
+
+	return models.MaterializeOccurrences(recurring, expenses, through)
+}
+
+// occurrenceOf loads a recurring expense and checks that date is one of its occurrences
+func (rs *RecurringService) occurrenceOf(userID, id int, date time.Time) (*models.RecurringExpense, error) {
+	recurring, err := rs.GetRecurringExpense(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := recurrence.Parse(recurring.RRule)
+	if err != nil {
+		return nil, err
+	}
+	if len(rule.Between(recurring.StartDate, date, date)) == 0 {
+		return nil, ErrNotAnOccurrence
+	}
+
+	return recurring, nil
+}
+
+// validate normalizes the RRULE and checks the category of a recurring expense
+func (rs *RecurringService) validate(recurring *models.RecurringExpense) error {
+	rule, err := recurrence.Parse(recurring.RRule)
+	if err != nil {
+		return ErrInvalidRecurrenceRule
+	}
+	recurring.RRule = rule.String()
+
+	return rs.CategoryService.ValidateCategory(recurring.UserID, recurring.CategoryID)
+}
+
+// dateKeyLayout formats dates as keys of the occurrence exception map
+const dateKeyLayout = "2006-01-02"
+
+// buildOccurrenceExpense builds the expense for one occurrence, applying an optional exception
+func buildOccurrenceExpense(recurring *models.RecurringExpense, date time.Time, exception *models.OccurrenceException) *models.Expense {
+	recurringID := recurring.ID
+	occurrenceDate := date
+	expense := &models.Expense{
+		UserID:             recurring.UserID,
+		Amount:             recurring.Amount,
+		Date:               date,
+		Description:        recurring.Description,
+		CategoryID:         recurring.CategoryID,
+		Payee:              recurring.Payee,
+		RecurringExpenseID: &recurringID,
+		OccurrenceDate:     &occurrenceDate,
+	}
+
+	if exception == nil {
+		return expense
+	}
+	if exception.Amount != nil {
+		expense.Amount = *exception.Amount
+	}
+	if exception.Date != nil {
+		expense.Date = *exception.Date
+	}
+	if exception.CategoryID != nil {
+		expense.CategoryID = exception.CategoryID
+	}
+	if exception.Description != nil {
+		expense.Description = *exception.Description
+	}
+	if exception.Payee != nil {
+		expense.Payee = *exception.Payee
+	}
+	return expense
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+)
+
+// uncategorized names the group of expenses without a category in reports
+const uncategorized = "Uncategorized"
+
+// SpendingReport totals a user's spending over a date range in one currency
+type SpendingReport struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Total      money.Amount        `json:"total"`
+	Categories []*CategorySpending `json:"categories"`
+	// Unconverted lists spending that could not be converted for lack of an exchange rate
+	Unconverted []money.Amount `json:"unconverted,omitempty"`
+}
+
+// CategorySpending is the converted total of one category
+type CategorySpending struct {
+	CategoryID *int         `json:"category_id"`
+	Name       string       `json:"name"`
+	Total      money.Amount `json:"total"`
+}
+
+type ReportService struct {
+	Converter money.Converter
+}
+
+func NewReportService(converter money.Converter) *ReportService {
+	return &ReportService{Converter: converter}
+}
+
+// Spending totals the user's expenses from from to to (both inclusive) per
+// category. Each day's spending is converted at that day's rate into currency,
+// or into the user's home currency when currency is empty.
+func (rs *ReportService) Spending(userID int, from, to time.Time, currency string) (*SpendingReport, error) {
+	if currency == "" {
+		user, err := models.GetUserByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		currency = user.HomeCurrency
+	}
+
+	total, err := money.Zero(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := models.SumExpensesByCategoryAndDay(userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := models.ListCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	byCategory := map[int]*CategorySpending{}
+	unconverted := map[string]money.Amount{}
+	for _, d := range daily {
+		key := 0
+		if d.CategoryID != nil {
+			key = *d.CategoryID
+		}
+		spending, ok := byCategory[key]
+		if !ok {
+			spending = &CategorySpending{CategoryID: d.CategoryID, Name: uncategorized, Total: total}
+			if d.CategoryID != nil {
+				spending.Name = names[*d.CategoryID]
+			}
+			byCategory[key] = spending
+		}
+
+		if spending.Total, err = addConverted(rs.Converter, spending.Total, d.Amount, d.Date, unconverted); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &SpendingReport{From: from, To: to, Categories: []*CategorySpending{}}
+	for _, spending := range byCategory {
+		if total, err = total.Add(spending.Total); err != nil {
+			return nil, err
+		}
+		report.Categories = append(report.Categories, spending)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i].Total.Minor(), report.Categories[j].Total.Minor()
+		if a != b {
+			return a > b
+		}
+		return report.Categories[i].Name < report.Categories[j].Name
+	})
+	report.Total = total
+	report.Unconverted = sortedAmounts(unconverted)
+	return report, nil
+}
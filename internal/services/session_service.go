@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
+	"github.com/henok-tesfu/expense-manager/internal/models"
+)
+
+// ErrSessionNotFound is returned when a session does not exist, has ended or belongs to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// sessionCacheTTL is how long the state of a session is trusted without
+	// asking the database. A session ended on another instance keeps working
+	// there for at most this long.
+	sessionCacheTTL = 30 * time.Second
+	// sessionCacheSize bounds the number of cached sessions
+	sessionCacheSize = 10000
+)
+
+// cachedSession is the state of a session at the time it was looked up
+type cachedSession struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// SessionService manages the logins of users and the refresh tokens rotated
+// from them. It implements jwt.SessionStore, caching whether sessions are
+// active so that authenticating a request rarely needs the database.
+type SessionService struct {
+	mu    sync.Mutex
+	cache map[string]cachedSession
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{cache: map[string]cachedSession{}}
+}
+
+// StartSession records a new session together with its first refresh token
+func (s *SessionService) StartSession(sessionID, tokenID string, userID int, expiresAt time.Time, device jwt.Device) error {
+	now := time.Now().UTC()
+	return models.CreateSession(&models.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt.UTC(),
+	}, &models.RefreshToken{
+		ID:        tokenID,
+		UserID:    userID,
+		FamilyID:  sessionID,
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// StartImpersonation records a session in which a staff user acts as the
+// user; it has no refresh token
+func (s *SessionService) StartImpersonation(sessionID string, userID, impersonatorID int, expiresAt time.Time, device jwt.Device) error {
+	return models.CreateImpersonationSession(&models.Session{
+		ID:             sessionID,
+		UserID:         userID,
+		UserAgent:      device.UserAgent,
+		IP:             device.IP,
+		LastUsedAt:     time.Now().UTC(),
+		ExpiresAt:      expiresAt.UTC(),
+		ImpersonatorID: &impersonatorID,
+	})
+}
+
+// Rotate replaces the refresh token oldID with newID in the same session and
+// returns the session ID. A token that was already replaced ends its session.
+func (s *SessionService) Rotate(oldID, newID string, expiresAt time.Time, device jwt.Device) (string, error) {
+	old, err := models.GetRefreshToken(oldID)
+	if err != nil {
+		return "", err
+	}
+	if old == nil {
+		return "", jwt.ErrTokenRevoked
+	}
+
+	result, err := models.RotateRefreshToken(oldID, &models.RefreshToken{ID: newID, ExpiresAt: expiresAt.UTC()},
+		device.UserAgent, device.IP)
+	if err != nil {
+		return "", err
+	}
+
+	switch result {
+	case models.RotationReused:
+		s.forget(old.FamilyID)
+		return "", jwt.ErrTokenReused
+	case models.RotationInvalid:
+		return "", jwt.ErrTokenRevoked
+	}
+	return old.FamilyID, nil
+}
+
+// IsActive reports whether a refresh token was issued and is neither revoked nor expired
+func (s *SessionService) IsActive(tokenID string) (bool, error) {
+	token, err := models.GetRefreshToken(tokenID)
+	if err != nil || token == nil {
+		return false, err
+	}
+	return token.RevokedAt == nil && time.Now().Before(token.ExpiresAt), nil
+}
+
+// Revoke ends the session of a refresh token
+func (s *SessionService) Revoke(tokenID string) error {
+	token, err := models.GetRefreshToken(tokenID)
+	if err != nil || token == nil {
+		return err
+	}
+	_, err = models.RevokeSession(token.UserID, token.FamilyID)
+	s.forget(token.FamilyID)
+	return err
+}
+
+// IsSessionActive reports whether a session exists and was not ended,
+// answering from the cache when the session was looked up recently
+func (s *SessionService) IsSessionActive(sessionID string) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.checkedAt) < sessionCacheTTL {
+		return cached.active, nil
+	}
+
+	active, err := models.IsSessionActive(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= sessionCacheSize {
+		s.evictExpired(now)
+	}
+	if len(s.cache) < sessionCacheSize {
+		s.cache[sessionID] = cachedSession{active: active, checkedAt: now}
+	}
+	return active, nil
+}
+
+// ListSessions returns the active sessions of a user, marking currentID as the current one
+func (s *SessionService) ListSessions(userID int, currentID string) ([]*models.Session, error) {
+	sessions, err := models.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a session of the user out
+func (s *SessionService) RevokeSession(userID int, id string) error {
+	revoked, err := models.RevokeSession(userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	s.forget(id)
+	return nil
+}
+
+// RevokeOtherSessions signs out every session of the user except currentID
+// and returns how many were ended
+func (s *SessionService) RevokeOtherSessions(userID int, currentID string) (int, error) {
+	ids, err := models.RevokeOtherSessions(userID, currentID)
+	if err != nil {
+		return 0, err
+	}
+	s.forget(ids...)
+	return len(ids), nil
+}
+
+// forget drops sessions from the cache so that their next use is checked against the database
+func (s *SessionService) forget(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.cache, id)
+	}
+}
+
+// evictExpired drops cache entries older than the TTL; the caller holds the lock
+func (s *SessionService) evictExpired(now time.Time) {
+	for id, cached := range s.cache {
+		if now.Sub(cached.checkedAt) >= sessionCacheTTL {
+			delete(s.cache, id)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/models"
+	"github.com/henok-tesfu/expense-manager/internal/totp"
+	"github.com/skip2/go-qrcode"
+)
+
+// recoveryCodeCount is the number of recovery codes a user gets
+const recoveryCodeCount = 10
+
+// recoveryCodeAlphabet leaves out characters that are easily confused
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+var (
+	// ErrTwoFactorEnabled is returned when enrolling a user who already has two-factor authentication
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming without starting an enrolment first
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication enrolment was not started")
+	// ErrTwoFactorNotEnabled is returned when disabling two-factor authentication of a user without it
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidTwoFactorCode is returned for wrong, reused or expired codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor authentication code")
+)
+
+// TwoFactorEnrollment is a started enrolment for the user to add to an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	// URI is the otpauth URI the QR code encodes
+	URI string `json:"otpauth_uri" example:"otpauth://totp/Expense%20Manager:jane@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Expense%20Manager"`
+	// QRCode is a PNG of the URI as a data URI
+	QRCode string `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgo..."`
+}
+
+// TwoFactorService manages TOTP two-factor authentication and recovery codes
+type TwoFactorService struct {
+	// Issuer names the application in authenticator apps
+	Issuer string
+}
+
+func NewTwoFactorService(issuer string) *TwoFactorService {
+	return &TwoFactorService{Issuer: issuer}
+}
+
+// Enroll starts an enrolment with a new secret, replacing any unconfirmed
+// one. It takes effect once confirmed with a code.
+func (ts *TwoFactorService) Enroll(userID int) (*TwoFactorEnrollment, error) {
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	started, err := models.SetPendingTOTPSecret(userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	uri := totp.URI(ts.Issuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm enables two-factor authentication with a first code from the
+// authenticator and returns the recovery codes, which are not shown again
+func (ts *TwoFactorService) Confirm(userID int, code string) ([]string, error) {
+	state, err := models.GetTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, ErrUserNotFound
+	}
+	if state.EnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+	if state.Secret == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Validate(*state.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := models.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks the second factor of a login: a code from the authenticator
+// or one of the recovery codes, which is used up
+func (ts *TwoFactorService) Verify(userID int, code string) error {
+	state, err := models.GetTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if state == nil || state.EnabledAt == nil || state.Secret == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if step, ok := totp.Validate(*state.Secret, code, time.Now()); ok {
+		// Each code works once, so that an observed code cannot be replayed
+		fresh, err := models.UseTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := models.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after
+// checking a code, and returns the new ones
+func (ts *TwoFactorService) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if err := ts.Verify(userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a code
+func (ts *TwoFactorService) Disable(userID int, code string) error {
+	if err := ts.Verify(userID, code); err != nil {
+		return err
+	}
+	return models.DisableTOTP(userID)
+}
+
+// newRecoveryCodes returns recovery codes such as "k7pm-x2qd" and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	random := make([]byte, 8)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		var b strings.Builder
+		for j, r := range random {
+			if j == 4 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryCodeAlphabet[int(r)%len(recoveryCodeAlphabet)])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes in recovery codes
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
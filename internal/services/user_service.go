@@ -1,44 +1,358 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/henok-tesfu/expense-manager/internal/jwt"
 	"github.com/henok-tesfu/expense-manager/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/henok-tesfu/expense-manager/internal/password"
+	"github.com/henok-tesfu/expense-manager/internal/rbac"
 )
 
-type UserService struct{}
+var (
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned for emails of other users, including deleted ones that were not purged yet
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrIncorrectPassword is returned when the current password given to confirm a change is wrong
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	// ErrAccountDisabled is returned for users whose account staff disabled
+	ErrAccountDisabled = errors.New("account is disabled")
+)
+
+// purgeBatchSize is the number of deleted users PurgeDeletedUsers handles per call
+const purgeBatchSize = 100
 
-func NewUserService() *UserService {
-	return &UserService{}
+// defaultHomeCurrency matches the column default of users.home_currency
+const defaultHomeCurrency = "USD"
+
+// ProfileUpdate holds the profile fields to change; nil fields stay as they are
+type ProfileUpdate struct {
+	Username     *string
+	Email        *string
+	HomeCurrency *string
+	Locale       *string
+	TimeZone     *string
+	// CurrentPassword confirms a change of the email
+	CurrentPassword string
 }
 
-func (us *UserService) RegisterUser(username, email, password string) (*models.User, error) {
-	if len(password) < 6 {
-		return nil, errors.New("password must be at least 6 characters long")
+type UserService struct {
+	CategoryService   *CategoryService
+	AttachmentService *AttachmentService
+	SessionService    *SessionService
+	TwoFactorService  *TwoFactorService
+	LoginGuardService *LoginGuardService
+	Hasher            password.Hasher
+	Policy            *password.Policy
+}
+
+func NewUserService(categoryService *CategoryService, attachmentService *AttachmentService,
+	sessionService *SessionService, twoFactorService *TwoFactorService, loginGuardService *LoginGuardService,
+	hasher password.Hasher, policy *password.Policy) *UserService {
+	return &UserService{
+		CategoryService:   categoryService,
+		AttachmentService: attachmentService,
+		SessionService:    sessionService,
+		TwoFactorService:  twoFactorService,
+		LoginGuardService: loginGuardService,
+		Hasher:            hasher,
+		Policy:            policy,
+	}
+}
+
+// RegisterUser creates an account; an empty homeCurrency defaults to USD
+func (us *UserService) RegisterUser(username, email, password, homeCurrency string) (*models.User, error) {
+	if taken, _ := models.EmailExists(email); taken {
+		return nil, ErrEmailTaken
+	}
+
+	if err := us.Policy.Check(password, username, email); err != nil {
+		return nil, err
+	}
+
+	if homeCurrency == "" {
+		homeCurrency = defaultHomeCurrency
 	}
 
-	if user, _ := models.GetUserByEmail(email); user != nil {
-		return nil, errors.New("email already registered")
+	passwordHash, err := us.Hasher.Hash(password)
+	if err != nil {
+		return nil, err
 	}
 
-	userID, err := models.RegisterUser(username, email, password)
+	userID, err := models.RegisterUser(username, email, passwordHash, homeCurrency)
 	if err != nil {
 		return nil, err
 	}
 
+	// The account is usable without categories, so a failed seed is not fatal
+	if err := us.CategoryService.SeedDefaultCategories(int(userID)); err != nil {
+		log.Printf("Failed to seed default categories for user %d: %v", userID, err)
+	}
+
 	return models.GetUserByID(int(userID))
 }
 
+// AuthenticateUser checks the email and password of a user. A password
+// hash with an outdated algorithm or parameters is replaced by a current one.
+// Disabled users get ErrAccountDisabled, but only with the right password.
 func (us *UserService) AuthenticateUser(email, password string) (*models.User, error) {
 	user, err := models.GetUserByEmail(email)
 	if err != nil || user == nil {
 		return nil, errors.New("invalid email or password")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+	if ok, err := us.Hasher.Verify(password, user.Password); err != nil || !ok {
+		if err != nil {
+			log.Printf("Failed to verify the password of user %d: %v", user.ID, err)
+		}
 		return nil, errors.New("invalid email or password")
 	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	// The login succeeds with the old hash, so a failed upgrade is only logged
+	if us.Hasher.NeedsRehash(user.Password) {
+		if err := us.rehash(user, password); err != nil {
+			log.Printf("Failed to upgrade the password hash of user %d: %v", user.ID, err)
+		}
+	}
 
 	return user, nil
 }
+
+// rehash replaces the password hash of a user with one from the current hasher
+func (us *UserService) rehash(user *models.User, password string) error {
+	passwordHash, err := us.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	replaced, err := models.ReplacePasswordHash(user.ID, user.Password, passwordHash)
+	if err != nil {
+		return err
+	}
+	if replaced {
+		user.Password = passwordHash
+	}
+	return nil
+}
+
+func (us *UserService) GetUser(id int) (*models.User, error) {
+	user, err := models.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// AccessGrant returns what the access tokens of a user allow: whether they
+// verified their email, and their roles and permissions
+func (us *UserService) AccessGrant(id int) (jwt.AccessGrant, error) {
+	user, err := us.GetUser(id)
+	if err != nil {
+		return jwt.AccessGrant{}, err
+	}
+	if user.DisabledAt != nil {
+		return jwt.AccessGrant{}, ErrAccountDisabled
+	}
+
+	roles, err := models.ListUserRoles(id)
+	if err != nil {
+		return jwt.AccessGrant{}, err
+	}
+	grant := jwt.AccessGrant{EmailVerified: user.EmailVerifiedAt != nil}
+	if len(roles) > 0 {
+		grant.Roles = roles
+		grant.Permissions = rbac.Permissions(roles)
+	}
+	return grant, nil
+}
+
+// SetHomeCurrency changes the currency the user's reports default to
+func (us *UserService) SetHomeCurrency(id int, currency string) (*models.User, error) {
+	if _, err := us.GetUser(id); err != nil {
+		return nil, err
+	}
+
+	if err := models.UpdateHomeCurrency(id, currency); err != nil {
+		return nil, err
+	}
+
+	return us.GetUser(id)
+}
+
+// UpdateProfile changes the profile of a user. A new email has to be
+// confirmed with the current password, checked like a login from device, and
+// verified again; emailChanged tells the caller to send the verification email.
+func (us *UserService) UpdateProfile(ctx context.Context, id int, device jwt.Device, update ProfileUpdate) (user *models.User, emailChanged bool, err error) {
+	user, err = us.GetUser(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if update.Email != nil && !strings.EqualFold(*update.Email, user.Email) {
+		if err := us.confirm(ctx, user, device, update.CurrentPassword, nil); err != nil {
+			return nil, false, err
+		}
+		taken, err := models.EmailExists(*update.Email)
+		if err != nil {
+			return nil, false, err
+		}
+		if taken {
+			return nil, false, ErrEmailTaken
+		}
+		emailChanged = true
+	}
+
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{
+		{update.Username, &user.Username},
+		{update.HomeCurrency, &user.HomeCurrency},
+		{update.Locale, &user.Locale},
+		{update.TimeZone, &user.TimeZone},
+	} {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+	if err := models.UpdateProfile(user); err != nil {
+		return nil, false, err
+	}
+	if emailChanged {
+		if err := models.ChangeEmail(id, *update.Email); err != nil {
+			return nil, false, err
+		}
+	}
+
+	user, err = us.GetUser(id)
+	return user, emailChanged, err
+}
+
+// ChangePassword sets a new password after checking the current one like a
+// login from device, and signs the user out of every session except
+// currentSessionID
+func (us *UserService) ChangePassword(ctx context.Context, id int, device jwt.Device, currentSessionID, currentPassword, newPassword string) error {
+	user, err := us.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if err := us.confirm(ctx, user, device, currentPassword, nil); err != nil {
+		return err
+	}
+	if err := us.Policy.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	passwordHash, err := us.Hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	sessionIDs, err := models.ChangePassword(id, passwordHash, currentSessionID)
+	if err != nil {
+		return err
+	}
+	us.SessionService.forget(sessionIDs...)
+	return nil
+}
+
+// DeleteAccount deletes a user at once as far as logging in and the API are
+// concerned: every session and token stops working. Their expenses,
+// attachments and other data are purged after a grace period. As it cannot
+// be undone, it needs the current password and, with two-factor
+// authentication, a code, which are checked like a login from device.
+func (us *UserService) DeleteAccount(ctx context.Context, id int, device jwt.Device, currentPassword, code string) error {
+	user, err := us.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if err := us.confirm(ctx, user, device, currentPassword, &code); err != nil {
+		return err
+	}
+
+	sessionIDs, ok, err := models.SoftDeleteUser(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+	us.SessionService.forget(sessionIDs...)
+	return nil
+}
+
+// PurgeDeletedUsers permanently deletes the users deleted before
+// deletedBefore with all their data and attachment files, and returns how
+// many it purged. A user whose files cannot all be deleted is kept, so that
+// the next run tries again.
+func (us *UserService) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ids, err := models.ListUsersDeletedBefore(deletedBefore, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := us.AttachmentService.deleteUserBlobs(ctx, id); err != nil {
+			log.Printf("Failed to delete the attachment files of deleted user %d: %v", id, err)
+			continue
+		}
+		if err := models.PurgeUser(id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// confirm checks the current password given to confirm a change and, when
+// code is not nil and the user has two-factor authentication, the code. The
+// check counts as a login attempt, so that a stolen access token cannot be
+// used to guess the password without limit.
+func (us *UserService) confirm(ctx context.Context, user *models.User, device jwt.Device, password string, code *string) error {
+	if _, err := us.LoginGuardService.Attempt(ctx, user.Email, device); err != nil {
+		return err
+	}
+
+	if err := us.checkPassword(user, password); err != nil {
+		if errors.Is(err, ErrIncorrectPassword) {
+			us.LoginGuardService.Failed(user.Email, device, models.FailedLoginInvalidCredentials)
+		}
+		return err
+	}
+	if code != nil && user.TwoFactorEnabled {
+		if err := us.TwoFactorService.Verify(user.ID, *code); err != nil {
+			if errors.Is(err, ErrInvalidTwoFactorCode) {
+				us.LoginGuardService.Failed(user.Email, device, models.FailedLoginInvalidCode)
+			}
+			return err
+		}
+	}
+
+	if err := us.LoginGuardService.Succeeded(ctx, user.Email, device); err != nil {
+		log.Printf("Failed to reset failed logins of user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// checkPassword returns ErrIncorrectPassword unless password is the user's password
+func (us *UserService) checkPassword(user *models.User, password string) error {
+	ok, err := us.Hasher.Verify(password, user.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
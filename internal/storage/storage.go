@@ -0,0 +1,40 @@
+// Package storage stores binary objects such as receipt files behind a
+// common interface, with a local-filesystem and an S3-compatible backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when no object is stored under a key
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for keys that are empty or could escape the storage root
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage keeps objects under slash-separated keys such as "receipts/1/2/abc"
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey rejects keys that are empty, absolute or contain "." or ".." segments
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// Package takeout writes the personal data export of a user: a ZIP archive
+// of JSON and CSV files with the original attachment files alongside.
+package takeout
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/export"
+)
+
+// Archive writes files into a ZIP archive one after another. Close must be
+// called to complete the archive.
+type Archive struct {
+	zip *zip.Writer
+}
+
+// NewArchive returns an archive writing to w
+func NewArchive(w io.Writer) *Archive {
+	return &Archive{zip: zip.NewWriter(w)}
+}
+
+// Create starts a file in the archive; it is written until the next file is started
+func (a *Archive) Create(name string) (io.Writer, error) {
+	return a.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now().UTC(),
+	})
+}
+
+// JSON adds a file holding v as indented JSON
+func (a *Archive) JSON(name string, v interface{}) error {
+	w, err := a.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// CSV adds a file with a header and rows, escaping text that spreadsheets
+// would run as a formula
+func (a *Archive) CSV(name string, header []string, rows [][]string) error {
+	w, err := a.Create(name)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		fields := make([]string, len(row))
+		for i, field := range row {
+			fields[i] = export.EscapeFormula(field)
+		}
+		if err := writer.Write(fields); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// JSONArray starts a file holding a JSON array whose elements are written
+// one at a time, so that large lists never sit in memory
+func (a *Archive) JSONArray(name string) (*ArrayWriter, error) {
+	w, err := a.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+	return &ArrayWriter{w: w}, nil
+}
+
+// Close completes the archive without closing the underlying writer
+func (a *Archive) Close() error {
+	return a.zip.Close()
+}
+
+// ArrayWriter writes the elements of a JSON array. Close must be called to
+// complete the array before the next file of the archive is started.
+type ArrayWriter struct {
+	w     io.Writer
+	count int
+}
+
+// Write adds an element to the array
+func (aw *ArrayWriter) Write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := "\n  "
+	if aw.count > 0 {
+		separator = "," + separator
+	}
+	if _, err := io.WriteString(aw.w, separator); err != nil {
+		return err
+	}
+	aw.count++
+	_, err = aw.w.Write(data)
+	return err
+}
+
+// Close completes the array
+func (aw *ArrayWriter) Close() error {
+	end := "]\n"
+	if aw.count > 0 {
+		end = "\n]\n"
+	}
+	_, err := io.WriteString(aw.w, end)
+	return err
+}
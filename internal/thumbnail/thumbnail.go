@@ -0,0 +1,96 @@
+// Package thumbnail produces small JPEG previews of uploaded images
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Register the decoders for the image formats accepted as receipts
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	// quality is the JPEG quality of generated thumbnails
+	quality = 80
+	// maxPixels caps the size of images that are decoded. A small file can
+	// declare a huge image, and decoding allocates memory for every pixel.
+	maxPixels = 40_000_000
+)
+
+// ErrTooLarge is returned for images with more pixels than are decoded
+var ErrTooLarge = errors.New("image is too large for a thumbnail")
+
+// Generate decodes a JPEG, PNG or GIF image and returns it as a JPEG scaled
+// down to fit within maxSize×maxSize. Smaller images keep their size.
+// Transparent areas are flattened onto white.
+func Generate(r io.Reader, maxSize int) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The header gives the dimensions without decoding the pixels
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxPixels || config.Height > maxPixels ||
+		int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, fmt.Errorf("%w: %d×%d", ErrTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scale(src, maxSize), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scale shrinks src by averaging the source pixels covered by each target pixel
+func scale(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	targetWidth, targetHeight := width, height
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			targetWidth, targetHeight = maxSize, max(1, height*maxSize/width)
+		} else {
+			targetWidth, targetHeight = max(1, width*maxSize/height), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		y0 := bounds.Min.Y + y*height/targetHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/targetHeight)
+		for x := 0; x < targetWidth; x++ {
+			x0 := bounds.Min.X + x*width/targetWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/targetWidth)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					// Premultiplied colour over a white background
+					r += uint64(pr + 0xffff - pa)
+					g += uint64(pg + 0xffff - pa)
+					b += uint64(pb + 0xffff - pa)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff})
+		}
+	}
+	return dst
+}
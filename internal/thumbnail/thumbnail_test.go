@@ -0,0 +1,80 @@
+package thumbnail
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"runtime"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.NRGBA{R: 200, A: 0xff})
+		}
+	}
+	// A transparent corner is flattened onto white
+	src.Set(0, 0, color.NRGBA{})
+
+	thumb, err := Generate(bytes.NewReader(encodePNG(t, src)), 200)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	got, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if size := got.Bounds().Size(); size != image.Pt(200, 50) {
+		t.Errorf("thumbnail is %v, want 200×50", size)
+	}
+
+	small, err := Generate(bytes.NewReader(encodePNG(t, image.NewGray(image.Rect(0, 0, 20, 10)))), 200)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got, _ := jpeg.Decode(bytes.NewReader(small)); got.Bounds().Size() != image.Pt(20, 10) {
+		t.Errorf("small image thumbnail is %v, want it to keep its size", got.Bounds().Size())
+	}
+}
+
+func TestGenerateRejectsHugeImages(t *testing.T) {
+	// A tiny PNG whose header claims 30000×30000 pixels
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	binary.BigEndian.PutUint32(data[16:20], 30000)
+	binary.BigEndian.PutUint32(data[20:24], 30000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := Generate(bytes.NewReader(data), 200)
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Generate: err = %v, want ErrTooLarge", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("Generate allocated %d bytes for a rejected image", allocated)
+	}
+}
+
+func TestGenerateRejectsOtherFiles(t *testing.T) {
+	if _, err := Generate(bytes.NewReader([]byte("%PDF-1.7")), 200); err == nil {
+		t.Error("Generate of a PDF succeeded, want an error")
+	}
+}
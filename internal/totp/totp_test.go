@@ -0,0 +1,120 @@
+package totp
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238, appendix B, cut to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// Secrets are accepted in lower case, as some apps show them
+	if got, _ := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 1); got != "287082" {
+		t.Errorf("Code with a lower-case secret = %s, want 287082", got)
+	}
+	if _, err := Code("not base32!", 1); !errors.Is(err, ErrInvalidSecret) {
+		t.Errorf("Code with an invalid secret: err = %v, want ErrInvalidSecret", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name string
+		code string
+		step int64
+		ok   bool
+	}{
+		{"current step", "050471", current, true},
+		{"with a space", "050 471", current, true},
+		{"previous step", mustCode(t, current-1), current - 1, true},
+		{"next step", mustCode(t, current+1), current + 1, true},
+		{"two steps ago", mustCode(t, current-2), 0, false},
+		{"wrong code", "000000", 0, false},
+		{"too short", "05047", 0, false},
+		{"too long", "0504710", 0, false},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, tt.code, now)
+		if ok != tt.ok || step != tt.step {
+			t.Errorf("%s: Validate(%q) = %d, %v; want %d, %v", tt.name, tt.code, step, ok, tt.step, tt.ok)
+		}
+	}
+}
+
+func mustCode(t *testing.T, step int64) string {
+	t.Helper()
+	code, err := Code(rfcSecret, step)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	return code
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret %q has %d characters, want 32", secret, len(secret))
+	}
+	if _, err := Code(secret, 0); err != nil {
+		t.Errorf("Code with a generated secret: %v", err)
+	}
+	other, _ := GenerateSecret()
+	if other == secret {
+		t.Error("two generated secrets are equal")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Expense Manager", "jane+test@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse(%q): %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI %q is not an otpauth://totp URI", uri)
+	}
+	if parsed.Path != "/Expense Manager:jane+test@example.com" {
+		t.Errorf("label = %q", parsed.Path)
+	}
+	query := parsed.Query()
+	if query.Get("secret") != rfcSecret || query.Get("issuer") != "Expense Manager" ||
+		query.Get("digits") != "6" || query.Get("period") != "30" || query.Get("algorithm") != "SHA1" {
+		t.Errorf("query = %v", query)
+	}
+	if want := "issuer=Expense%20Manager"; !strings.Contains(parsed.RawQuery, want) {
+		t.Errorf("query %q does not encode the space as %%20", parsed.RawQuery)
+	}
+}
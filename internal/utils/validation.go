@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/henok-tesfu/expense-manager/internal/money"
+	"github.com/henok-tesfu/expense-manager/internal/recurrence"
 )
 
 // Validator instance
@@ -15,6 +17,29 @@ func RegisterCustomValidations() {
 	validate.RegisterValidation("is-cool", func(fl validator.FieldLevel) bool {
 		return fl.Field().String() == "cool"
 	})
+
+	// "money": a string such as "12.34 USD" that parses into a money.Amount
+	validate.RegisterValidation("money", func(fl validator.FieldLevel) bool {
+		_, err := money.Parse(fl.Field().String())
+		return err == nil
+	})
+
+	// "money_positive": like "money", but the amount must be greater than zero
+	validate.RegisterValidation("money_positive", func(fl validator.FieldLevel) bool {
+		amount, err := money.Parse(fl.Field().String())
+		return err == nil && amount.IsPositive()
+	})
+
+	// "currency": a known ISO 4217 currency code
+	validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return money.IsValidCurrency(fl.Field().String())
+	})
+
+	// "rrule": a recurrence rule in the supported RFC 5545 subset
+	validate.RegisterValidation("rrule", func(fl validator.FieldLevel) bool {
+		_, err := recurrence.Parse(fl.Field().String())
+		return err == nil
+	})
 }
 
 // FormatValidationErrors formats validation errors into a map
@@ -30,6 +55,10 @@ func FormatValidationErrors(err error) map[string]string {
 		switch tag {
 		case "required":
 			errors[field] = fmt.Sprintf("%s is required", field)
+		case "required_if":
+			errors[field] = fmt.Sprintf("%s is required when %s", field, param)
+		case "required_unless":
+			errors[field] = fmt.Sprintf("%s is required unless %s", field, param)
 		case "email":
 			errors[field] = fmt.Sprintf("%s must be a valid email address", field)
 		case "min":
@@ -40,6 +69,28 @@ func FormatValidationErrors(err error) map[string]string {
 			errors[field] = fmt.Sprintf("%s must be greater than or equal to %s", field, param)
 		case "lte":
 			errors[field] = fmt.Sprintf("%s must be less than or equal to %s", field, param)
+		case "gt":
+			errors[field] = fmt.Sprintf("%s must be greater than %s", field, param)
+		case "len":
+			errors[field] = fmt.Sprintf("%s must be exactly %s characters long", field, param)
+		case "datetime":
+			errors[field] = fmt.Sprintf("%s must be a date in the format %s", field, param)
+		case "oneof":
+			errors[field] = fmt.Sprintf("%s must be one of: %s", field, param)
+		case "hexcolor":
+			errors[field] = fmt.Sprintf("%s must be a hex colour such as #1E88E5", field)
+		case "money":
+			errors[field] = fmt.Sprintf("%s must be an amount with a valid currency, e.g. '12.34 USD'", field)
+		case "money_positive":
+			errors[field] = fmt.Sprintf("%s must be a positive amount with a valid currency, e.g. '12.34 USD'", field)
+		case "currency":
+			errors[field] = fmt.Sprintf("%s must be a valid ISO 4217 currency code", field)
+		case "rrule":
+			errors[field] = fmt.Sprintf("%s must be a recurrence rule such as FREQ=MONTHLY;BYMONTHDAY=1", field)
+		case "bcp47_language_tag":
+			errors[field] = fmt.Sprintf("%s must be a language tag such as en-US", field)
+		case "timezone":
+			errors[field] = fmt.Sprintf("%s must be a time zone such as Europe/Berlin", field)
 		case "is-cool":
 			errors[field] = fmt.Sprintf("%s must be 'cool'", field)
 		default:
@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// AccountPurger permanently deletes accounts, with all their data and
+// attachment files, once a grace period has passed since they were deleted
+type AccountPurger struct {
+	UserService *services.UserService
+	GracePeriod time.Duration
+	Interval    time.Duration
+}
+
+// NewAccountPurger creates a new AccountPurger
+func NewAccountPurger(userService *services.UserService, gracePeriod, interval time.Duration) *AccountPurger {
+	return &AccountPurger{
+		UserService: userService,
+		GracePeriod: gracePeriod,
+		Interval:    interval,
+	}
+}
+
+// Run purges right away and then once per interval until ctx is cancelled
+func (p *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.UserService.PurgeDeletedUsers(ctx, time.Now().Add(-p.GracePeriod).UTC())
+		if err != nil {
+			log.Println("Failed to purge deleted accounts:", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
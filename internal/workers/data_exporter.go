@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// DataExporter builds requested data exports in the background and removes
+// them once they expire
+type DataExporter struct {
+	DataExportService *services.DataExportService
+	Interval          time.Duration
+}
+
+// NewDataExporter creates a new DataExporter
+func NewDataExporter(dataExportService *services.DataExportService, interval time.Duration) *DataExporter {
+	return &DataExporter{
+		DataExportService: dataExportService,
+		Interval:          interval,
+	}
+}
+
+// Run builds every pending export and cleans up right away, and then once
+// per interval until ctx is cancelled
+func (e *DataExporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.DataExportService.Cleanup(ctx); err != nil {
+			log.Println("Failed to clean up data exports:", err)
+		}
+		for ctx.Err() == nil {
+			processed, err := e.DataExportService.ProcessNext(ctx)
+			if err != nil {
+				log.Println("Failed to process data export:", err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
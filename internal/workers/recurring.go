@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/henok-tesfu/expense-manager/internal/services"
+)
+
+// RecurringMaterializer turns due occurrences of recurring expenses into expenses in the background
+type RecurringMaterializer struct {
+	RecurringService *services.RecurringService
+	Interval         time.Duration
+}
+
+// NewRecurringMaterializer creates a new RecurringMaterializer
+func NewRecurringMaterializer(recurringService *services.RecurringService, interval time.Duration) *RecurringMaterializer {
+	return &RecurringMaterializer{
+		RecurringService: recurringService,
+		Interval:         interval,
+	}
+}
+
+// Run materializes right away, catching up on anything missed while the
+// server was down, and then once per interval until ctx is cancelled
+func (m *RecurringMaterializer) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.RecurringService.MaterializeDue(time.Now().UTC()); err != nil {
+			log.Println("Failed to materialize recurring expenses:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
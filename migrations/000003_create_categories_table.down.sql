@@ -0,0 +1,12 @@
+ALTER TABLE expenses
+    ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT '' AFTER date;
+
+UPDATE expenses e
+    JOIN categories c ON c.id = e.category_id
+SET e.category = c.name;
+
+ALTER TABLE expenses
+    DROP FOREIGN KEY fk_expenses_category,
+    DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS categories;
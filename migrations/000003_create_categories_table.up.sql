@@ -0,0 +1,28 @@
+CREATE TABLE categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    parent_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    colour CHAR(7) NOT NULL DEFAULT '#9E9E9E',
+    icon VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_categories_user (user_id),
+    CONSTRAINT fk_categories_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+ALTER TABLE expenses
+    ADD COLUMN category_id INT NULL AFTER date,
+    ADD CONSTRAINT fk_expenses_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
+
+-- Every distinct free-text category of a user becomes one of their categories
+INSERT INTO categories (user_id, name)
+SELECT DISTINCT user_id, TRIM(category) FROM expenses WHERE TRIM(category) <> '';
+
+UPDATE expenses e
+    JOIN categories c ON c.user_id = e.user_id AND c.name = TRIM(e.category)
+SET e.category_id = c.id;
+
+ALTER TABLE expenses
+    DROP COLUMN category;
@@ -0,0 +1,8 @@
+ALTER TABLE expenses
+    DROP FOREIGN KEY fk_expenses_recurring,
+    DROP INDEX uq_expenses_occurrence,
+    DROP COLUMN occurrence_date,
+    DROP COLUMN recurring_expense_id;
+
+DROP TABLE IF EXISTS recurring_expense_exceptions;
+DROP TABLE IF EXISTS recurring_expenses;
@@ -0,0 +1,42 @@
+CREATE TABLE recurring_expenses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    amount DECIMAL(19, 4) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    category_id INT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    payee VARCHAR(255) NOT NULL DEFAULT '',
+    rrule VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    materialized_through DATE NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_recurring_expenses_due (active, materialized_through),
+    CONSTRAINT fk_recurring_expenses_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_recurring_expenses_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+);
+
+CREATE TABLE recurring_expense_exceptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    recurring_expense_id INT NOT NULL,
+    occurrence_date DATE NOT NULL,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    amount DECIMAL(19, 4) NULL,
+    currency CHAR(3) NULL,
+    date DATE NULL,
+    category_id INT NULL,
+    description VARCHAR(255) NULL,
+    payee VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_recurring_exceptions_occurrence (recurring_expense_id, occurrence_date),
+    CONSTRAINT fk_recurring_exceptions_recurring FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses(id) ON DELETE CASCADE,
+    CONSTRAINT fk_recurring_exceptions_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+);
+
+ALTER TABLE expenses
+    ADD COLUMN recurring_expense_id INT NULL AFTER payee,
+    ADD COLUMN occurrence_date DATE NULL AFTER recurring_expense_id,
+    ADD UNIQUE KEY uq_expenses_occurrence (recurring_expense_id, occurrence_date),
+    ADD CONSTRAINT fk_expenses_recurring FOREIGN KEY (recurring_expense_id) REFERENCES recurring_expenses(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN home_currency;
+
+DROP TABLE IF EXISTS exchange_rates;
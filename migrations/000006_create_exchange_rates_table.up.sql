@@ -0,0 +1,13 @@
+CREATE TABLE exchange_rates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    date DATE NOT NULL,
+    base CHAR(3) NOT NULL,
+    quote CHAR(3) NOT NULL,
+    rate DECIMAL(24, 10) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_exchange_rates_pair_date (base, quote, date)
+);
+
+ALTER TABLE users
+    ADD COLUMN home_currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER password;
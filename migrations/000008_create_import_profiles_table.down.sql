@@ -0,0 +1,5 @@
+ALTER TABLE expenses
+    DROP INDEX uq_expenses_import_hash,
+    DROP COLUMN import_hash;
+
+DROP TABLE IF EXISTS import_profiles;
@@ -0,0 +1,26 @@
+CREATE TABLE import_profiles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    delimiter CHAR(1) NOT NULL DEFAULT ',',
+    has_header BOOLEAN NOT NULL DEFAULT TRUE,
+    skip_rows INT NOT NULL DEFAULT 0,
+    date_column VARCHAR(100) NOT NULL,
+    date_format VARCHAR(50) NOT NULL,
+    amount_sign ENUM('negative_is_expense', 'positive_is_expense', 'debit_credit') NOT NULL,
+    amount_column VARCHAR(100) NOT NULL DEFAULT '',
+    debit_column VARCHAR(100) NOT NULL DEFAULT '',
+    credit_column VARCHAR(100) NOT NULL DEFAULT '',
+    decimal_separator CHAR(1) NOT NULL DEFAULT '.',
+    description_columns JSON NOT NULL,
+    payee_column VARCHAR(100) NOT NULL DEFAULT '',
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_import_profiles_user (user_id),
+    CONSTRAINT fk_import_profiles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE expenses
+    ADD COLUMN import_hash CHAR(64) NULL AFTER occurrence_date,
+    ADD UNIQUE KEY uq_expenses_import_hash (user_id, import_hash);
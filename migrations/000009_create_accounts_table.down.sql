@@ -0,0 +1,5 @@
+ALTER TABLE expenses
+    DROP FOREIGN KEY fk_expenses_account,
+    DROP COLUMN account_id;
+
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,4 @@
+ALTER TABLE refresh_tokens
+    DROP INDEX idx_refresh_tokens_family,
+    DROP COLUMN replaced_by,
+    DROP COLUMN family_id;
@@ -0,0 +1,10 @@
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id CHAR(32) NOT NULL DEFAULT '' AFTER user_id,
+    ADD COLUMN replaced_by CHAR(32) NULL AFTER revoked_at;
+
+-- Tokens issued before rotation each start their own family
+UPDATE refresh_tokens SET family_id = id;
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN family_id DROP DEFAULT,
+    ADD INDEX idx_refresh_tokens_family (family_id);
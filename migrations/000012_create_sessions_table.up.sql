@@ -0,0 +1,19 @@
+CREATE TABLE sessions (
+    id CHAR(32) PRIMARY KEY,
+    user_id INT NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NULL,
+    INDEX idx_sessions_user (user_id),
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Every existing refresh token family becomes a session
+INSERT INTO sessions (id, user_id, created_at, last_used_at, expires_at, revoked_at)
+SELECT family_id, MIN(user_id), MIN(created_at), MAX(created_at), MAX(expires_at),
+    CASE WHEN SUM(revoked_at IS NULL) = 0 THEN MAX(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL AFTER email;
+
+-- Accounts created before verification existed are trusted
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE email_verification_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_email_verification_tokens_hash (token_hash),
+    INDEX idx_email_verification_tokens_user (user_id, created_at),
+    CONSTRAINT fk_email_verification_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
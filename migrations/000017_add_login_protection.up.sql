@@ -0,0 +1,20 @@
+CREATE TABLE login_attempt_counters (
+    counter_key VARCHAR(300) PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    INDEX idx_login_attempt_counters_expires (expires_at)
+);
+
+CREATE TABLE failed_logins (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NULL,
+    email VARCHAR(255) NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    reason VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_failed_logins_email (email, created_at),
+    INDEX idx_failed_logins_ip (ip, created_at),
+    CONSTRAINT fk_failed_logins_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP INDEX idx_users_deleted_at,
+    DROP COLUMN locale,
+    DROP COLUMN time_zone,
+    DROP COLUMN deleted_at;
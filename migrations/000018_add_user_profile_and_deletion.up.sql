@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT 'en-US' AFTER home_currency,
+    ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC' AFTER locale,
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD INDEX idx_users_deleted_at (deleted_at);
@@ -0,0 +1,15 @@
+CREATE TABLE data_exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    storage_key VARCHAR(255) NULL,
+    size BIGINT NULL,
+    error VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME NULL,
+    completed_at DATETIME NULL,
+    expires_at DATETIME NULL,
+    INDEX idx_data_exports_user (user_id, created_at),
+    INDEX idx_data_exports_status (status, id),
+    CONSTRAINT fk_data_exports_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
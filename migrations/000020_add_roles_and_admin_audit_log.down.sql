@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS admin_audit_log;
+
+ALTER TABLE sessions
+    DROP FOREIGN KEY fk_sessions_impersonator,
+    DROP COLUMN impersonator_id;
+
+ALTER TABLE users
+    DROP COLUMN disabled_at;
+
+DROP TABLE IF EXISTS user_roles;
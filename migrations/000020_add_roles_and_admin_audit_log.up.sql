@@ -0,0 +1,31 @@
+CREATE TABLE user_roles (
+    user_id INT NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    granted_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role),
+    CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_roles_granted_by FOREIGN KEY (granted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE users
+    ADD COLUMN disabled_at DATETIME NULL;
+
+ALTER TABLE sessions
+    ADD COLUMN impersonator_id INT NULL,
+    ADD CONSTRAINT fk_sessions_impersonator FOREIGN KEY (impersonator_id) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE admin_audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id INT NULL,
+    action VARCHAR(32) NOT NULL,
+    target_user_id INT NULL,
+    detail VARCHAR(255) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_admin_audit_log_actor (actor_id, created_at),
+    INDEX idx_admin_audit_log_target (target_user_id, created_at),
+    CONSTRAINT fk_admin_audit_log_actor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_admin_audit_log_target FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
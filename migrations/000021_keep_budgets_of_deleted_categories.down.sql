@@ -0,0 +1,3 @@
+ALTER TABLE budgets
+    DROP FOREIGN KEY fk_budgets_category,
+    ADD CONSTRAINT fk_budgets_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE;
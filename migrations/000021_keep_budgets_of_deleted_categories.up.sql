@@ -0,0 +1,6 @@
+-- Deleting a category moves its budgets to another category, so the foreign
+-- key no longer deletes them. SET NULL rather than RESTRICT keeps purging a
+-- user, which cascades to both tables, independent of the order of the cascades.
+ALTER TABLE budgets
+    DROP FOREIGN KEY fk_budgets_category,
+    ADD CONSTRAINT fk_budgets_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;